@@ -70,6 +70,38 @@ func GenerateSessionID() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
+// AccessTokenPrefix starts every personal access token so they are easy to spot, in logs or leaked in a repo
+const AccessTokenPrefix = "bt_"
+
+// GenerateAccessToken creates a random personal access token
+func GenerateAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAccessToken is what gets stored for a token. Tokens are long and random so no salt is needed.
+func HashAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetAccessTokenFromRequest reads a personal access token from a Bearer header or, for
+// e-reader apps that only do Basic auth, from the password with any username
+func GetAccessTokenFromRequest(r *http.Request) (string, error) {
+	if _, password, ok := r.BasicAuth(); ok && password != "" {
+		return password, nil
+	}
+	authHeader := r.Header.Get("Authorization")
+	parts := strings.Split(authHeader, " ")
+	if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" && strings.HasPrefix(parts[1], AccessTokenPrefix) {
+		return parts[1], nil
+	}
+	return "", errors.New("access token not found in request")
+}
+
 // GetSessionIDFromRequest extracts the session ID from the request
 func GetSessionIDFromRequest(r *http.Request) (string, error) {
 	authHeader := r.Header.Get("Authorization")
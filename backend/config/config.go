@@ -4,6 +4,13 @@ import "os"
 
 var FRONTEND_HOSTNAME string
 
+// CALIBRE_LIBRARY_DIR is where Calibre libraries on the server can be imported from by path.
+// When it is not set only uploaded metadata.db files can be imported.
+var CALIBRE_LIBRARY_DIR string
+
+// COVER_CACHE_DIR is where cover images fetched for year in review reports are kept, covers by default
+var COVER_CACHE_DIR string
+
 func init() {
 	// Default to development
 	FRONTEND_HOSTNAME = "http://localhost:3000"
@@ -13,4 +20,11 @@ func init() {
 		FRONTEND_HOSTNAME = "http://localhost:8080"
 
 	}
+
+	CALIBRE_LIBRARY_DIR = os.Getenv("CALIBRE_LIBRARY_DIR")
+
+	COVER_CACHE_DIR = os.Getenv("COVER_CACHE_DIR")
+	if COVER_CACHE_DIR == "" {
+		COVER_CACHE_DIR = "covers"
+	}
 }
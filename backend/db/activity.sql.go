@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: activity.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const listUserActivity = `-- name: ListUserActivity :many
+SELECT e.id, e.user_id, u.username, e.book_id, b.title, b.author, b.image_url, e.type, e.value, e.created_at
+FROM activity_events e
+JOIN users u ON u.id = e.user_id
+JOIN user_books ub ON ub.user_id = e.user_id AND ub.book_id = e.book_id
+JOIN books b ON b.id = e.book_id
+WHERE e.user_id = ?1
+    AND (ub.visibility != 'private' OR CAST(?2 AS BOOLEAN))
+    AND (e.created_at < datetime(?3) OR (e.created_at = datetime(?3) AND e.id < ?4))
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT ?5
+`
+
+type ListUserActivityParams struct {
+	UserID         int64       `json:"user_id"`
+	IncludePrivate bool        `json:"include_private"`
+	BeforeTime     interface{} `json:"before_time"`
+	BeforeID       int64       `json:"before_id"`
+	Limit          int64       `json:"limit"`
+}
+
+type ListUserActivityRow struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	Username  string        `json:"username"`
+	BookID    int64         `json:"book_id"`
+	Title     string        `json:"title"`
+	Author    string        `json:"author"`
+	ImageUrl  string        `json:"image_url"`
+	Type      string        `json:"type"`
+	Value     sql.NullInt64 `json:"value"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// one user's events before a position in the timeline, newest first. Events on private entries
+// are only included for the user's own timeline.
+func (q *Queries) ListUserActivity(ctx context.Context, arg ListUserActivityParams) ([]ListUserActivityRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserActivity,
+		arg.UserID,
+		arg.IncludePrivate,
+		arg.BeforeTime,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserActivityRow
+	for rows.Next() {
+		var i ListUserActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Username,
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+			&i.Type,
+			&i.Value,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
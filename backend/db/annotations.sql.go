@@ -0,0 +1,352 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: annotations.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countQuotes = `-- name: CountQuotes :one
+SELECT COUNT(*) FROM annotations WHERE user_id = ? AND type IN ('quote', 'highlight')
+`
+
+func (q *Queries) CountQuotes(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countQuotes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAnnotation = `-- name: CreateAnnotation :one
+INSERT INTO annotations (user_id, book_id, type, text, page, location, chapter, private, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateAnnotationParams struct {
+	UserID    int64          `json:"user_id"`
+	BookID    int64          `json:"book_id"`
+	Type      string         `json:"type"`
+	Text      string         `json:"text"`
+	Page      sql.NullInt64  `json:"page"`
+	Location  sql.NullString `json:"location"`
+	Chapter   sql.NullString `json:"chapter"`
+	Private   bool           `json:"private"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (q *Queries) CreateAnnotation(ctx context.Context, arg CreateAnnotationParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createAnnotation,
+		arg.UserID,
+		arg.BookID,
+		arg.Type,
+		arg.Text,
+		arg.Page,
+		arg.Location,
+		arg.Chapter,
+		arg.Private,
+		arg.CreatedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteAnnotation = `-- name: DeleteAnnotation :execrows
+DELETE FROM annotations WHERE id = ? AND user_id = ? AND book_id = ?
+`
+
+type DeleteAnnotationParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) DeleteAnnotation(ctx context.Context, arg DeleteAnnotationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAnnotation, arg.ID, arg.UserID, arg.BookID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAnnotation = `-- name: GetAnnotation :one
+SELECT id, user_id, book_id, type, text, page, location, created_at, source, source_key, chapter, private, updated_at
+FROM annotations
+WHERE id = ? AND user_id = ?
+`
+
+type GetAnnotationParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetAnnotation(ctx context.Context, arg GetAnnotationParams) (Annotation, error) {
+	row := q.db.QueryRowContext(ctx, getAnnotation, arg.ID, arg.UserID)
+	var i Annotation
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.Type,
+		&i.Text,
+		&i.Page,
+		&i.Location,
+		&i.CreatedAt,
+		&i.Source,
+		&i.SourceKey,
+		&i.Chapter,
+		&i.Private,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRandomQuote = `-- name: GetRandomQuote :one
+SELECT a.id, a.user_id, a.book_id, a.type, a.text, a.page, a.location, a.chapter, a.private, a.source, a.created_at, a.updated_at,
+    b.title AS book_title, b.author AS book_author
+FROM annotations a
+JOIN books b ON b.id = a.book_id
+WHERE a.user_id = ? AND a.type IN ('quote', 'highlight')
+ORDER BY RANDOM()
+LIMIT 1
+`
+
+type GetRandomQuoteRow struct {
+	ID         int64          `json:"id"`
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	Type       string         `json:"type"`
+	Text       string         `json:"text"`
+	Page       sql.NullInt64  `json:"page"`
+	Location   sql.NullString `json:"location"`
+	Chapter    sql.NullString `json:"chapter"`
+	Private    bool           `json:"private"`
+	Source     string         `json:"source"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	BookTitle  string         `json:"book_title"`
+	BookAuthor string         `json:"book_author"`
+}
+
+func (q *Queries) GetRandomQuote(ctx context.Context, userID int64) (GetRandomQuoteRow, error) {
+	row := q.db.QueryRowContext(ctx, getRandomQuote, userID)
+	var i GetRandomQuoteRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.Type,
+		&i.Text,
+		&i.Page,
+		&i.Location,
+		&i.Chapter,
+		&i.Private,
+		&i.Source,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.BookTitle,
+		&i.BookAuthor,
+	)
+	return i, err
+}
+
+const importAnnotation = `-- name: ImportAnnotation :execrows
+INSERT OR IGNORE INTO annotations (user_id, book_id, type, text, page, location, private, created_at, source, source_key)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type ImportAnnotationParams struct {
+	UserID    int64          `json:"user_id"`
+	BookID    int64          `json:"book_id"`
+	Type      string         `json:"type"`
+	Text      string         `json:"text"`
+	Page      sql.NullInt64  `json:"page"`
+	Location  sql.NullString `json:"location"`
+	Private   bool           `json:"private"`
+	CreatedAt time.Time      `json:"created_at"`
+	Source    string         `json:"source"`
+	SourceKey sql.NullString `json:"source_key"`
+}
+
+func (q *Queries) ImportAnnotation(ctx context.Context, arg ImportAnnotationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, importAnnotation,
+		arg.UserID,
+		arg.BookID,
+		arg.Type,
+		arg.Text,
+		arg.Page,
+		arg.Location,
+		arg.Private,
+		arg.CreatedAt,
+		arg.Source,
+		arg.SourceKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAnnotations = `-- name: ListAnnotations :many
+SELECT id, user_id, book_id, type, text, page, location, created_at, source, source_key, chapter, private, updated_at
+FROM annotations
+WHERE user_id = ? AND book_id = ?
+ORDER BY COALESCE(page, 0), CAST(location AS INTEGER), id
+`
+
+type ListAnnotationsParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+// in reading order, CAST takes the start of a location range like 170-172
+func (q *Queries) ListAnnotations(ctx context.Context, arg ListAnnotationsParams) ([]Annotation, error) {
+	rows, err := q.db.QueryContext(ctx, listAnnotations, arg.UserID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Annotation
+	for rows.Next() {
+		var i Annotation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.Type,
+			&i.Text,
+			&i.Page,
+			&i.Location,
+			&i.CreatedAt,
+			&i.Source,
+			&i.SourceKey,
+			&i.Chapter,
+			&i.Private,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuotes = `-- name: ListQuotes :many
+SELECT a.id, a.user_id, a.book_id, a.type, a.text, a.page, a.location, a.chapter, a.private, a.source, a.created_at, a.updated_at,
+    b.title AS book_title, b.author AS book_author
+FROM annotations a
+JOIN books b ON b.id = a.book_id
+WHERE a.user_id = ?1 AND a.type IN ('quote', 'highlight') AND a.id < ?2
+ORDER BY a.id DESC
+LIMIT ?3
+`
+
+type ListQuotesParams struct {
+	UserID   int64 `json:"user_id"`
+	BeforeID int64 `json:"before_id"`
+	Limit    int64 `json:"limit"`
+}
+
+type ListQuotesRow struct {
+	ID         int64          `json:"id"`
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	Type       string         `json:"type"`
+	Text       string         `json:"text"`
+	Page       sql.NullInt64  `json:"page"`
+	Location   sql.NullString `json:"location"`
+	Chapter    sql.NullString `json:"chapter"`
+	Private    bool           `json:"private"`
+	Source     string         `json:"source"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	BookTitle  string         `json:"book_title"`
+	BookAuthor string         `json:"book_author"`
+}
+
+// quotes and highlights across the whole library, newest first, paged by id
+func (q *Queries) ListQuotes(ctx context.Context, arg ListQuotesParams) ([]ListQuotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listQuotes, arg.UserID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListQuotesRow
+	for rows.Next() {
+		var i ListQuotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.Type,
+			&i.Text,
+			&i.Page,
+			&i.Location,
+			&i.Chapter,
+			&i.Private,
+			&i.Source,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.BookTitle,
+			&i.BookAuthor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAnnotation = `-- name: UpdateAnnotation :execrows
+UPDATE annotations
+SET type = ?, text = ?, page = ?, location = ?, chapter = ?, private = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND book_id = ?
+`
+
+type UpdateAnnotationParams struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text"`
+	Page     sql.NullInt64  `json:"page"`
+	Location sql.NullString `json:"location"`
+	Chapter  sql.NullString `json:"chapter"`
+	Private  bool           `json:"private"`
+	ID       int64          `json:"id"`
+	UserID   int64          `json:"user_id"`
+	BookID   int64          `json:"book_id"`
+}
+
+func (q *Queries) UpdateAnnotation(ctx context.Context, arg UpdateAnnotationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAnnotation,
+		arg.Type,
+		arg.Text,
+		arg.Page,
+		arg.Location,
+		arg.Chapter,
+		arg.Private,
+		arg.ID,
+		arg.UserID,
+		arg.BookID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
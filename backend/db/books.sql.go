@@ -38,7 +38,7 @@ func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (int64,
 }
 
 const createUserBook = `-- name: CreateUserBook :exec
-INSERT INTO user_books (user_id, book_id) VALUES (?, ?)
+INSERT INTO user_books (user_id, book_id, added_at, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 `
 
 type CreateUserBookParams struct {
@@ -83,10 +83,22 @@ SELECT
     ub.user_id,
     ub.book_id,
     ub.start_date,
-    ub.progress,
+    CAST(COALESCE((
+        SELECT CASE
+            WHEN pe.unit = 'percent' THEN pe.value
+            WHEN pe.total > 0 THEN pe.value * 100 / pe.total
+        END
+        FROM progress_events pe
+        WHERE pe.read_id = (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) AS INTEGER) AS progress,
     ub.finish_date,
     ub.rating,
     ub.review,
+    ub.version,
+    ub.updated_at,
+    ub.visibility,
     b.isbn,
     b.title,
     b.description,
@@ -106,10 +118,13 @@ type GetUserBookRow struct {
 	UserID      int64          `json:"user_id"`
 	BookID      int64          `json:"book_id"`
 	StartDate   sql.NullTime   `json:"start_date"`
-	Progress    sql.NullInt64  `json:"progress"`
+	Progress    int64          `json:"progress"`
 	FinishDate  sql.NullTime   `json:"finish_date"`
 	Rating      sql.NullInt64  `json:"rating"`
 	Review      sql.NullString `json:"review"`
+	Version     int64          `json:"version"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	Visibility  string         `json:"visibility"`
 	Isbn        string         `json:"isbn"`
 	Title       string         `json:"title"`
 	Description string         `json:"description"`
@@ -128,6 +143,9 @@ func (q *Queries) GetUserBook(ctx context.Context, arg GetUserBookParams) (GetUs
 		&i.FinishDate,
 		&i.Rating,
 		&i.Review,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.Visibility,
 		&i.Isbn,
 		&i.Title,
 		&i.Description,
@@ -179,7 +197,16 @@ SELECT
     b.description,
     b.author,
     b.image_url,
-    ub.progress,
+    CAST(COALESCE((
+        SELECT CASE
+            WHEN pe.unit = 'percent' THEN pe.value
+            WHEN pe.total > 0 THEN pe.value * 100 / pe.total
+        END
+        FROM progress_events pe
+        WHERE pe.read_id = (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) AS INTEGER) AS progress,
     ub.start_date,
     ub.finish_date,
     ub.rating
@@ -196,7 +223,7 @@ type ListBooksByUserRow struct {
 	Description string        `json:"description"`
 	Author      string        `json:"author"`
 	ImageUrl    string        `json:"image_url"`
-	Progress    sql.NullInt64 `json:"progress"`
+	Progress    int64         `json:"progress"`
 	StartDate   sql.NullTime  `json:"start_date"`
 	FinishDate  sql.NullTime  `json:"finish_date"`
 	Rating      sql.NullInt64 `json:"rating"`
@@ -237,7 +264,7 @@ func (q *Queries) ListBooksByUser(ctx context.Context, userID int64) ([]ListBook
 }
 
 const listUserBooks = `-- name: ListUserBooks :many
-SELECT user_id, book_id, start_date, progress, finish_date, rating, review FROM user_books WHERE user_id = ?
+SELECT user_id, book_id, start_date, progress, finish_date, rating, review, version, updated_at, added_at, visibility FROM user_books WHERE user_id = ?
 `
 
 func (q *Queries) ListUserBooks(ctx context.Context, userID int64) ([]UserBook, error) {
@@ -257,6 +284,10 @@ func (q *Queries) ListUserBooks(ctx context.Context, userID int64) ([]UserBook,
 			&i.FinishDate,
 			&i.Rating,
 			&i.Review,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.AddedAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -271,6 +302,20 @@ func (q *Queries) ListUserBooks(ctx context.Context, userID int64) ([]UserBook,
 	return items, nil
 }
 
+const touchUserBook = `-- name: TouchUserBook :exec
+UPDATE user_books SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = ? AND book_id = ?
+`
+
+type TouchUserBookParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) TouchUserBook(ctx context.Context, arg TouchUserBookParams) error {
+	_, err := q.db.ExecContext(ctx, touchUserBook, arg.UserID, arg.BookID)
+	return err
+}
+
 const updateBook = `-- name: UpdateBook :exec
 UPDATE books SET isbn = ?, title = ?, description = ?, author = ?, image_url = ? WHERE id = ?
 `
@@ -296,27 +341,36 @@ func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) error {
 	return err
 }
 
-const updateUserBook = `-- name: UpdateUserBook :exec
-UPDATE user_books SET progress = ? ,finish_date = ?, rating = ?, review = ? WHERE user_id = ? AND book_id = ?
+const updateUserBook = `-- name: UpdateUserBook :execrows
+UPDATE user_books
+SET start_date = ?, finish_date = ?, rating = ?, review = ?, visibility = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND book_id = ? AND version = ?
 `
 
 type UpdateUserBookParams struct {
-	Progress   sql.NullInt64  `json:"progress"`
+	StartDate  sql.NullTime   `json:"start_date"`
 	FinishDate sql.NullTime   `json:"finish_date"`
 	Rating     sql.NullInt64  `json:"rating"`
 	Review     sql.NullString `json:"review"`
+	Visibility string         `json:"visibility"`
 	UserID     int64          `json:"user_id"`
 	BookID     int64          `json:"book_id"`
+	Version    int64          `json:"version"`
 }
 
-func (q *Queries) UpdateUserBook(ctx context.Context, arg UpdateUserBookParams) error {
-	_, err := q.db.ExecContext(ctx, updateUserBook,
-		arg.Progress,
+func (q *Queries) UpdateUserBook(ctx context.Context, arg UpdateUserBookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserBook,
+		arg.StartDate,
 		arg.FinishDate,
 		arg.Rating,
 		arg.Review,
+		arg.Visibility,
 		arg.UserID,
 		arg.BookID,
+		arg.Version,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
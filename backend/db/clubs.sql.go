@@ -0,0 +1,691 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: clubs.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const addClubMember = `-- name: AddClubMember :exec
+INSERT INTO club_members (club_id, user_id, role)
+VALUES (?, ?, ?)
+ON CONFLICT (club_id, user_id) DO UPDATE SET role = excluded.role
+`
+
+type AddClubMemberParams struct {
+	ClubID int64  `json:"club_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) AddClubMember(ctx context.Context, arg AddClubMemberParams) error {
+	_, err := q.db.ExecContext(ctx, addClubMember, arg.ClubID, arg.UserID, arg.Role)
+	return err
+}
+
+const createClub = `-- name: CreateClub :one
+INSERT INTO clubs (name, description, created_by)
+VALUES (?, ?, ?)
+RETURNING id
+`
+
+type CreateClubParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedBy   int64  `json:"created_by"`
+}
+
+func (q *Queries) CreateClub(ctx context.Context, arg CreateClubParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createClub, arg.Name, arg.Description, arg.CreatedBy)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createClubInvite = `-- name: CreateClubInvite :exec
+INSERT INTO club_invites (club_id, user_id, role, invited_by)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (club_id, user_id) DO UPDATE SET role = excluded.role, invited_by = excluded.invited_by
+`
+
+type CreateClubInviteParams struct {
+	ClubID    int64  `json:"club_id"`
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role"`
+	InvitedBy int64  `json:"invited_by"`
+}
+
+func (q *Queries) CreateClubInvite(ctx context.Context, arg CreateClubInviteParams) error {
+	_, err := q.db.ExecContext(ctx, createClubInvite,
+		arg.ClubID,
+		arg.UserID,
+		arg.Role,
+		arg.InvitedBy,
+	)
+	return err
+}
+
+const createClubPost = `-- name: CreateClubPost :one
+INSERT INTO club_posts (section_id, user_id, parent_id, body)
+VALUES (?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateClubPostParams struct {
+	SectionID int64         `json:"section_id"`
+	UserID    int64         `json:"user_id"`
+	ParentID  sql.NullInt64 `json:"parent_id"`
+	Body      string        `json:"body"`
+}
+
+func (q *Queries) CreateClubPost(ctx context.Context, arg CreateClubPostParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createClubPost,
+		arg.SectionID,
+		arg.UserID,
+		arg.ParentID,
+		arg.Body,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createClubSection = `-- name: CreateClubSection :one
+INSERT INTO club_sections (club_id, book_id, title, start_at, end_at, deadline)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, club_id, book_id, title, start_at, end_at, deadline
+`
+
+type CreateClubSectionParams struct {
+	ClubID   int64        `json:"club_id"`
+	BookID   int64        `json:"book_id"`
+	Title    string       `json:"title"`
+	StartAt  int64        `json:"start_at"`
+	EndAt    int64        `json:"end_at"`
+	Deadline sql.NullTime `json:"deadline"`
+}
+
+func (q *Queries) CreateClubSection(ctx context.Context, arg CreateClubSectionParams) (ClubSection, error) {
+	row := q.db.QueryRowContext(ctx, createClubSection,
+		arg.ClubID,
+		arg.BookID,
+		arg.Title,
+		arg.StartAt,
+		arg.EndAt,
+		arg.Deadline,
+	)
+	var i ClubSection
+	err := row.Scan(
+		&i.ID,
+		&i.ClubID,
+		&i.BookID,
+		&i.Title,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Deadline,
+	)
+	return i, err
+}
+
+const deleteClub = `-- name: DeleteClub :exec
+DELETE FROM clubs WHERE id = ?
+`
+
+func (q *Queries) DeleteClub(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteClub, id)
+	return err
+}
+
+const deleteClubInvite = `-- name: DeleteClubInvite :execrows
+DELETE FROM club_invites WHERE club_id = ? AND user_id = ?
+`
+
+type DeleteClubInviteParams struct {
+	ClubID int64 `json:"club_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteClubInvite(ctx context.Context, arg DeleteClubInviteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteClubInvite, arg.ClubID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteClubPost = `-- name: DeleteClubPost :exec
+UPDATE club_posts SET body = '', deleted_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+func (q *Queries) DeleteClubPost(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteClubPost, id)
+	return err
+}
+
+const deleteClubSection = `-- name: DeleteClubSection :exec
+DELETE FROM club_sections WHERE id = ? AND club_id = ?
+`
+
+type DeleteClubSectionParams struct {
+	ID     int64 `json:"id"`
+	ClubID int64 `json:"club_id"`
+}
+
+func (q *Queries) DeleteClubSection(ctx context.Context, arg DeleteClubSectionParams) error {
+	_, err := q.db.ExecContext(ctx, deleteClubSection, arg.ID, arg.ClubID)
+	return err
+}
+
+const getClub = `-- name: GetClub :one
+SELECT c.id, c.name, c.description, c.book_id, c.schedule_unit, c.schedule_total, c.created_at,
+    b.isbn, b.title, b.author, b.image_url
+FROM clubs c
+LEFT JOIN books b ON b.id = c.book_id
+WHERE c.id = ?
+`
+
+type GetClubRow struct {
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	BookID        sql.NullInt64  `json:"book_id"`
+	ScheduleUnit  string         `json:"schedule_unit"`
+	ScheduleTotal int64          `json:"schedule_total"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Isbn          sql.NullString `json:"isbn"`
+	Title         sql.NullString `json:"title"`
+	Author        sql.NullString `json:"author"`
+	ImageUrl      sql.NullString `json:"image_url"`
+}
+
+func (q *Queries) GetClub(ctx context.Context, id int64) (GetClubRow, error) {
+	row := q.db.QueryRowContext(ctx, getClub, id)
+	var i GetClubRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.BookID,
+		&i.ScheduleUnit,
+		&i.ScheduleTotal,
+		&i.CreatedAt,
+		&i.Isbn,
+		&i.Title,
+		&i.Author,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getClubInvite = `-- name: GetClubInvite :one
+SELECT club_id, user_id, role, invited_by, created_at
+FROM club_invites
+WHERE club_id = ? AND user_id = ?
+`
+
+type GetClubInviteParams struct {
+	ClubID int64 `json:"club_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetClubInvite(ctx context.Context, arg GetClubInviteParams) (ClubInvite, error) {
+	row := q.db.QueryRowContext(ctx, getClubInvite, arg.ClubID, arg.UserID)
+	var i ClubInvite
+	err := row.Scan(
+		&i.ClubID,
+		&i.UserID,
+		&i.Role,
+		&i.InvitedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getClubMember = `-- name: GetClubMember :one
+SELECT club_id, user_id, role, joined_at
+FROM club_members
+WHERE club_id = ? AND user_id = ?
+`
+
+type GetClubMemberParams struct {
+	ClubID int64 `json:"club_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetClubMember(ctx context.Context, arg GetClubMemberParams) (ClubMember, error) {
+	row := q.db.QueryRowContext(ctx, getClubMember, arg.ClubID, arg.UserID)
+	var i ClubMember
+	err := row.Scan(
+		&i.ClubID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+	)
+	return i, err
+}
+
+const getClubPost = `-- name: GetClubPost :one
+SELECT id, section_id, user_id, parent_id, body, created_at, deleted_at
+FROM club_posts
+WHERE id = ? AND section_id = ?
+`
+
+type GetClubPostParams struct {
+	ID        int64 `json:"id"`
+	SectionID int64 `json:"section_id"`
+}
+
+func (q *Queries) GetClubPost(ctx context.Context, arg GetClubPostParams) (ClubPost, error) {
+	row := q.db.QueryRowContext(ctx, getClubPost, arg.ID, arg.SectionID)
+	var i ClubPost
+	err := row.Scan(
+		&i.ID,
+		&i.SectionID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getClubSection = `-- name: GetClubSection :one
+SELECT id, club_id, book_id, title, start_at, end_at, deadline
+FROM club_sections
+WHERE id = ? AND club_id = ?
+`
+
+type GetClubSectionParams struct {
+	ID     int64 `json:"id"`
+	ClubID int64 `json:"club_id"`
+}
+
+func (q *Queries) GetClubSection(ctx context.Context, arg GetClubSectionParams) (ClubSection, error) {
+	row := q.db.QueryRowContext(ctx, getClubSection, arg.ID, arg.ClubID)
+	var i ClubSection
+	err := row.Scan(
+		&i.ID,
+		&i.ClubID,
+		&i.BookID,
+		&i.Title,
+		&i.StartAt,
+		&i.EndAt,
+		&i.Deadline,
+	)
+	return i, err
+}
+
+const listClubMemberProgress = `-- name: ListClubMemberProgress :many
+SELECT u.id, u.username, u.display_name, u.profile_visibility, m.role, m.joined_at,
+    COALESCE(ub.visibility, '') AS visibility,
+    CAST(EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = ?1 AND f.followee_id = u.id AND f.status = 'accepted'
+    ) AS BOOLEAN) AS followed,
+    COALESCE(r.status, '') AS status,
+    COALESCE(pe.unit, '') AS unit,
+    COALESCE(pe.value, 0) AS value,
+    COALESCE(pe.total, 0) AS total
+FROM club_members m
+JOIN users u ON u.id = m.user_id
+LEFT JOIN user_books ub ON ub.user_id = m.user_id AND ub.book_id = ?2
+LEFT JOIN reads r ON r.id = (
+    SELECT MAX(r2.id) FROM reads r2 WHERE r2.user_id = m.user_id AND r2.book_id = ?2
+)
+LEFT JOIN progress_events pe ON pe.id = (
+    SELECT pe2.id FROM progress_events pe2
+    WHERE pe2.read_id = r.id
+    ORDER BY pe2.created_at DESC, pe2.id DESC
+    LIMIT 1
+)
+WHERE m.club_id = ?3
+ORDER BY u.username
+`
+
+type ListClubMemberProgressParams struct {
+	ViewerID int64 `json:"viewer_id"`
+	BookID   int64 `json:"book_id"`
+	ClubID   int64 `json:"club_id"`
+}
+
+type ListClubMemberProgressRow struct {
+	ID                int64     `json:"id"`
+	Username          string    `json:"username"`
+	DisplayName       string    `json:"display_name"`
+	ProfileVisibility string    `json:"profile_visibility"`
+	Role              string    `json:"role"`
+	JoinedAt          time.Time `json:"joined_at"`
+	Visibility        string    `json:"visibility"`
+	Followed          bool      `json:"followed"`
+	Status            string    `json:"status"`
+	Unit              string    `json:"unit"`
+	Value             int64     `json:"value"`
+	Total             int64     `json:"total"`
+}
+
+// every member with where they are in a book: the latest progress of their current read of it
+// and its status, both empty when the book is not in their library or not started. visibility is
+// their entry's, empty without one, and followed whether the viewer follows them.
+func (q *Queries) ListClubMemberProgress(ctx context.Context, arg ListClubMemberProgressParams) ([]ListClubMemberProgressRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClubMemberProgress, arg.ViewerID, arg.BookID, arg.ClubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClubMemberProgressRow
+	for rows.Next() {
+		var i ListClubMemberProgressRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.ProfileVisibility,
+			&i.Role,
+			&i.JoinedAt,
+			&i.Visibility,
+			&i.Followed,
+			&i.Status,
+			&i.Unit,
+			&i.Value,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClubPosts = `-- name: ListClubPosts :many
+SELECT p.id, p.parent_id, p.user_id, u.username, p.body, p.created_at, p.deleted_at
+FROM club_posts p
+JOIN users u ON u.id = p.user_id
+WHERE p.section_id = ?
+ORDER BY p.created_at, p.id
+`
+
+type ListClubPostsRow struct {
+	ID        int64         `json:"id"`
+	ParentID  sql.NullInt64 `json:"parent_id"`
+	UserID    int64         `json:"user_id"`
+	Username  string        `json:"username"`
+	Body      string        `json:"body"`
+	CreatedAt time.Time     `json:"created_at"`
+	DeletedAt sql.NullTime  `json:"deleted_at"`
+}
+
+func (q *Queries) ListClubPosts(ctx context.Context, sectionID int64) ([]ListClubPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClubPosts, sectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClubPostsRow
+	for rows.Next() {
+		var i ListClubPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.UserID,
+			&i.Username,
+			&i.Body,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClubSections = `-- name: ListClubSections :many
+SELECT s.id, s.club_id, s.book_id, s.title, s.start_at, s.end_at, s.deadline,
+    (SELECT COUNT(*) FROM club_posts p WHERE p.section_id = s.id AND p.deleted_at IS NULL) AS posts
+FROM club_sections s
+WHERE s.club_id = ? AND s.book_id = ?
+ORDER BY s.start_at, s.id
+`
+
+type ListClubSectionsParams struct {
+	ClubID int64 `json:"club_id"`
+	BookID int64 `json:"book_id"`
+}
+
+type ListClubSectionsRow struct {
+	ID       int64        `json:"id"`
+	ClubID   int64        `json:"club_id"`
+	BookID   int64        `json:"book_id"`
+	Title    string       `json:"title"`
+	StartAt  int64        `json:"start_at"`
+	EndAt    int64        `json:"end_at"`
+	Deadline sql.NullTime `json:"deadline"`
+	Posts    int64        `json:"posts"`
+}
+
+func (q *Queries) ListClubSections(ctx context.Context, arg ListClubSectionsParams) ([]ListClubSectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClubSections, arg.ClubID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClubSectionsRow
+	for rows.Next() {
+		var i ListClubSectionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClubID,
+			&i.BookID,
+			&i.Title,
+			&i.StartAt,
+			&i.EndAt,
+			&i.Deadline,
+			&i.Posts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserClubInvites = `-- name: ListUserClubInvites :many
+SELECT i.club_id, c.name, c.description, i.role, u.username AS invited_by, i.created_at
+FROM club_invites i
+JOIN clubs c ON c.id = i.club_id
+JOIN users u ON u.id = i.invited_by
+WHERE i.user_id = ?
+ORDER BY i.created_at DESC, i.club_id
+`
+
+type ListUserClubInvitesRow struct {
+	ClubID      int64     `json:"club_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Role        string    `json:"role"`
+	InvitedBy   string    `json:"invited_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListUserClubInvites(ctx context.Context, userID int64) ([]ListUserClubInvitesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserClubInvites, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserClubInvitesRow
+	for rows.Next() {
+		var i ListUserClubInvitesRow
+		if err := rows.Scan(
+			&i.ClubID,
+			&i.Name,
+			&i.Description,
+			&i.Role,
+			&i.InvitedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserClubs = `-- name: ListUserClubs :many
+SELECT c.id, c.name, c.description, c.book_id, c.created_at, m.role,
+    (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id) AS members
+FROM club_members m
+JOIN clubs c ON c.id = m.club_id
+WHERE m.user_id = ?
+ORDER BY c.name, c.id
+`
+
+type ListUserClubsRow struct {
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	BookID      sql.NullInt64 `json:"book_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Role        string        `json:"role"`
+	Members     int64         `json:"members"`
+}
+
+func (q *Queries) ListUserClubs(ctx context.Context, userID int64) ([]ListUserClubsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserClubs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserClubsRow
+	for rows.Next() {
+		var i ListUserClubsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.BookID,
+			&i.CreatedAt,
+			&i.Role,
+			&i.Members,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeClubMember = `-- name: RemoveClubMember :execrows
+DELETE FROM club_members WHERE club_id = ? AND user_id = ?
+`
+
+type RemoveClubMemberParams struct {
+	ClubID int64 `json:"club_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) RemoveClubMember(ctx context.Context, arg RemoveClubMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeClubMember, arg.ClubID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setClubBook = `-- name: SetClubBook :exec
+UPDATE clubs SET book_id = ?, schedule_unit = ?, schedule_total = ? WHERE id = ?
+`
+
+type SetClubBookParams struct {
+	BookID        sql.NullInt64 `json:"book_id"`
+	ScheduleUnit  string        `json:"schedule_unit"`
+	ScheduleTotal int64         `json:"schedule_total"`
+	ID            int64         `json:"id"`
+}
+
+func (q *Queries) SetClubBook(ctx context.Context, arg SetClubBookParams) error {
+	_, err := q.db.ExecContext(ctx, setClubBook,
+		arg.BookID,
+		arg.ScheduleUnit,
+		arg.ScheduleTotal,
+		arg.ID,
+	)
+	return err
+}
+
+const updateClub = `-- name: UpdateClub :exec
+UPDATE clubs SET name = ?, description = ? WHERE id = ?
+`
+
+type UpdateClubParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ID          int64  `json:"id"`
+}
+
+func (q *Queries) UpdateClub(ctx context.Context, arg UpdateClubParams) error {
+	_, err := q.db.ExecContext(ctx, updateClub, arg.Name, arg.Description, arg.ID)
+	return err
+}
+
+const updateClubSection = `-- name: UpdateClubSection :exec
+UPDATE club_sections SET title = ?, start_at = ?, end_at = ?, deadline = ?
+WHERE id = ? AND club_id = ?
+`
+
+type UpdateClubSectionParams struct {
+	Title    string       `json:"title"`
+	StartAt  int64        `json:"start_at"`
+	EndAt    int64        `json:"end_at"`
+	Deadline sql.NullTime `json:"deadline"`
+	ID       int64        `json:"id"`
+	ClubID   int64        `json:"club_id"`
+}
+
+func (q *Queries) UpdateClubSection(ctx context.Context, arg UpdateClubSectionParams) error {
+	_, err := q.db.ExecContext(ctx, updateClubSection,
+		arg.Title,
+		arg.StartAt,
+		arg.EndAt,
+		arg.Deadline,
+		arg.ID,
+		arg.ClubID,
+	)
+	return err
+}
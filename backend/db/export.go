@@ -0,0 +1,84 @@
+package db
+
+// Written by hand so rows can be handed over one at a time,
+// sqlc's :many collects the whole result into a slice first.
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ExportLibraryRow struct {
+	BookID      int64          `json:"book_id"`
+	Isbn        string         `json:"isbn"`
+	Title       string         `json:"title"`
+	Author      string         `json:"author"`
+	Description string         `json:"description"`
+	ImageUrl    string         `json:"image_url"`
+	Status      string         `json:"status"`
+	AddedAt     sql.NullTime   `json:"added_at"`
+	StartDate   sql.NullTime   `json:"start_date"`
+	FinishDate  sql.NullTime   `json:"finish_date"`
+	Rating      sql.NullInt64  `json:"rating"`
+	Review      sql.NullString `json:"review"`
+	ReadCount   int64          `json:"read_count"`
+}
+
+// an entry with no reads yet is still on the to-read pile
+const exportLibrary = `
+SELECT
+    b.id,
+    b.isbn,
+    b.title,
+    b.author,
+    b.description,
+    b.image_url,
+    COALESCE((SELECT r.status FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id ORDER BY r.id DESC LIMIT 1), 'to-read'),
+    ub.added_at,
+    ub.start_date,
+    ub.finish_date,
+    ub.rating,
+    ub.review,
+    (SELECT COUNT(*) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id AND r.status = 'finished')
+FROM user_books ub
+JOIN books b ON b.id = ub.book_id
+WHERE ub.user_id = ?
+ORDER BY ub.added_at, ub.book_id
+`
+
+// ExportLibrary calls fn for each of a user's library entries as it is read from the database.
+// An error from fn stops the export and is returned.
+func (q *Queries) ExportLibrary(ctx context.Context, userID int64, fn func(ExportLibraryRow) error) error {
+	rows, err := q.db.QueryContext(ctx, exportLibrary, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i ExportLibraryRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Isbn,
+			&i.Title,
+			&i.Author,
+			&i.Description,
+			&i.ImageUrl,
+			&i.Status,
+			&i.AddedAt,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Rating,
+			&i.Review,
+			&i.ReadCount,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
@@ -0,0 +1,163 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: feeds.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const getFeedSettings = `-- name: GetFeedSettings :one
+SELECT id, username, feed_enabled, feed_reviews, feed_updated_at
+FROM users
+WHERE id = ?
+`
+
+type GetFeedSettingsRow struct {
+	ID            int64        `json:"id"`
+	Username      string       `json:"username"`
+	FeedEnabled   bool         `json:"feed_enabled"`
+	FeedReviews   bool         `json:"feed_reviews"`
+	FeedUpdatedAt sql.NullTime `json:"feed_updated_at"`
+}
+
+func (q *Queries) GetFeedSettings(ctx context.Context, id int64) (GetFeedSettingsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedSettings, id)
+	var i GetFeedSettingsRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FeedEnabled,
+		&i.FeedReviews,
+		&i.FeedUpdatedAt,
+	)
+	return i, err
+}
+
+const getFeedSettingsByUsername = `-- name: GetFeedSettingsByUsername :one
+SELECT id, username, feed_enabled, feed_reviews, feed_updated_at
+FROM users
+WHERE username = ? AND profile_visibility = 'public'
+`
+
+type GetFeedSettingsByUsernameRow struct {
+	ID            int64        `json:"id"`
+	Username      string       `json:"username"`
+	FeedEnabled   bool         `json:"feed_enabled"`
+	FeedReviews   bool         `json:"feed_reviews"`
+	FeedUpdatedAt sql.NullTime `json:"feed_updated_at"`
+}
+
+// only public profiles have a feed, making a profile private or followers-only turns it off
+func (q *Queries) GetFeedSettingsByUsername(ctx context.Context, username string) (GetFeedSettingsByUsernameRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedSettingsByUsername, username)
+	var i GetFeedSettingsByUsernameRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FeedEnabled,
+		&i.FeedReviews,
+		&i.FeedUpdatedAt,
+	)
+	return i, err
+}
+
+const getLibraryUpdatedAt = `-- name: GetLibraryUpdatedAt :one
+SELECT updated_at
+FROM user_books
+WHERE user_id = ? AND updated_at IS NOT NULL
+ORDER BY updated_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLibraryUpdatedAt(ctx context.Context, userID int64) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLibraryUpdatedAt, userID)
+	var updated_at sql.NullTime
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
+const listFeedReads = `-- name: ListFeedReads :many
+SELECT r.id, r.book_id, r.start_date, r.finish_date, r.status, r.rating, r.review,
+    b.isbn, b.title, b.author, b.image_url
+FROM reads r
+JOIN books b ON b.id = r.book_id
+JOIN user_books ub ON ub.user_id = r.user_id AND ub.book_id = r.book_id
+JOIN users u ON u.id = r.user_id
+WHERE r.user_id = ? AND ub.visibility = 'public' AND u.profile_visibility = 'public'
+ORDER BY COALESCE(r.finish_date, r.start_date) DESC, r.id DESC
+LIMIT ?
+`
+
+type ListFeedReadsParams struct {
+	UserID int64 `json:"user_id"`
+	Limit  int64 `json:"limit"`
+}
+
+type ListFeedReadsRow struct {
+	ID         int64          `json:"id"`
+	BookID     int64          `json:"book_id"`
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Status     string         `json:"status"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+	Isbn       string         `json:"isbn"`
+	Title      string         `json:"title"`
+	Author     string         `json:"author"`
+	ImageUrl   string         `json:"image_url"`
+}
+
+func (q *Queries) ListFeedReads(ctx context.Context, arg ListFeedReadsParams) ([]ListFeedReadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedReads, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedReadsRow
+	for rows.Next() {
+		var i ListFeedReadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookID,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Status,
+			&i.Rating,
+			&i.Review,
+			&i.Isbn,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFeedSettings = `-- name: UpdateFeedSettings :exec
+UPDATE users SET feed_enabled = ?, feed_reviews = ?, feed_updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateFeedSettingsParams struct {
+	FeedEnabled bool  `json:"feed_enabled"`
+	FeedReviews bool  `json:"feed_reviews"`
+	ID          int64 `json:"id"`
+}
+
+func (q *Queries) UpdateFeedSettings(ctx context.Context, arg UpdateFeedSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSettings, arg.FeedEnabled, arg.FeedReviews, arg.ID)
+	return err
+}
@@ -0,0 +1,220 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: follows.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const acceptFollow = `-- name: AcceptFollow :execrows
+UPDATE follows SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP
+WHERE follower_id = ? AND followee_id = ? AND status = 'pending'
+`
+
+type AcceptFollowParams struct {
+	FollowerID int64 `json:"follower_id"`
+	FolloweeID int64 `json:"followee_id"`
+}
+
+func (q *Queries) AcceptFollow(ctx context.Context, arg AcceptFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acceptFollow, arg.FollowerID, arg.FolloweeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createFollow = `-- name: CreateFollow :exec
+INSERT INTO follows (follower_id, followee_id, status, accepted_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (follower_id, followee_id) DO NOTHING
+`
+
+type CreateFollowParams struct {
+	FollowerID int64        `json:"follower_id"`
+	FolloweeID int64        `json:"followee_id"`
+	Status     string       `json:"status"`
+	AcceptedAt sql.NullTime `json:"accepted_at"`
+}
+
+func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) error {
+	_, err := q.db.ExecContext(ctx, createFollow,
+		arg.FollowerID,
+		arg.FolloweeID,
+		arg.Status,
+		arg.AcceptedAt,
+	)
+	return err
+}
+
+const deleteFollow = `-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = ? AND followee_id = ?
+`
+
+type DeleteFollowParams struct {
+	FollowerID int64 `json:"follower_id"`
+	FolloweeID int64 `json:"followee_id"`
+}
+
+func (q *Queries) DeleteFollow(ctx context.Context, arg DeleteFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFollow, arg.FollowerID, arg.FolloweeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFollow = `-- name: GetFollow :one
+SELECT follower_id, followee_id, status, created_at, accepted_at
+FROM follows
+WHERE follower_id = ? AND followee_id = ?
+`
+
+type GetFollowParams struct {
+	FollowerID int64 `json:"follower_id"`
+	FolloweeID int64 `json:"followee_id"`
+}
+
+func (q *Queries) GetFollow(ctx context.Context, arg GetFollowParams) (Follow, error) {
+	row := q.db.QueryRowContext(ctx, getFollow, arg.FollowerID, arg.FolloweeID)
+	var i Follow
+	err := row.Scan(
+		&i.FollowerID,
+		&i.FolloweeID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.AcceptedAt,
+	)
+	return i, err
+}
+
+const listFollowers = `-- name: ListFollowers :many
+SELECT u.id, u.username, u.display_name, f.status, f.created_at
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.followee_id = ? AND f.status = ?
+ORDER BY f.created_at DESC, u.id DESC
+`
+
+type ListFollowersParams struct {
+	FolloweeID int64  `json:"followee_id"`
+	Status     string `json:"status"`
+}
+
+type ListFollowersRow struct {
+	ID          int64     `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListFollowers(ctx context.Context, arg ListFollowersParams) ([]ListFollowersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowers, arg.FolloweeID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowersRow
+	for rows.Next() {
+		var i ListFollowersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowing = `-- name: ListFollowing :many
+SELECT u.id, u.username, u.display_name, f.status, f.created_at
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = ?
+ORDER BY f.created_at DESC, u.id DESC
+`
+
+type ListFollowingRow struct {
+	ID          int64     `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListFollowing(ctx context.Context, followerID int64) ([]ListFollowingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFollowing, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowingRow
+	for rows.Next() {
+		var i ListFollowingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimelineSources = `-- name: ListTimelineSources :many
+SELECT u.id
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = ? AND f.status = 'accepted' AND u.profile_visibility IN ('followers', 'public')
+`
+
+// the people whose activity shows in a user's timeline, their profile has to be visible to followers
+func (q *Queries) ListTimelineSources(ctx context.Context, followerID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listTimelineSources, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,371 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: goals.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createChallenge = `-- name: CreateChallenge :one
+INSERT INTO challenges (user_id, year, name, target, rules)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, year, name, target, rules, created_at
+`
+
+type CreateChallengeParams struct {
+	UserID int64  `json:"user_id"`
+	Year   int64  `json:"year"`
+	Name   string `json:"name"`
+	Target int64  `json:"target"`
+	Rules  string `json:"rules"`
+}
+
+func (q *Queries) CreateChallenge(ctx context.Context, arg CreateChallengeParams) (Challenge, error) {
+	row := q.db.QueryRowContext(ctx, createChallenge,
+		arg.UserID,
+		arg.Year,
+		arg.Name,
+		arg.Target,
+		arg.Rules,
+	)
+	var i Challenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Year,
+		&i.Name,
+		&i.Target,
+		&i.Rules,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteChallenge = `-- name: DeleteChallenge :execrows
+DELETE FROM challenges WHERE id = ? AND user_id = ?
+`
+
+type DeleteChallengeParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteChallenge(ctx context.Context, arg DeleteChallengeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChallenge, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteReadingGoal = `-- name: DeleteReadingGoal :execrows
+DELETE FROM reading_goals WHERE user_id = ? AND year = ? AND unit = ?
+`
+
+type DeleteReadingGoalParams struct {
+	UserID int64  `json:"user_id"`
+	Year   int64  `json:"year"`
+	Unit   string `json:"unit"`
+}
+
+func (q *Queries) DeleteReadingGoal(ctx context.Context, arg DeleteReadingGoalParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteReadingGoal, arg.UserID, arg.Year, arg.Unit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getChallenge = `-- name: GetChallenge :one
+SELECT id, user_id, year, name, target, rules, created_at
+FROM challenges
+WHERE id = ? AND user_id = ?
+`
+
+type GetChallengeParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetChallenge(ctx context.Context, arg GetChallengeParams) (Challenge, error) {
+	row := q.db.QueryRowContext(ctx, getChallenge, arg.ID, arg.UserID)
+	var i Challenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Year,
+		&i.Name,
+		&i.Target,
+		&i.Rules,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuthorsReadBefore = `-- name: ListAuthorsReadBefore :many
+SELECT DISTINCT b.author
+FROM reads r
+JOIN books b ON b.id = r.book_id
+WHERE r.user_id = ?1 AND r.status = 'finished' AND r.finish_date IS NOT NULL
+    AND CAST(strftime('%Y', r.finish_date) AS INTEGER) < CAST(?2 AS INTEGER)
+`
+
+type ListAuthorsReadBeforeParams struct {
+	UserID int64 `json:"user_id"`
+	Year   int64 `json:"year"`
+}
+
+// authors of books the user finished before a year, whoever else they read that year is new to them
+func (q *Queries) ListAuthorsReadBefore(ctx context.Context, arg ListAuthorsReadBeforeParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorsReadBefore, arg.UserID, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var author string
+		if err := rows.Scan(&author); err != nil {
+			return nil, err
+		}
+		items = append(items, author)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChallenges = `-- name: ListChallenges :many
+SELECT id, user_id, year, name, target, rules, created_at
+FROM challenges
+WHERE user_id = ? AND year = ?
+ORDER BY created_at, id
+`
+
+type ListChallengesParams struct {
+	UserID int64 `json:"user_id"`
+	Year   int64 `json:"year"`
+}
+
+func (q *Queries) ListChallenges(ctx context.Context, arg ListChallengesParams) ([]Challenge, error) {
+	rows, err := q.db.QueryContext(ctx, listChallenges, arg.UserID, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Challenge
+	for rows.Next() {
+		var i Challenge
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Year,
+			&i.Name,
+			&i.Target,
+			&i.Rules,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinishedBooks = `-- name: ListFinishedBooks :many
+SELECT fb.book_id, fb.title, fb.author, fb.finish_date, fb.rating, fb.pages,
+    CAST(COALESCE((
+        SELECT pe.total FROM progress_events pe
+        WHERE pe.user_id = fb.user_id AND pe.book_id = fb.book_id AND pe.unit = 'minutes' AND pe.total IS NOT NULL
+        ORDER BY pe.created_at DESC, pe.id DESC LIMIT 1
+    ), 0) AS INTEGER) AS minutes,
+    CAST(COALESCE((
+        SELECT s.name FROM series_books sb JOIN series s ON s.id = sb.series_id
+        WHERE sb.book_id = fb.book_id AND sb.user_id IN (0, fb.user_id) ORDER BY s.name LIMIT 1
+    ), '') AS TEXT) AS series,
+    CAST(COALESCE((
+        SELECT group_concat(t.tag, char(31)) FROM user_book_tags t
+        WHERE t.user_id = fb.user_id AND t.book_id = fb.book_id
+    ), '') AS TEXT) AS tags
+FROM finished_books fb
+WHERE fb.user_id = ?1
+    AND CAST(strftime('%Y', fb.finish_date) AS INTEGER) = CAST(?2 AS INTEGER)
+ORDER BY fb.finish_date, fb.read_id
+`
+
+type ListFinishedBooksParams struct {
+	UserID int64 `json:"user_id"`
+	Year   int64 `json:"year"`
+}
+
+type ListFinishedBooksRow struct {
+	BookID     int64         `json:"book_id"`
+	Title      string        `json:"title"`
+	Author     string        `json:"author"`
+	FinishDate sql.NullTime  `json:"finish_date"`
+	Rating     sql.NullInt64 `json:"rating"`
+	Pages      int64         `json:"pages"`
+	Minutes    int64         `json:"minutes"`
+	Series     string        `json:"series"`
+	Tags       string        `json:"tags"`
+}
+
+// the reads finished in a year with what goals and challenges look at, a book read twice in it
+// counts twice. Pages and minutes are the totals last given with progress in that unit, 0 when
+// there never was any.
+func (q *Queries) ListFinishedBooks(ctx context.Context, arg ListFinishedBooksParams) ([]ListFinishedBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFinishedBooks, arg.UserID, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFinishedBooksRow
+	for rows.Next() {
+		var i ListFinishedBooksRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.FinishDate,
+			&i.Rating,
+			&i.Pages,
+			&i.Minutes,
+			&i.Series,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReadingGoals = `-- name: ListReadingGoals :many
+SELECT user_id, year, unit, target, updated_at
+FROM reading_goals
+WHERE user_id = ? AND year = ?
+ORDER BY unit
+`
+
+type ListReadingGoalsParams struct {
+	UserID int64 `json:"user_id"`
+	Year   int64 `json:"year"`
+}
+
+func (q *Queries) ListReadingGoals(ctx context.Context, arg ListReadingGoalsParams) ([]ReadingGoal, error) {
+	rows, err := q.db.QueryContext(ctx, listReadingGoals, arg.UserID, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadingGoal
+	for rows.Next() {
+		var i ReadingGoal
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Year,
+			&i.Unit,
+			&i.Target,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setReadingGoal = `-- name: SetReadingGoal :one
+INSERT INTO reading_goals (user_id, year, unit, target)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, year, unit) DO UPDATE SET target = excluded.target, updated_at = CURRENT_TIMESTAMP
+RETURNING user_id, year, unit, target, updated_at
+`
+
+type SetReadingGoalParams struct {
+	UserID int64  `json:"user_id"`
+	Year   int64  `json:"year"`
+	Unit   string `json:"unit"`
+	Target int64  `json:"target"`
+}
+
+func (q *Queries) SetReadingGoal(ctx context.Context, arg SetReadingGoalParams) (ReadingGoal, error) {
+	row := q.db.QueryRowContext(ctx, setReadingGoal,
+		arg.UserID,
+		arg.Year,
+		arg.Unit,
+		arg.Target,
+	)
+	var i ReadingGoal
+	err := row.Scan(
+		&i.UserID,
+		&i.Year,
+		&i.Unit,
+		&i.Target,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateChallenge = `-- name: UpdateChallenge :one
+UPDATE challenges SET year = ?, name = ?, target = ?, rules = ?
+WHERE id = ? AND user_id = ?
+RETURNING id, user_id, year, name, target, rules, created_at
+`
+
+type UpdateChallengeParams struct {
+	Year   int64  `json:"year"`
+	Name   string `json:"name"`
+	Target int64  `json:"target"`
+	Rules  string `json:"rules"`
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+}
+
+func (q *Queries) UpdateChallenge(ctx context.Context, arg UpdateChallengeParams) (Challenge, error) {
+	row := q.db.QueryRowContext(ctx, updateChallenge,
+		arg.Year,
+		arg.Name,
+		arg.Target,
+		arg.Rules,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Challenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Year,
+		&i.Name,
+		&i.Target,
+		&i.Rules,
+		&i.CreatedAt,
+	)
+	return i, err
+}
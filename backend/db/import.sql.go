@@ -0,0 +1,232 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: import.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createImportJob = `-- name: CreateImportJob :one
+INSERT INTO import_jobs (user_id, format, dry_run, status, total_rows)
+VALUES (?, ?, ?, 'queued', ?)
+RETURNING id
+`
+
+type CreateImportJobParams struct {
+	UserID    int64  `json:"user_id"`
+	Format    string `json:"format"`
+	DryRun    bool   `json:"dry_run"`
+	TotalRows int64  `json:"total_rows"`
+}
+
+func (q *Queries) CreateImportJob(ctx context.Context, arg CreateImportJobParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createImportJob,
+		arg.UserID,
+		arg.Format,
+		arg.DryRun,
+		arg.TotalRows,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const failInterruptedImportJobs = `-- name: FailInterruptedImportJobs :exec
+UPDATE import_jobs
+SET status = 'failed', error = 'the server restarted before the import finished', finished_at = CURRENT_TIMESTAMP
+WHERE status IN ('queued', 'running')
+`
+
+func (q *Queries) FailInterruptedImportJobs(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, failInterruptedImportJobs)
+	return err
+}
+
+const findBookByTitleAuthor = `-- name: FindBookByTitleAuthor :one
+SELECT id, isbn, title, description, author, image_url
+FROM books
+WHERE title = ? COLLATE NOCASE AND author = ? COLLATE NOCASE
+ORDER BY id
+LIMIT 1
+`
+
+type FindBookByTitleAuthorParams struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+func (q *Queries) FindBookByTitleAuthor(ctx context.Context, arg FindBookByTitleAuthorParams) (Book, error) {
+	row := q.db.QueryRowContext(ctx, findBookByTitleAuthor, arg.Title, arg.Author)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Isbn,
+		&i.Title,
+		&i.Description,
+		&i.Author,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const finishImportJob = `-- name: FinishImportJob :exec
+UPDATE import_jobs SET status = ?, report = ?, error = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+type FinishImportJobParams struct {
+	Status string         `json:"status"`
+	Report sql.NullString `json:"report"`
+	Error  sql.NullString `json:"error"`
+	ID     int64          `json:"id"`
+}
+
+func (q *Queries) FinishImportJob(ctx context.Context, arg FinishImportJobParams) error {
+	_, err := q.db.ExecContext(ctx, finishImportJob,
+		arg.Status,
+		arg.Report,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const getBookByIsbn = `-- name: GetBookByIsbn :one
+SELECT id, isbn, title, description, author, image_url FROM books WHERE isbn = ?
+`
+
+func (q *Queries) GetBookByIsbn(ctx context.Context, isbn string) (Book, error) {
+	row := q.db.QueryRowContext(ctx, getBookByIsbn, isbn)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Isbn,
+		&i.Title,
+		&i.Description,
+		&i.Author,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getImportJob = `-- name: GetImportJob :one
+SELECT id, user_id, format, dry_run, status, total_rows, processed_rows, report, error, created_at, finished_at
+FROM import_jobs
+WHERE id = ? AND user_id = ?
+`
+
+type GetImportJobParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetImportJob(ctx context.Context, arg GetImportJobParams) (ImportJob, error) {
+	row := q.db.QueryRowContext(ctx, getImportJob, arg.ID, arg.UserID)
+	var i ImportJob
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Format,
+		&i.DryRun,
+		&i.Status,
+		&i.TotalRows,
+		&i.ProcessedRows,
+		&i.Report,
+		&i.Error,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const importRead = `-- name: ImportRead :one
+INSERT INTO reads (user_id, book_id, start_date, finish_date, status, rating, review)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type ImportReadParams struct {
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Status     string         `json:"status"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+}
+
+func (q *Queries) ImportRead(ctx context.Context, arg ImportReadParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, importRead,
+		arg.UserID,
+		arg.BookID,
+		arg.StartDate,
+		arg.FinishDate,
+		arg.Status,
+		arg.Rating,
+		arg.Review,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const importUserBook = `-- name: ImportUserBook :exec
+INSERT INTO user_books (user_id, book_id, start_date, finish_date, rating, review, added_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+`
+
+type ImportUserBookParams struct {
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+	AddedAt    sql.NullTime   `json:"added_at"`
+}
+
+func (q *Queries) ImportUserBook(ctx context.Context, arg ImportUserBookParams) error {
+	_, err := q.db.ExecContext(ctx, importUserBook,
+		arg.UserID,
+		arg.BookID,
+		arg.StartDate,
+		arg.FinishDate,
+		arg.Rating,
+		arg.Review,
+		arg.AddedAt,
+	)
+	return err
+}
+
+const updateImportJobProgress = `-- name: UpdateImportJobProgress :exec
+UPDATE import_jobs SET status = 'running', processed_rows = ? WHERE id = ?
+`
+
+type UpdateImportJobProgressParams struct {
+	ProcessedRows int64 `json:"processed_rows"`
+	ID            int64 `json:"id"`
+}
+
+func (q *Queries) UpdateImportJobProgress(ctx context.Context, arg UpdateImportJobProgressParams) error {
+	_, err := q.db.ExecContext(ctx, updateImportJobProgress, arg.ProcessedRows, arg.ID)
+	return err
+}
+
+const userHasBook = `-- name: UserHasBook :one
+SELECT COUNT(*) FROM user_books WHERE user_id = ? AND book_id = ?
+`
+
+type UserHasBookParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) UserHasBook(ctx context.Context, arg UserHasBookParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, userHasBook, arg.UserID, arg.BookID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
@@ -0,0 +1,244 @@
+package db
+
+// The library listing is written by hand rather than generated by sqlc,
+// its WHERE and ORDER BY clauses change with the filters and sort asked for.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// LibrarySort is a field the library listing can be ordered by
+type LibrarySort string
+
+const (
+	LibrarySortTitle    LibrarySort = "title"
+	LibrarySortAuthor   LibrarySort = "author"
+	LibrarySortAdded    LibrarySort = "added"
+	LibrarySortFinished LibrarySort = "finished"
+	LibrarySortRating   LibrarySort = "rating"
+)
+
+// librarySort is how a sort orders rows and the value kept in the cursor for it. The user_books
+// columns are sorted on as they are, nulls first, so the indexes from 0004 give the order without
+// a sort step. Timestamps go in the cursor as stored so they compare the same way on the next page.
+type librarySort struct {
+	column string
+	key    string
+}
+
+var librarySortKeys = map[LibrarySort]librarySort{
+	LibrarySortTitle:    {column: "b.title COLLATE NOCASE", key: "b.title"},
+	LibrarySortAuthor:   {column: "b.author COLLATE NOCASE", key: "b.author"},
+	LibrarySortAdded:    {column: "ub.added_at", key: "CAST(ub.added_at AS TEXT)"},
+	LibrarySortFinished: {column: "ub.finish_date", key: "CAST(ub.finish_date AS TEXT)"},
+	LibrarySortRating:   {column: "ub.rating", key: "ub.rating"},
+}
+
+// ValidLibrarySort reports whether sort is one of the supported orders
+func ValidLibrarySort(sort LibrarySort) bool {
+	_, ok := librarySortKeys[sort]
+	return ok
+}
+
+// LibraryFilter narrows the library listing, zero values mean no filter
+type LibraryFilter struct {
+	RatingMin    int64
+	RatingMax    int64
+	FinishedYear int
+	Author       string
+	// Status is the current read's status, or to-read for entries never started
+	Status string
+	// Tag is a shelf the entry is on, matched without case
+	Tag string
+	// Visibilities keeps entries shown at one of these levels, for someone else looking at the library
+	Visibilities []string
+}
+
+// LibraryStatusToRead is the Status filter for entries with no reads yet
+const LibraryStatusToRead = "to-read"
+
+// LibraryCursor marks the last row of a page, the next page starts after it
+type LibraryCursor struct {
+	Sort   LibrarySort `json:"s"`
+	Desc   bool        `json:"d"`
+	Key    interface{} `json:"k"`
+	BookID int64       `json:"id"`
+}
+
+type ListLibraryParams struct {
+	UserID int64
+	Filter LibraryFilter
+	Sort   LibrarySort
+	Desc   bool
+	After  *LibraryCursor
+	Limit  int64
+}
+
+type ListLibraryRow struct {
+	ID          int64          `json:"id"`
+	Isbn        string         `json:"isbn"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Author      string         `json:"author"`
+	ImageUrl    string         `json:"image_url"`
+	Progress    int64          `json:"progress"`
+	StartDate   sql.NullTime   `json:"start_date"`
+	FinishDate  sql.NullTime   `json:"finish_date"`
+	Rating      sql.NullInt64  `json:"rating"`
+	Review      sql.NullString `json:"review"`
+	AddedAt     sql.NullTime   `json:"added_at"`
+	SortKey     interface{}    `json:"-"`
+}
+
+func (f LibraryFilter) where(userID int64) (string, []interface{}) {
+	conditions := []string{"ub.user_id = ?"}
+	args := []interface{}{userID}
+	if f.RatingMin > 0 {
+		conditions = append(conditions, "ub.rating >= ?")
+		args = append(args, f.RatingMin)
+	}
+	if f.RatingMax > 0 {
+		conditions = append(conditions, "ub.rating <= ?")
+		args = append(args, f.RatingMax)
+	}
+	if f.FinishedYear > 0 {
+		// a range rather than strftime so idx_user_books_finished can be used
+		conditions = append(conditions, "ub.finish_date >= ? AND ub.finish_date < ?")
+		args = append(args, fmt.Sprintf("%04d-01-01", f.FinishedYear), fmt.Sprintf("%04d-01-01", f.FinishedYear+1))
+	}
+	if f.Author != "" {
+		conditions = append(conditions, "b.author LIKE '%' || ? || '%'")
+		args = append(args, f.Author)
+	}
+	if f.Status == LibraryStatusToRead {
+		conditions = append(conditions, "NOT EXISTS (SELECT 1 FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)")
+	} else if f.Status != "" {
+		conditions = append(conditions, "(SELECT r.status FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id ORDER BY r.id DESC LIMIT 1) = ?")
+		args = append(args, f.Status)
+	}
+	if f.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM user_book_tags t WHERE t.user_id = ub.user_id AND t.book_id = ub.book_id AND t.tag = ? COLLATE NOCASE)")
+		args = append(args, f.Tag)
+	}
+	if len(f.Visibilities) > 0 {
+		conditions = append(conditions, "ub.visibility IN (?"+strings.Repeat(", ?", len(f.Visibilities)-1)+")")
+		for _, visibility := range f.Visibilities {
+			args = append(args, visibility)
+		}
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+const listLibrary = `
+SELECT
+    b.id,
+    b.isbn,
+    b.title,
+    b.description,
+    b.author,
+    b.image_url,
+    CAST(COALESCE((
+        SELECT CASE
+            WHEN pe.unit = 'percent' THEN pe.value
+            WHEN pe.total > 0 THEN pe.value * 100 / pe.total
+        END
+        FROM progress_events pe
+        WHERE pe.read_id = (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) AS INTEGER) AS progress,
+    ub.start_date,
+    ub.finish_date,
+    ub.rating,
+    ub.review,
+    ub.added_at,
+    %s AS sort_key
+FROM books b
+JOIN user_books ub ON b.id = ub.book_id
+WHERE %s
+ORDER BY %s %s, ub.book_id %s
+LIMIT ?
+`
+
+// after is the keyset condition for the rows following cursor. Nulls come first in ascending
+// order, so they are before any key going up and after every key going down.
+func (s librarySort) after(cursor *LibraryCursor, desc bool) (string, []interface{}) {
+	column := s.column
+	switch {
+	case cursor.Key == nil && !desc:
+		return fmt.Sprintf("(%[1]s IS NOT NULL OR (%[1]s IS NULL AND ub.book_id > ?))", column), []interface{}{cursor.BookID}
+	case cursor.Key == nil:
+		return fmt.Sprintf("(%[1]s IS NULL AND ub.book_id < ?)", column), []interface{}{cursor.BookID}
+	case !desc:
+		return fmt.Sprintf("(%[1]s > ? OR (%[1]s = ? AND ub.book_id > ?))", column), []interface{}{cursor.Key, cursor.Key, cursor.BookID}
+	default:
+		return fmt.Sprintf("(%[1]s < ? OR (%[1]s = ? AND ub.book_id < ?) OR %[1]s IS NULL)", column), []interface{}{cursor.Key, cursor.Key, cursor.BookID}
+	}
+}
+
+// ListLibrary returns one page of a user's library using keyset pagination on the sort key and book id
+func (q *Queries) ListLibrary(ctx context.Context, arg ListLibraryParams) ([]ListLibraryRow, error) {
+	sort, ok := librarySortKeys[arg.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown library sort %q", arg.Sort)
+	}
+	where, args := arg.Filter.where(arg.UserID)
+	direction := "ASC"
+	if arg.Desc {
+		direction = "DESC"
+	}
+	if arg.After != nil {
+		after, afterArgs := sort.after(arg.After, arg.Desc)
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+	args = append(args, arg.Limit)
+
+	query := fmt.Sprintf(listLibrary, sort.key, where, sort.column, direction, direction)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLibraryRow
+	for rows.Next() {
+		var i ListLibraryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Isbn,
+			&i.Title,
+			&i.Description,
+			&i.Author,
+			&i.ImageUrl,
+			&i.Progress,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Rating,
+			&i.Review,
+			&i.AddedAt,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountLibrary counts every entry matching the filter, ignoring pagination
+func (q *Queries) CountLibrary(ctx context.Context, userID int64, filter LibraryFilter) (int64, error) {
+	where, args := filter.where(userID)
+	query := "SELECT COUNT(*) FROM books b JOIN user_books ub ON b.id = ub.book_id WHERE " + where
+	var count int64
+	err := q.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
@@ -9,6 +9,40 @@ import (
 	"time"
 )
 
+type AccessToken struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"token_hash"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+}
+
+type ActivityEvent struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	BookID    int64         `json:"book_id"`
+	Type      string        `json:"type"`
+	Value     sql.NullInt64 `json:"value"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Annotation struct {
+	ID        int64          `json:"id"`
+	UserID    int64          `json:"user_id"`
+	BookID    int64          `json:"book_id"`
+	Type      string         `json:"type"`
+	Text      string         `json:"text"`
+	Page      sql.NullInt64  `json:"page"`
+	Location  sql.NullString `json:"location"`
+	CreatedAt time.Time      `json:"created_at"`
+	Source    string         `json:"source"`
+	SourceKey sql.NullString `json:"source_key"`
+	Chapter   sql.NullString `json:"chapter"`
+	Private   bool           `json:"private"`
+	UpdatedAt sql.NullTime   `json:"updated_at"`
+}
+
 type Book struct {
 	ID          int64  `json:"id"`
 	Isbn        string `json:"isbn"`
@@ -18,6 +52,221 @@ type Book struct {
 	ImageUrl    string `json:"image_url"`
 }
 
+type BookIdentifier struct {
+	BookID int64  `json:"book_id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+}
+
+type Challenge struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Year      int64     `json:"year"`
+	Name      string    `json:"name"`
+	Target    int64     `json:"target"`
+	Rules     string    `json:"rules"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Club struct {
+	ID            int64         `json:"id"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	BookID        sql.NullInt64 `json:"book_id"`
+	ScheduleUnit  string        `json:"schedule_unit"`
+	ScheduleTotal int64         `json:"schedule_total"`
+	CreatedBy     int64         `json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type ClubInvite struct {
+	ClubID    int64     `json:"club_id"`
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	InvitedBy int64     `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ClubMember struct {
+	ClubID   int64     `json:"club_id"`
+	UserID   int64     `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type ClubPost struct {
+	ID        int64         `json:"id"`
+	SectionID int64         `json:"section_id"`
+	UserID    int64         `json:"user_id"`
+	ParentID  sql.NullInt64 `json:"parent_id"`
+	Body      string        `json:"body"`
+	CreatedAt time.Time     `json:"created_at"`
+	DeletedAt sql.NullTime  `json:"deleted_at"`
+}
+
+type ClubSection struct {
+	ID       int64        `json:"id"`
+	ClubID   int64        `json:"club_id"`
+	BookID   int64        `json:"book_id"`
+	Title    string       `json:"title"`
+	StartAt  int64        `json:"start_at"`
+	EndAt    int64        `json:"end_at"`
+	Deadline sql.NullTime `json:"deadline"`
+}
+
+type CommentReport struct {
+	CommentID int64     `json:"comment_id"`
+	UserID    int64     `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExternalBook struct {
+	UserID     int64     `json:"user_id"`
+	Source     string    `json:"source"`
+	ExternalID string    `json:"external_id"`
+	BookID     int64     `json:"book_id"`
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+type FinishedBook struct {
+	UserID     int64         `json:"user_id"`
+	BookID     int64         `json:"book_id"`
+	ReadID     int64         `json:"read_id"`
+	Title      string        `json:"title"`
+	Author     string        `json:"author"`
+	StartDate  sql.NullTime  `json:"start_date"`
+	FinishDate sql.NullTime  `json:"finish_date"`
+	Rating     sql.NullInt64 `json:"rating"`
+	Pages      int64         `json:"pages"`
+}
+
+type Follow struct {
+	FollowerID int64        `json:"follower_id"`
+	FolloweeID int64        `json:"followee_id"`
+	Status     string       `json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	AcceptedAt sql.NullTime `json:"accepted_at"`
+}
+
+type ImportJob struct {
+	ID            int64          `json:"id"`
+	UserID        int64          `json:"user_id"`
+	Format        string         `json:"format"`
+	DryRun        bool           `json:"dry_run"`
+	Status        string         `json:"status"`
+	TotalRows     int64          `json:"total_rows"`
+	ProcessedRows int64          `json:"processed_rows"`
+	Report        sql.NullString `json:"report"`
+	Error         sql.NullString `json:"error"`
+	CreatedAt     time.Time      `json:"created_at"`
+	FinishedAt    sql.NullTime   `json:"finished_at"`
+}
+
+type LibrarySearch struct {
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Description string `json:"description"`
+	Review      string `json:"review"`
+	Notes       string `json:"notes"`
+	UserID      string `json:"user_id"`
+	BookID      string `json:"book_id"`
+}
+
+type Notification struct {
+	ID         int64         `json:"id"`
+	UserID     int64         `json:"user_id"`
+	Type       string        `json:"type"`
+	ActorID    int64         `json:"actor_id"`
+	ReviewerID int64         `json:"reviewer_id"`
+	BookID     int64         `json:"book_id"`
+	CommentID  sql.NullInt64 `json:"comment_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ReadAt     sql.NullTime  `json:"read_at"`
+}
+
+type ProgressEvent struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	BookID    int64         `json:"book_id"`
+	ReadID    sql.NullInt64 `json:"read_id"`
+	Unit      string        `json:"unit"`
+	Value     int64         `json:"value"`
+	Total     sql.NullInt64 `json:"total"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Read struct {
+	ID         int64          `json:"id"`
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Status     string         `json:"status"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+}
+
+type ReadingGoal struct {
+	UserID    int64     `json:"user_id"`
+	Year      int64     `json:"year"`
+	Unit      string    `json:"unit"`
+	Target    int64     `json:"target"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReadingSession struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	BookID    int64         `json:"book_id"`
+	ReadID    sql.NullInt64 `json:"read_id"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   sql.NullTime  `json:"ended_at"`
+	StartPage sql.NullInt64 `json:"start_page"`
+	EndPage   sql.NullInt64 `json:"end_page"`
+}
+
+type Recommendation struct {
+	UserID     int64     `json:"user_id"`
+	BookID     int64     `json:"book_id"`
+	Score      float64   `json:"score"`
+	Reasons    string    `json:"reasons"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+type ReviewComment struct {
+	ID         int64         `json:"id"`
+	ReviewerID int64         `json:"reviewer_id"`
+	BookID     int64         `json:"book_id"`
+	UserID     int64         `json:"user_id"`
+	Body       string        `json:"body"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  sql.NullTime  `json:"updated_at"`
+	DeletedAt  sql.NullTime  `json:"deleted_at"`
+	HiddenAt   sql.NullTime  `json:"hidden_at"`
+	HiddenBy   sql.NullInt64 `json:"hidden_by"`
+}
+
+type ReviewReaction struct {
+	ReviewerID int64     `json:"reviewer_id"`
+	BookID     int64     `json:"book_id"`
+	UserID     int64     `json:"user_id"`
+	Reaction   string    `json:"reaction"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Series struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type SeriesBook struct {
+	SeriesID int64           `json:"series_id"`
+	BookID   int64           `json:"book_id"`
+	UserID   int64           `json:"user_id"`
+	Position sql.NullFloat64 `json:"position"`
+}
+
 type Session struct {
 	ID        string       `json:"id"`
 	UserID    int64        `json:"user_id"`
@@ -26,10 +275,19 @@ type Session struct {
 }
 
 type User struct {
-	ID           int64        `json:"id"`
-	Username     string       `json:"username"`
-	PasswordHash string       `json:"password_hash"`
-	CreatedAt    sql.NullTime `json:"created_at"`
+	ID                int64        `json:"id"`
+	Username          string       `json:"username"`
+	PasswordHash      string       `json:"password_hash"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+	FeedEnabled       bool         `json:"feed_enabled"`
+	FeedReviews       bool         `json:"feed_reviews"`
+	FeedUpdatedAt     sql.NullTime `json:"feed_updated_at"`
+	ProfileVisibility string       `json:"profile_visibility"`
+	DisplayName       string       `json:"display_name"`
+	Bio               string       `json:"bio"`
+	IsAdmin           bool         `json:"is_admin"`
+	StatsVersion      int64        `json:"stats_version"`
+	Timezone          string       `json:"timezone"`
 }
 
 type UserBook struct {
@@ -40,4 +298,22 @@ type UserBook struct {
 	FinishDate sql.NullTime   `json:"finish_date"`
 	Rating     sql.NullInt64  `json:"rating"`
 	Review     sql.NullString `json:"review"`
+	Version    int64          `json:"version"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	AddedAt    sql.NullTime   `json:"added_at"`
+	Visibility string         `json:"visibility"`
+}
+
+type UserBookTag struct {
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Tag    string `json:"tag"`
+	Source string `json:"source"`
+}
+
+type YearReview struct {
+	UserID    int64     `json:"user_id"`
+	Year      int64     `json:"year"`
+	Public    bool      `json:"public"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,144 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: notifications.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT n.id, n.type, a.username AS actor, r.username AS reviewer, n.book_id, b.title, n.comment_id, n.created_at, n.read_at
+FROM notifications n
+JOIN users a ON a.id = n.actor_id
+JOIN users r ON r.id = n.reviewer_id
+JOIN books b ON b.id = n.book_id
+WHERE n.user_id = ?1
+    AND (n.read_at IS NULL OR CAST(?2 AS BOOLEAN))
+    AND (n.created_at < datetime(?3) OR (n.created_at = datetime(?3) AND n.id < ?4))
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT ?5
+`
+
+type ListNotificationsParams struct {
+	UserID      int64       `json:"user_id"`
+	IncludeRead bool        `json:"include_read"`
+	BeforeTime  interface{} `json:"before_time"`
+	BeforeID    int64       `json:"before_id"`
+	Limit       int64       `json:"limit"`
+}
+
+type ListNotificationsRow struct {
+	ID        int64         `json:"id"`
+	Type      string        `json:"type"`
+	Actor     string        `json:"actor"`
+	Reviewer  string        `json:"reviewer"`
+	BookID    int64         `json:"book_id"`
+	Title     string        `json:"title"`
+	CommentID sql.NullInt64 `json:"comment_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	ReadAt    sql.NullTime  `json:"read_at"`
+}
+
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifications,
+		arg.UserID,
+		arg.IncludeRead,
+		arg.BeforeTime,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotificationsRow
+	for rows.Next() {
+		var i ListNotificationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Actor,
+			&i.Reviewer,
+			&i.BookID,
+			&i.Title,
+			&i.CommentID,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :execrows
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND id <= ? AND read_at IS NULL
+`
+
+type MarkNotificationsReadParams struct {
+	UserID int64 `json:"user_id"`
+	ID     int64 `json:"id"`
+}
+
+// marks the user's notifications up to and including up_to_id as read
+func (q *Queries) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationsRead, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const notifyReviewComment = `-- name: NotifyReviewComment :exec
+INSERT INTO notifications (user_id, type, actor_id, reviewer_id, book_id, comment_id)
+SELECT recipients.user_id, 'comment', ?1, ?2, ?3, ?4
+FROM (
+    SELECT ?2 AS user_id
+    UNION
+    SELECT c.user_id FROM review_comments c
+    WHERE c.reviewer_id = ?2 AND c.book_id = ?3 AND c.deleted_at IS NULL
+) recipients
+WHERE recipients.user_id != ?1
+`
+
+type NotifyReviewCommentParams struct {
+	ActorID    int64         `json:"actor_id"`
+	ReviewerID int64         `json:"reviewer_id"`
+	BookID     int64         `json:"book_id"`
+	CommentID  sql.NullInt64 `json:"comment_id"`
+}
+
+// tells the reviewer and everyone else who commented on the review about a new comment
+func (q *Queries) NotifyReviewComment(ctx context.Context, arg NotifyReviewCommentParams) error {
+	_, err := q.db.ExecContext(ctx, notifyReviewComment,
+		arg.ActorID,
+		arg.ReviewerID,
+		arg.BookID,
+		arg.CommentID,
+	)
+	return err
+}
@@ -0,0 +1,145 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: profiles.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+const getProfile = `-- name: GetProfile :one
+SELECT id, username, display_name, bio, profile_visibility, created_at
+FROM users
+WHERE id = ?
+`
+
+type GetProfileRow struct {
+	ID                int64        `json:"id"`
+	Username          string       `json:"username"`
+	DisplayName       string       `json:"display_name"`
+	Bio               string       `json:"bio"`
+	ProfileVisibility string       `json:"profile_visibility"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+}
+
+func (q *Queries) GetProfile(ctx context.Context, id int64) (GetProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getProfile, id)
+	var i GetProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.ProfileVisibility,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProfileByUsername = `-- name: GetProfileByUsername :one
+SELECT id, username, display_name, bio, profile_visibility, created_at
+FROM users
+WHERE username = ?
+`
+
+type GetProfileByUsernameRow struct {
+	ID                int64        `json:"id"`
+	Username          string       `json:"username"`
+	DisplayName       string       `json:"display_name"`
+	Bio               string       `json:"bio"`
+	ProfileVisibility string       `json:"profile_visibility"`
+	CreatedAt         sql.NullTime `json:"created_at"`
+}
+
+func (q *Queries) GetProfileByUsername(ctx context.Context, username string) (GetProfileByUsernameRow, error) {
+	row := q.db.QueryRowContext(ctx, getProfileByUsername, username)
+	var i GetProfileByUsernameRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.ProfileVisibility,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listVisibleUserTags = `-- name: ListVisibleUserTags :many
+SELECT t.tag, COUNT(*) AS books
+FROM user_book_tags t
+JOIN user_books ub ON ub.user_id = t.user_id AND ub.book_id = t.book_id
+WHERE t.user_id = ?1 AND ub.visibility IN (/*SLICE:visibilities*/?)
+GROUP BY t.tag
+ORDER BY t.tag COLLATE NOCASE
+`
+
+type ListVisibleUserTagsParams struct {
+	UserID       int64    `json:"user_id"`
+	Visibilities []string `json:"visibilities"`
+}
+
+type ListVisibleUserTagsRow struct {
+	Tag   string `json:"tag"`
+	Books int64  `json:"books"`
+}
+
+func (q *Queries) ListVisibleUserTags(ctx context.Context, arg ListVisibleUserTagsParams) ([]ListVisibleUserTagsRow, error) {
+	query := listVisibleUserTags
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.Visibilities) > 0 {
+		for _, v := range arg.Visibilities {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:visibilities*/?", strings.Repeat(",?", len(arg.Visibilities))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:visibilities*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVisibleUserTagsRow
+	for rows.Next() {
+		var i ListVisibleUserTagsRow
+		if err := rows.Scan(&i.Tag, &i.Books); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProfile = `-- name: UpdateProfile :exec
+UPDATE users SET display_name = ?, bio = ?, profile_visibility = ?
+WHERE id = ?
+`
+
+type UpdateProfileParams struct {
+	DisplayName       string `json:"display_name"`
+	Bio               string `json:"bio"`
+	ProfileVisibility string `json:"profile_visibility"`
+	ID                int64  `json:"id"`
+}
+
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.ProfileVisibility,
+		arg.ID,
+	)
+	return err
+}
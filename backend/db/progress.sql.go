@@ -0,0 +1,202 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: progress.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createProgressEvent = `-- name: CreateProgressEvent :one
+INSERT INTO progress_events (user_id, book_id, read_id, unit, value, total, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, book_id, read_id, unit, value, total, created_at
+`
+
+type CreateProgressEventParams struct {
+	UserID    int64         `json:"user_id"`
+	BookID    int64         `json:"book_id"`
+	ReadID    sql.NullInt64 `json:"read_id"`
+	Unit      string        `json:"unit"`
+	Value     int64         `json:"value"`
+	Total     sql.NullInt64 `json:"total"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func (q *Queries) CreateProgressEvent(ctx context.Context, arg CreateProgressEventParams) (ProgressEvent, error) {
+	row := q.db.QueryRowContext(ctx, createProgressEvent,
+		arg.UserID,
+		arg.BookID,
+		arg.ReadID,
+		arg.Unit,
+		arg.Value,
+		arg.Total,
+		arg.CreatedAt,
+	)
+	var i ProgressEvent
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.ReadID,
+		&i.Unit,
+		&i.Value,
+		&i.Total,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestProgressTotal = `-- name: GetLatestProgressTotal :one
+SELECT total
+FROM progress_events
+WHERE user_id = ? AND book_id = ? AND unit = ? AND total IS NOT NULL
+ORDER BY created_at DESC, id DESC
+LIMIT 1
+`
+
+type GetLatestProgressTotalParams struct {
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Unit   string `json:"unit"`
+}
+
+func (q *Queries) GetLatestProgressTotal(ctx context.Context, arg GetLatestProgressTotalParams) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestProgressTotal, arg.UserID, arg.BookID, arg.Unit)
+	var total sql.NullInt64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const getUsualPace = `-- name: GetUsualPace :one
+SELECT CAST(COALESCE(SUM(CASE WHEN pages > 0 THEN pages END), 0) AS INTEGER) AS pages,
+    CAST(COALESCE(SUM(CASE WHEN pages > 0 THEN MAX(julianday(finish_date) - julianday(start_date), 1) END), 0) AS REAL) AS page_days,
+    COUNT(*) AS books,
+    CAST(COALESCE(SUM(MAX(julianday(finish_date) - julianday(start_date), 1)), 0) AS REAL) AS days
+FROM finished_books
+WHERE user_id = ? AND start_date IS NOT NULL AND finish_date >= start_date
+`
+
+type GetUsualPaceRow struct {
+	Pages    int64   `json:"pages"`
+	PageDays float64 `json:"page_days"`
+	Books    int64   `json:"books"`
+	Days     float64 `json:"days"`
+}
+
+// how fast the user gets through the books they finish: pages a day over those with a page count,
+// and books a day over all of them. A book finished the day it was started counts as a day.
+func (q *Queries) GetUsualPace(ctx context.Context, userID int64) (GetUsualPaceRow, error) {
+	row := q.db.QueryRowContext(ctx, getUsualPace, userID)
+	var i GetUsualPaceRow
+	err := row.Scan(
+		&i.Pages,
+		&i.PageDays,
+		&i.Books,
+		&i.Days,
+	)
+	return i, err
+}
+
+const listProgressEvents = `-- name: ListProgressEvents :many
+SELECT id, user_id, book_id, read_id, unit, value, total, created_at
+FROM progress_events
+WHERE user_id = ? AND book_id = ?
+ORDER BY created_at, id
+`
+
+type ListProgressEventsParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) ListProgressEvents(ctx context.Context, arg ListProgressEventsParams) ([]ProgressEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listProgressEvents, arg.UserID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgressEvent
+	for rows.Next() {
+		var i ProgressEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.ReadID,
+			&i.Unit,
+			&i.Value,
+			&i.Total,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReadingProgress = `-- name: ListReadingProgress :many
+SELECT r.book_id, r.start_date, pe.unit, pe.value, pe.total, pe.created_at
+FROM reads r
+JOIN progress_events pe ON pe.read_id = r.id
+WHERE r.user_id = ?1 AND r.status = 'reading'
+    AND (CAST(?2 AS INTEGER) = 0 OR r.book_id = ?2)
+    AND r.id = (SELECT MAX(latest.id) FROM reads latest WHERE latest.user_id = r.user_id AND latest.book_id = r.book_id)
+ORDER BY r.book_id, pe.created_at, pe.id
+`
+
+type ListReadingProgressParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+type ListReadingProgressRow struct {
+	BookID    int64         `json:"book_id"`
+	StartDate sql.NullTime  `json:"start_date"`
+	Unit      string        `json:"unit"`
+	Value     int64         `json:"value"`
+	Total     sql.NullInt64 `json:"total"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// progress on the current read of the books being read, all of them when book_id is 0
+func (q *Queries) ListReadingProgress(ctx context.Context, arg ListReadingProgressParams) ([]ListReadingProgressRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReadingProgress, arg.UserID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReadingProgressRow
+	for rows.Next() {
+		var i ListReadingProgressRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.StartDate,
+			&i.Unit,
+			&i.Value,
+			&i.Total,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,233 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: reads.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createRead = `-- name: CreateRead :one
+INSERT INTO reads (user_id, book_id, start_date, status)
+VALUES (?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateReadParams struct {
+	UserID    int64        `json:"user_id"`
+	BookID    int64        `json:"book_id"`
+	StartDate sql.NullTime `json:"start_date"`
+	Status    string       `json:"status"`
+}
+
+func (q *Queries) CreateRead(ctx context.Context, arg CreateReadParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createRead,
+		arg.UserID,
+		arg.BookID,
+		arg.StartDate,
+		arg.Status,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getCurrentRead = `-- name: GetCurrentRead :one
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE user_id = ? AND book_id = ?
+ORDER BY id DESC
+LIMIT 1
+`
+
+type GetCurrentReadParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) GetCurrentRead(ctx context.Context, arg GetCurrentReadParams) (Read, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentRead, arg.UserID, arg.BookID)
+	var i Read
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.StartDate,
+		&i.FinishDate,
+		&i.Status,
+		&i.Rating,
+		&i.Review,
+	)
+	return i, err
+}
+
+const getRead = `-- name: GetRead :one
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE id = ? AND user_id = ?
+`
+
+type GetReadParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetRead(ctx context.Context, arg GetReadParams) (Read, error) {
+	row := q.db.QueryRowContext(ctx, getRead, arg.ID, arg.UserID)
+	var i Read
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.StartDate,
+		&i.FinishDate,
+		&i.Status,
+		&i.Rating,
+		&i.Review,
+	)
+	return i, err
+}
+
+const listReadsByUserBook = `-- name: ListReadsByUserBook :many
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE user_id = ? AND book_id = ?
+ORDER BY id DESC
+`
+
+type ListReadsByUserBookParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) ListReadsByUserBook(ctx context.Context, arg ListReadsByUserBookParams) ([]Read, error) {
+	rows, err := q.db.QueryContext(ctx, listReadsByUserBook, arg.UserID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Read
+	for rows.Next() {
+		var i Read
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Status,
+			&i.Rating,
+			&i.Review,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const startUserBookRead = `-- name: StartUserBookRead :exec
+UPDATE user_books SET start_date = ?, finish_date = NULL, progress = 0, rating = NULL, review = NULL,
+    version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_books.user_id = ? AND user_books.book_id = ?
+`
+
+type StartUserBookReadParams struct {
+	StartDate sql.NullTime `json:"start_date"`
+	UserID    int64        `json:"user_id"`
+	BookID    int64        `json:"book_id"`
+}
+
+// a new read changes the entry, so earlier ETags no longer match. The rating and review were the
+// last read's, which keeps them, and saving the entry would otherwise copy them onto the new one.
+func (q *Queries) StartUserBookRead(ctx context.Context, arg StartUserBookReadParams) error {
+	_, err := q.db.ExecContext(ctx, startUserBookRead, arg.StartDate, arg.UserID, arg.BookID)
+	return err
+}
+
+const syncUserBookWithCurrentRead = `-- name: SyncUserBookWithCurrentRead :exec
+UPDATE user_books SET
+    start_date = (SELECT r.start_date FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1),
+    finish_date = (SELECT r.finish_date FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1),
+    rating = COALESCE((SELECT r.rating FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1), user_books.rating),
+    review = COALESCE((SELECT r.review FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1), user_books.review),
+    version = user_books.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_books.user_id = ? AND user_books.book_id = ?
+`
+
+type SyncUserBookWithCurrentReadParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) SyncUserBookWithCurrentRead(ctx context.Context, arg SyncUserBookWithCurrentReadParams) error {
+	_, err := q.db.ExecContext(ctx, syncUserBookWithCurrentRead, arg.UserID, arg.BookID)
+	return err
+}
+
+const updateCurrentRead = `-- name: UpdateCurrentRead :exec
+UPDATE reads SET start_date = ?, finish_date = ?, status = ?, rating = ?, review = ?
+WHERE id = (
+    SELECT MAX(r.id) FROM reads r WHERE r.user_id = ? AND r.book_id = ?
+)
+`
+
+type UpdateCurrentReadParams struct {
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Status     string         `json:"status"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+}
+
+func (q *Queries) UpdateCurrentRead(ctx context.Context, arg UpdateCurrentReadParams) error {
+	_, err := q.db.ExecContext(ctx, updateCurrentRead,
+		arg.StartDate,
+		arg.FinishDate,
+		arg.Status,
+		arg.Rating,
+		arg.Review,
+		arg.UserID,
+		arg.BookID,
+	)
+	return err
+}
+
+const updateRead = `-- name: UpdateRead :exec
+UPDATE reads SET start_date = ?, finish_date = ?, status = ?, rating = ?, review = ? WHERE id = ? AND user_id = ?
+`
+
+type UpdateReadParams struct {
+	StartDate  sql.NullTime   `json:"start_date"`
+	FinishDate sql.NullTime   `json:"finish_date"`
+	Status     string         `json:"status"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Review     sql.NullString `json:"review"`
+	ID         int64          `json:"id"`
+	UserID     int64          `json:"user_id"`
+}
+
+func (q *Queries) UpdateRead(ctx context.Context, arg UpdateReadParams) error {
+	_, err := q.db.ExecContext(ctx, updateRead,
+		arg.StartDate,
+		arg.FinishDate,
+		arg.Status,
+		arg.Rating,
+		arg.Review,
+		arg.ID,
+		arg.UserID,
+	)
+	return err
+}
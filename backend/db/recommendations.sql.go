@@ -0,0 +1,245 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: recommendations.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const clearRecommendations = `-- name: ClearRecommendations :exec
+DELETE FROM recommendations
+`
+
+func (q *Queries) ClearRecommendations(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearRecommendations)
+	return err
+}
+
+const createRecommendation = `-- name: CreateRecommendation :exec
+INSERT INTO recommendations (user_id, book_id, score, reasons, computed_at)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateRecommendationParams struct {
+	UserID     int64     `json:"user_id"`
+	BookID     int64     `json:"book_id"`
+	Score      float64   `json:"score"`
+	Reasons    string    `json:"reasons"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+func (q *Queries) CreateRecommendation(ctx context.Context, arg CreateRecommendationParams) error {
+	_, err := q.db.ExecContext(ctx, createRecommendation,
+		arg.UserID,
+		arg.BookID,
+		arg.Score,
+		arg.Reasons,
+		arg.ComputedAt,
+	)
+	return err
+}
+
+const listAllLibraryBooks = `-- name: ListAllLibraryBooks :many
+SELECT user_id, book_id FROM user_books
+`
+
+type ListAllLibraryBooksRow struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) ListAllLibraryBooks(ctx context.Context) ([]ListAllLibraryBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAllLibraryBooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllLibraryBooksRow
+	for rows.Next() {
+		var i ListAllLibraryBooksRow
+		if err := rows.Scan(&i.UserID, &i.BookID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookMetadata = `-- name: ListBookMetadata :many
+SELECT id, title, author FROM books
+`
+
+type ListBookMetadataRow struct {
+	ID     int64  `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+func (q *Queries) ListBookMetadata(ctx context.Context) ([]ListBookMetadataRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookMetadata)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookMetadataRow
+	for rows.Next() {
+		var i ListBookMetadataRow
+		if err := rows.Scan(&i.ID, &i.Title, &i.Author); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookSubjects = `-- name: ListBookSubjects :many
+SELECT DISTINCT t.book_id, CAST(lower(trim(t.tag)) AS TEXT) AS subject
+FROM user_book_tags t
+JOIN user_books ub ON ub.user_id = t.user_id AND ub.book_id = t.book_id
+WHERE ub.visibility = 'public' AND trim(t.tag) != ''
+`
+
+type ListBookSubjectsRow struct {
+	BookID  int64  `json:"book_id"`
+	Subject string `json:"subject"`
+}
+
+// the tags readers gave a book stand in for its subjects, only from public entries as
+// recommendations are shown to anyone
+func (q *Queries) ListBookSubjects(ctx context.Context) ([]ListBookSubjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookSubjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookSubjectsRow
+	for rows.Next() {
+		var i ListBookSubjectsRow
+		if err := rows.Scan(&i.BookID, &i.Subject); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLikedBooks = `-- name: ListLikedBooks :many
+SELECT user_id, book_id, CAST(rating AS INTEGER) AS rating, visibility = 'private' AS private
+FROM user_books
+WHERE rating >= 4
+`
+
+type ListLikedBooksRow struct {
+	UserID  int64 `json:"user_id"`
+	BookID  int64 `json:"book_id"`
+	Rating  int64 `json:"rating"`
+	Private bool  `json:"private"`
+}
+
+// the books each user rated highly, private entries only count towards their own recommendations
+func (q *Queries) ListLikedBooks(ctx context.Context) ([]ListLikedBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikedBooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLikedBooksRow
+	for rows.Next() {
+		var i ListLikedBooksRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.BookID,
+			&i.Rating,
+			&i.Private,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecommendations = `-- name: ListRecommendations :many
+SELECT r.book_id, b.title, b.author, b.image_url, r.score, r.reasons, r.computed_at
+FROM recommendations r
+JOIN books b ON b.id = r.book_id
+WHERE r.user_id = ?
+    AND NOT EXISTS (SELECT 1 FROM user_books ub WHERE ub.user_id = r.user_id AND ub.book_id = r.book_id)
+ORDER BY r.score DESC, r.book_id
+LIMIT ?
+`
+
+type ListRecommendationsParams struct {
+	UserID int64 `json:"user_id"`
+	Limit  int64 `json:"limit"`
+}
+
+type ListRecommendationsRow struct {
+	BookID     int64     `json:"book_id"`
+	Title      string    `json:"title"`
+	Author     string    `json:"author"`
+	ImageUrl   string    `json:"image_url"`
+	Score      float64   `json:"score"`
+	Reasons    string    `json:"reasons"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// books added to the library since the last run are left out
+func (q *Queries) ListRecommendations(ctx context.Context, arg ListRecommendationsParams) ([]ListRecommendationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecommendations, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecommendationsRow
+	for rows.Next() {
+		var i ListRecommendationsRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+			&i.Score,
+			&i.Reasons,
+			&i.ComputedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,464 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: reviews.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const addReviewReaction = `-- name: AddReviewReaction :exec
+INSERT INTO review_reactions (reviewer_id, book_id, user_id, reaction)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (reviewer_id, book_id, user_id, reaction) DO NOTHING
+`
+
+type AddReviewReactionParams struct {
+	ReviewerID int64  `json:"reviewer_id"`
+	BookID     int64  `json:"book_id"`
+	UserID     int64  `json:"user_id"`
+	Reaction   string `json:"reaction"`
+}
+
+func (q *Queries) AddReviewReaction(ctx context.Context, arg AddReviewReactionParams) error {
+	_, err := q.db.ExecContext(ctx, addReviewReaction,
+		arg.ReviewerID,
+		arg.BookID,
+		arg.UserID,
+		arg.Reaction,
+	)
+	return err
+}
+
+const countReviewComments = `-- name: CountReviewComments :one
+SELECT COUNT(*)
+FROM review_comments
+WHERE reviewer_id = ? AND book_id = ? AND deleted_at IS NULL AND hidden_at IS NULL
+`
+
+type CountReviewCommentsParams struct {
+	ReviewerID int64 `json:"reviewer_id"`
+	BookID     int64 `json:"book_id"`
+}
+
+func (q *Queries) CountReviewComments(ctx context.Context, arg CountReviewCommentsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countReviewComments, arg.ReviewerID, arg.BookID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createReviewComment = `-- name: CreateReviewComment :one
+INSERT INTO review_comments (reviewer_id, book_id, user_id, body)
+VALUES (?, ?, ?, ?)
+RETURNING id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by
+`
+
+type CreateReviewCommentParams struct {
+	ReviewerID int64  `json:"reviewer_id"`
+	BookID     int64  `json:"book_id"`
+	UserID     int64  `json:"user_id"`
+	Body       string `json:"body"`
+}
+
+func (q *Queries) CreateReviewComment(ctx context.Context, arg CreateReviewCommentParams) (ReviewComment, error) {
+	row := q.db.QueryRowContext(ctx, createReviewComment,
+		arg.ReviewerID,
+		arg.BookID,
+		arg.UserID,
+		arg.Body,
+	)
+	var i ReviewComment
+	err := row.Scan(
+		&i.ID,
+		&i.ReviewerID,
+		&i.BookID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HiddenBy,
+	)
+	return i, err
+}
+
+const deleteReviewComment = `-- name: DeleteReviewComment :exec
+UPDATE review_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) DeleteReviewComment(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteReviewComment, id)
+	return err
+}
+
+const dismissCommentReports = `-- name: DismissCommentReports :exec
+DELETE FROM comment_reports WHERE comment_id = ?
+`
+
+func (q *Queries) DismissCommentReports(ctx context.Context, commentID int64) error {
+	_, err := q.db.ExecContext(ctx, dismissCommentReports, commentID)
+	return err
+}
+
+const getReview = `-- name: GetReview :one
+SELECT ub.user_id, ub.book_id, ub.review, ub.rating, ub.visibility, ub.updated_at,
+    b.isbn, b.title, b.author, b.image_url
+FROM user_books ub
+JOIN books b ON b.id = ub.book_id
+WHERE ub.user_id = ? AND ub.book_id = ?
+`
+
+type GetReviewParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+type GetReviewRow struct {
+	UserID     int64          `json:"user_id"`
+	BookID     int64          `json:"book_id"`
+	Review     sql.NullString `json:"review"`
+	Rating     sql.NullInt64  `json:"rating"`
+	Visibility string         `json:"visibility"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	Isbn       string         `json:"isbn"`
+	Title      string         `json:"title"`
+	Author     string         `json:"author"`
+	ImageUrl   string         `json:"image_url"`
+}
+
+// the review on a library entry, the caller decides whether the viewer may see it from its visibility
+func (q *Queries) GetReview(ctx context.Context, arg GetReviewParams) (GetReviewRow, error) {
+	row := q.db.QueryRowContext(ctx, getReview, arg.UserID, arg.BookID)
+	var i GetReviewRow
+	err := row.Scan(
+		&i.UserID,
+		&i.BookID,
+		&i.Review,
+		&i.Rating,
+		&i.Visibility,
+		&i.UpdatedAt,
+		&i.Isbn,
+		&i.Title,
+		&i.Author,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getReviewComment = `-- name: GetReviewComment :one
+SELECT id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by
+FROM review_comments
+WHERE id = ?
+`
+
+func (q *Queries) GetReviewComment(ctx context.Context, id int64) (ReviewComment, error) {
+	row := q.db.QueryRowContext(ctx, getReviewComment, id)
+	var i ReviewComment
+	err := row.Scan(
+		&i.ID,
+		&i.ReviewerID,
+		&i.BookID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HiddenBy,
+	)
+	return i, err
+}
+
+const hideReviewComment = `-- name: HideReviewComment :execrows
+UPDATE review_comments SET hidden_at = CURRENT_TIMESTAMP, hidden_by = ? WHERE id = ? AND hidden_at IS NULL
+`
+
+type HideReviewCommentParams struct {
+	HiddenBy sql.NullInt64 `json:"hidden_by"`
+	ID       int64         `json:"id"`
+}
+
+func (q *Queries) HideReviewComment(ctx context.Context, arg HideReviewCommentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, hideReviewComment, arg.HiddenBy, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const isAdmin = `-- name: IsAdmin :one
+SELECT is_admin FROM users WHERE id = ?
+`
+
+func (q *Queries) IsAdmin(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isAdmin, id)
+	var is_admin bool
+	err := row.Scan(&is_admin)
+	return is_admin, err
+}
+
+const listReportedComments = `-- name: ListReportedComments :many
+SELECT c.id, c.reviewer_id, r.username AS reviewer, c.book_id, c.user_id, u.username, c.body, c.created_at,
+    COUNT(cr.user_id) AS reports, CAST(group_concat(cr.reason, char(10)) AS TEXT) AS reasons
+FROM review_comments c
+JOIN comment_reports cr ON cr.comment_id = c.id
+JOIN users u ON u.id = c.user_id
+JOIN users r ON r.id = c.reviewer_id
+WHERE c.deleted_at IS NULL AND c.hidden_at IS NULL
+    AND (c.created_at < datetime(?1) OR (c.created_at = datetime(?1) AND c.id < ?2))
+GROUP BY c.id
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT ?3
+`
+
+type ListReportedCommentsParams struct {
+	BeforeTime interface{} `json:"before_time"`
+	BeforeID   int64       `json:"before_id"`
+	Limit      int64       `json:"limit"`
+}
+
+type ListReportedCommentsRow struct {
+	ID         int64     `json:"id"`
+	ReviewerID int64     `json:"reviewer_id"`
+	Reviewer   string    `json:"reviewer"`
+	BookID     int64     `json:"book_id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	Reports    int64     `json:"reports"`
+	Reasons    string    `json:"reasons"`
+}
+
+// comments waiting for an admin: reported and still shown, newest first
+func (q *Queries) ListReportedComments(ctx context.Context, arg ListReportedCommentsParams) ([]ListReportedCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReportedComments, arg.BeforeTime, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReportedCommentsRow
+	for rows.Next() {
+		var i ListReportedCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReviewerID,
+			&i.Reviewer,
+			&i.BookID,
+			&i.UserID,
+			&i.Username,
+			&i.Body,
+			&i.CreatedAt,
+			&i.Reports,
+			&i.Reasons,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewComments = `-- name: ListReviewComments :many
+SELECT c.id, c.user_id, u.username, c.body, c.created_at, c.updated_at, c.deleted_at, c.hidden_at
+FROM review_comments c
+JOIN users u ON u.id = c.user_id
+WHERE c.reviewer_id = ?1 AND c.book_id = ?2
+    AND (c.created_at > datetime(?3) OR (c.created_at = datetime(?3) AND c.id > ?4))
+ORDER BY c.created_at, c.id
+LIMIT ?5
+`
+
+type ListReviewCommentsParams struct {
+	ReviewerID int64       `json:"reviewer_id"`
+	BookID     int64       `json:"book_id"`
+	AfterTime  interface{} `json:"after_time"`
+	AfterID    int64       `json:"after_id"`
+	Limit      int64       `json:"limit"`
+}
+
+type ListReviewCommentsRow struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	Username  string       `json:"username"`
+	Body      string       `json:"body"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	HiddenAt  sql.NullTime `json:"hidden_at"`
+}
+
+// a page of a review's comments, oldest first. Deleted and hidden comments stay in the list so
+// the conversation around them still reads in order, the caller leaves their body out.
+func (q *Queries) ListReviewComments(ctx context.Context, arg ListReviewCommentsParams) ([]ListReviewCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewComments,
+		arg.ReviewerID,
+		arg.BookID,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewCommentsRow
+	for rows.Next() {
+		var i ListReviewCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Username,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewReactions = `-- name: ListReviewReactions :many
+SELECT reaction, COUNT(*) AS reactions, CAST(MAX(user_id = ?1) AS BOOLEAN) AS reacted
+FROM review_reactions
+WHERE reviewer_id = ?2 AND book_id = ?3
+GROUP BY reaction
+ORDER BY reaction
+`
+
+type ListReviewReactionsParams struct {
+	ViewerID   int64 `json:"viewer_id"`
+	ReviewerID int64 `json:"reviewer_id"`
+	BookID     int64 `json:"book_id"`
+}
+
+type ListReviewReactionsRow struct {
+	Reaction  string `json:"reaction"`
+	Reactions int64  `json:"reactions"`
+	Reacted   bool   `json:"reacted"`
+}
+
+func (q *Queries) ListReviewReactions(ctx context.Context, arg ListReviewReactionsParams) ([]ListReviewReactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewReactions, arg.ViewerID, arg.ReviewerID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewReactionsRow
+	for rows.Next() {
+		var i ListReviewReactionsRow
+		if err := rows.Scan(&i.Reaction, &i.Reactions, &i.Reacted); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeReviewReaction = `-- name: RemoveReviewReaction :exec
+DELETE FROM review_reactions
+WHERE reviewer_id = ? AND book_id = ? AND user_id = ? AND reaction = ?
+`
+
+type RemoveReviewReactionParams struct {
+	ReviewerID int64  `json:"reviewer_id"`
+	BookID     int64  `json:"book_id"`
+	UserID     int64  `json:"user_id"`
+	Reaction   string `json:"reaction"`
+}
+
+func (q *Queries) RemoveReviewReaction(ctx context.Context, arg RemoveReviewReactionParams) error {
+	_, err := q.db.ExecContext(ctx, removeReviewReaction,
+		arg.ReviewerID,
+		arg.BookID,
+		arg.UserID,
+		arg.Reaction,
+	)
+	return err
+}
+
+const reportReviewComment = `-- name: ReportReviewComment :exec
+INSERT INTO comment_reports (comment_id, user_id, reason)
+VALUES (?, ?, ?)
+ON CONFLICT (comment_id, user_id) DO UPDATE SET reason = excluded.reason
+`
+
+type ReportReviewCommentParams struct {
+	CommentID int64  `json:"comment_id"`
+	UserID    int64  `json:"user_id"`
+	Reason    string `json:"reason"`
+}
+
+func (q *Queries) ReportReviewComment(ctx context.Context, arg ReportReviewCommentParams) error {
+	_, err := q.db.ExecContext(ctx, reportReviewComment, arg.CommentID, arg.UserID, arg.Reason)
+	return err
+}
+
+const restoreReviewComment = `-- name: RestoreReviewComment :execrows
+UPDATE review_comments SET hidden_at = NULL, hidden_by = NULL WHERE id = ? AND hidden_at IS NOT NULL
+`
+
+func (q *Queries) RestoreReviewComment(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreReviewComment, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateReviewComment = `-- name: UpdateReviewComment :one
+UPDATE review_comments SET body = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by
+`
+
+type UpdateReviewCommentParams struct {
+	Body string `json:"body"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateReviewComment(ctx context.Context, arg UpdateReviewCommentParams) (ReviewComment, error) {
+	row := q.db.QueryRowContext(ctx, updateReviewComment, arg.Body, arg.ID)
+	var i ReviewComment
+	err := row.Scan(
+		&i.ID,
+		&i.ReviewerID,
+		&i.BookID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HiddenBy,
+	)
+	return i, err
+}
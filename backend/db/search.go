@@ -0,0 +1,96 @@
+package db
+
+// Written by hand, sqlc doesn't understand FTS5's MATCH and auxiliary functions.
+
+import (
+	"context"
+	"html"
+	"strings"
+)
+
+// FTS5 marks matches with these, which can't clash with markup in the text, and the text is
+// escaped before they become <mark> tags
+const (
+	matchStart = "\ue000"
+	matchEnd   = "\ue001"
+)
+
+var matchMarkup = strings.NewReplacer(matchStart, "<mark>", matchEnd, "</mark>")
+
+// markMatches escapes text for HTML and wraps the matched terms in <mark></mark>
+func markMatches(text string) string {
+	return matchMarkup.Replace(html.EscapeString(text))
+}
+
+type SearchLibraryParams struct {
+	UserID int64
+	// Match is an FTS5 query expression
+	Match string
+	Limit int64
+}
+
+type SearchLibraryRow struct {
+	BookID   int64   `json:"book_id"`
+	Title    string  `json:"title"`
+	Author   string  `json:"author"`
+	ImageUrl string  `json:"image_url"`
+	Rank     float64 `json:"rank"`
+	// TitleHighlight and Snippet are HTML, escaped, with matched terms in <mark></mark>
+	TitleHighlight string `json:"title_highlight"`
+	Snippet        string `json:"snippet"`
+}
+
+// title matches count most, then author, then the user's own reviews and notes
+const searchLibrary = `
+SELECT
+    CAST(s.book_id AS INTEGER),
+    b.title,
+    b.author,
+    b.image_url,
+    bm25(library_search, 10.0, 5.0, 1.0, 3.0, 3.0) AS rank,
+    highlight(library_search, 0, char(57344), char(57345)),
+    snippet(library_search, -1, char(57344), char(57345), '…', 16)
+FROM library_search s
+JOIN books b ON b.id = s.book_id
+WHERE library_search MATCH ?
+    AND s.rowid BETWEEN ? AND ?
+ORDER BY rank
+LIMIT ?
+`
+
+// SearchLibrary runs a full-text search over one user's library, best matches first
+func (q *Queries) SearchLibrary(ctx context.Context, arg SearchLibraryParams) ([]SearchLibraryRow, error) {
+	// rowids are user_id * 2^32 + book_id, see migrations/0005_library_search.sql
+	first := arg.UserID << 32
+	last := first + (1 << 32) - 1
+	rows, err := q.db.QueryContext(ctx, searchLibrary, arg.Match, first, last, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchLibraryRow
+	for rows.Next() {
+		var i SearchLibraryRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+			&i.Rank,
+			&i.TitleHighlight,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		i.TitleHighlight = markMatches(i.TitleHighlight)
+		i.Snippet = markMatches(i.Snippet)
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
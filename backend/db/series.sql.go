@@ -0,0 +1,207 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: series.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const addSeriesBook = `-- name: AddSeriesBook :exec
+INSERT OR IGNORE INTO series_books (series_id, book_id, position) VALUES (?, ?, ?)
+`
+
+type AddSeriesBookParams struct {
+	SeriesID int64           `json:"series_id"`
+	BookID   int64           `json:"book_id"`
+	Position sql.NullFloat64 `json:"position"`
+}
+
+// for guesses like a series in an imported title, a position set by Calibre or by hand is kept
+func (q *Queries) AddSeriesBook(ctx context.Context, arg AddSeriesBookParams) error {
+	_, err := q.db.ExecContext(ctx, addSeriesBook, arg.SeriesID, arg.BookID, arg.Position)
+	return err
+}
+
+const listBookSeries = `-- name: ListBookSeries :many
+SELECT s.id, s.name, sb.position, CAST(sb.user_id != 0 AS BOOLEAN) AS manual
+FROM series_books sb
+JOIN series s ON s.id = sb.series_id
+WHERE sb.book_id = ?1 AND sb.user_id = (
+        SELECT MAX(own.user_id) FROM series_books own
+        WHERE own.series_id = sb.series_id AND own.book_id = sb.book_id AND own.user_id IN (0, ?2)
+    )
+ORDER BY s.name
+`
+
+type ListBookSeriesParams struct {
+	BookID int64 `json:"book_id"`
+	UserID int64 `json:"user_id"`
+}
+
+type ListBookSeriesRow struct {
+	ID       int64           `json:"id"`
+	Name     string          `json:"name"`
+	Position sql.NullFloat64 `json:"position"`
+	Manual   bool            `json:"manual"`
+}
+
+// the series a book is in for a user, manual is whether they put it there themselves
+func (q *Queries) ListBookSeries(ctx context.Context, arg ListBookSeriesParams) ([]ListBookSeriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookSeries, arg.BookID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookSeriesRow
+	for rows.Next() {
+		var i ListBookSeriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Position,
+			&i.Manual,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSeriesEntries = `-- name: ListSeriesEntries :many
+SELECT sb.series_id, s.name AS series_name, b.id AS book_id, b.title, b.author, b.image_url, sb.position,
+    CAST(EXISTS (
+        SELECT 1 FROM user_books ub WHERE ub.user_id = ?1 AND ub.book_id = b.id
+    ) AS BOOLEAN) AS in_library,
+    CAST(EXISTS (
+        SELECT 1 FROM reads r WHERE r.user_id = ?1 AND r.book_id = b.id AND r.status = 'finished'
+    ) AS BOOLEAN) AS finished,
+    CAST(COALESCE((
+        SELECT r.status FROM reads r WHERE r.user_id = ?1 AND r.book_id = b.id ORDER BY r.id DESC LIMIT 1
+    ), '') AS TEXT) AS read_status,
+    CAST(COALESCE((
+        SELECT max(r.finish_date) FROM reads r WHERE r.user_id = ?1 AND r.book_id = b.id AND r.status = 'finished'
+    ), '') AS TEXT) AS last_finished
+FROM series_books sb
+JOIN series s ON s.id = sb.series_id
+JOIN books b ON b.id = sb.book_id
+WHERE sb.user_id = (
+        SELECT MAX(own.user_id) FROM series_books own
+        WHERE own.series_id = sb.series_id AND own.book_id = sb.book_id AND own.user_id IN (0, ?1)
+    )
+    AND ((CAST(?2 AS INTEGER) = 0 AND sb.series_id IN (
+        SELECT sb2.series_id FROM series_books sb2
+        JOIN reads r ON r.book_id = sb2.book_id
+        WHERE sb2.user_id IN (0, ?1) AND r.user_id = ?1 AND r.status = 'finished'
+    ))
+    OR sb.series_id = CAST(?2 AS INTEGER))
+ORDER BY sb.series_id, sb.position IS NULL, sb.position, b.title
+`
+
+type ListSeriesEntriesParams struct {
+	UserID   int64 `json:"user_id"`
+	SeriesID int64 `json:"series_id"`
+}
+
+type ListSeriesEntriesRow struct {
+	SeriesID     int64           `json:"series_id"`
+	SeriesName   string          `json:"series_name"`
+	BookID       int64           `json:"book_id"`
+	Title        string          `json:"title"`
+	Author       string          `json:"author"`
+	ImageUrl     string          `json:"image_url"`
+	Position     sql.NullFloat64 `json:"position"`
+	InLibrary    bool            `json:"in_library"`
+	Finished     bool            `json:"finished"`
+	ReadStatus   string          `json:"read_status"`
+	LastFinished string          `json:"last_finished"`
+}
+
+// the entries of series in reading order, with where the user is with each. series_id 0 means every
+// series the user has finished an entry of. Entries are the catalog's and the user's own, theirs
+// taking the place of the catalog's for the same book.
+func (q *Queries) ListSeriesEntries(ctx context.Context, arg ListSeriesEntriesParams) ([]ListSeriesEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSeriesEntries, arg.UserID, arg.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSeriesEntriesRow
+	for rows.Next() {
+		var i ListSeriesEntriesRow
+		if err := rows.Scan(
+			&i.SeriesID,
+			&i.SeriesName,
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+			&i.Position,
+			&i.InLibrary,
+			&i.Finished,
+			&i.ReadStatus,
+			&i.LastFinished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeUserSeriesBook = `-- name: RemoveUserSeriesBook :execrows
+DELETE FROM series_books WHERE series_id = ? AND book_id = ? AND user_id = ?
+`
+
+type RemoveUserSeriesBookParams struct {
+	SeriesID int64 `json:"series_id"`
+	BookID   int64 `json:"book_id"`
+	UserID   int64 `json:"user_id"`
+}
+
+func (q *Queries) RemoveUserSeriesBook(ctx context.Context, arg RemoveUserSeriesBookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeUserSeriesBook, arg.SeriesID, arg.BookID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserSeriesBook = `-- name: SetUserSeriesBook :exec
+INSERT INTO series_books (series_id, book_id, user_id, position) VALUES (?, ?, ?, ?)
+ON CONFLICT (series_id, book_id, user_id) DO UPDATE SET position = excluded.position
+`
+
+type SetUserSeriesBookParams struct {
+	SeriesID int64           `json:"series_id"`
+	BookID   int64           `json:"book_id"`
+	UserID   int64           `json:"user_id"`
+	Position sql.NullFloat64 `json:"position"`
+}
+
+// a user's own entry, which only they see in place of the catalog's
+func (q *Queries) SetUserSeriesBook(ctx context.Context, arg SetUserSeriesBookParams) error {
+	_, err := q.db.ExecContext(ctx, setUserSeriesBook,
+		arg.SeriesID,
+		arg.BookID,
+		arg.UserID,
+		arg.Position,
+	)
+	return err
+}
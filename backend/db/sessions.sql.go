@@ -0,0 +1,270 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: sessions.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const getLatestPage = `-- name: GetLatestPage :one
+SELECT value
+FROM progress_events
+WHERE user_id = ? AND book_id = ? AND unit = 'pages'
+ORDER BY created_at DESC, id DESC
+LIMIT 1
+`
+
+type GetLatestPageParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+// where the reader is in a book as far as page progress goes
+func (q *Queries) GetLatestPage(ctx context.Context, arg GetLatestPageParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestPage, arg.UserID, arg.BookID)
+	var value int64
+	err := row.Scan(&value)
+	return value, err
+}
+
+const getRunningSession = `-- name: GetRunningSession :one
+SELECT id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+FROM reading_sessions
+WHERE user_id = ? AND ended_at IS NULL
+`
+
+func (q *Queries) GetRunningSession(ctx context.Context, userID int64) (ReadingSession, error) {
+	row := q.db.QueryRowContext(ctx, getRunningSession, userID)
+	var i ReadingSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.ReadID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.StartPage,
+		&i.EndPage,
+	)
+	return i, err
+}
+
+const getTimezone = `-- name: GetTimezone :one
+SELECT timezone FROM users WHERE id = ?
+`
+
+func (q *Queries) GetTimezone(ctx context.Context, id int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getTimezone, id)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const listReadingSessions = `-- name: ListReadingSessions :many
+SELECT id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+FROM reading_sessions
+WHERE user_id = ? AND book_id = ?
+ORDER BY started_at DESC, id DESC
+`
+
+type ListReadingSessionsParams struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+func (q *Queries) ListReadingSessions(ctx context.Context, arg ListReadingSessionsParams) ([]ReadingSession, error) {
+	rows, err := q.db.QueryContext(ctx, listReadingSessions, arg.UserID, arg.BookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadingSession
+	for rows.Next() {
+		var i ReadingSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BookID,
+			&i.ReadID,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.StartPage,
+			&i.EndPage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReadingTimes = `-- name: ListReadingTimes :many
+SELECT started_at AS read_at FROM reading_sessions WHERE reading_sessions.user_id = ?1 AND ended_at IS NOT NULL
+UNION ALL
+SELECT created_at FROM progress_events WHERE progress_events.user_id = ?1
+ORDER BY read_at
+`
+
+// every time the user read something, a finished session or a progress update, for streaks
+func (q *Queries) ListReadingTimes(ctx context.Context, userID int64) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, listReadingTimes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var read_at time.Time
+		if err := rows.Scan(&read_at); err != nil {
+			return nil, err
+		}
+		items = append(items, read_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionSpeeds = `-- name: ListSessionSpeeds :many
+SELECT s.id, s.started_at, s.ended_at,
+    CAST(s.end_page - s.start_page AS INTEGER) AS pages,
+    CAST(COALESCE((
+        SELECT group_concat(t.tag, char(31)) FROM user_book_tags t
+        WHERE t.user_id = s.user_id AND t.book_id = s.book_id
+    ), '') AS TEXT) AS tags
+FROM reading_sessions s
+WHERE s.user_id = ? AND s.ended_at IS NOT NULL AND s.end_page > s.start_page
+ORDER BY s.started_at
+`
+
+type ListSessionSpeedsRow struct {
+	ID        int64        `json:"id"`
+	StartedAt time.Time    `json:"started_at"`
+	EndedAt   sql.NullTime `json:"ended_at"`
+	Pages     int64        `json:"pages"`
+	Tags      string       `json:"tags"`
+}
+
+// finished sessions that moved forward through pages, with the tags of their book as genres
+func (q *Queries) ListSessionSpeeds(ctx context.Context, userID int64) ([]ListSessionSpeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionSpeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionSpeedsRow
+	for rows.Next() {
+		var i ListSessionSpeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Pages,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const startReadingSession = `-- name: StartReadingSession :one
+INSERT INTO reading_sessions (user_id, book_id, read_id, started_at, start_page)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+`
+
+type StartReadingSessionParams struct {
+	UserID    int64         `json:"user_id"`
+	BookID    int64         `json:"book_id"`
+	ReadID    sql.NullInt64 `json:"read_id"`
+	StartedAt time.Time     `json:"started_at"`
+	StartPage sql.NullInt64 `json:"start_page"`
+}
+
+func (q *Queries) StartReadingSession(ctx context.Context, arg StartReadingSessionParams) (ReadingSession, error) {
+	row := q.db.QueryRowContext(ctx, startReadingSession,
+		arg.UserID,
+		arg.BookID,
+		arg.ReadID,
+		arg.StartedAt,
+		arg.StartPage,
+	)
+	var i ReadingSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.ReadID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.StartPage,
+		&i.EndPage,
+	)
+	return i, err
+}
+
+const stopReadingSession = `-- name: StopReadingSession :one
+UPDATE reading_sessions
+SET ended_at = ?, end_page = ?
+WHERE id = ? AND ended_at IS NULL
+RETURNING id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+`
+
+type StopReadingSessionParams struct {
+	EndedAt sql.NullTime  `json:"ended_at"`
+	EndPage sql.NullInt64 `json:"end_page"`
+	ID      int64         `json:"id"`
+}
+
+func (q *Queries) StopReadingSession(ctx context.Context, arg StopReadingSessionParams) (ReadingSession, error) {
+	row := q.db.QueryRowContext(ctx, stopReadingSession, arg.EndedAt, arg.EndPage, arg.ID)
+	var i ReadingSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BookID,
+		&i.ReadID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.StartPage,
+		&i.EndPage,
+	)
+	return i, err
+}
+
+const updateTimezone = `-- name: UpdateTimezone :exec
+UPDATE users SET timezone = ? WHERE id = ?
+`
+
+type UpdateTimezoneParams struct {
+	Timezone string `json:"timezone"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateTimezone(ctx context.Context, arg UpdateTimezoneParams) error {
+	_, err := q.db.ExecContext(ctx, updateTimezone, arg.Timezone, arg.ID)
+	return err
+}
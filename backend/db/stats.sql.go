@@ -0,0 +1,357 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: stats.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const getStatsSummary = `-- name: GetStatsSummary :one
+SELECT COUNT(*) AS books,
+    CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages,
+    CAST(COALESCE(AVG(CASE WHEN start_date IS NOT NULL THEN julianday(finish_date) - julianday(start_date) END), 0) AS REAL) AS average_days
+FROM finished_books
+WHERE user_id = ?1
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+`
+
+type GetStatsSummaryParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+}
+
+type GetStatsSummaryRow struct {
+	Books       int64   `json:"books"`
+	Pages       int64   `json:"pages"`
+	AverageDays float64 `json:"average_days"`
+}
+
+// days to finish only takes in entries with a start date
+func (q *Queries) GetStatsSummary(ctx context.Context, arg GetStatsSummaryParams) (GetStatsSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getStatsSummary, arg.UserID, arg.FromTime, arg.ToTime)
+	var i GetStatsSummaryRow
+	err := row.Scan(&i.Books, &i.Pages, &i.AverageDays)
+	return i, err
+}
+
+const getStatsVersion = `-- name: GetStatsVersion :one
+SELECT stats_version FROM users WHERE id = ?
+`
+
+func (q *Queries) GetStatsVersion(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getStatsVersion, id)
+	var stats_version int64
+	err := row.Scan(&stats_version)
+	return stats_version, err
+}
+
+const listStatsByMonth = `-- name: ListStatsByMonth :many
+SELECT CAST(strftime('%Y-%m', finish_date) AS TEXT) AS month,
+    COUNT(*) AS books,
+    CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages
+FROM finished_books
+WHERE user_id = ?1
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+GROUP BY month
+ORDER BY month
+`
+
+type ListStatsByMonthParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+}
+
+type ListStatsByMonthRow struct {
+	Month string `json:"month"`
+	Books int64  `json:"books"`
+	Pages int64  `json:"pages"`
+}
+
+func (q *Queries) ListStatsByMonth(ctx context.Context, arg ListStatsByMonthParams) ([]ListStatsByMonthRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsByMonth, arg.UserID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsByMonthRow
+	for rows.Next() {
+		var i ListStatsByMonthRow
+		if err := rows.Scan(&i.Month, &i.Books, &i.Pages); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatsLongestReads = `-- name: ListStatsLongestReads :many
+SELECT book_id, title, author, start_date, finish_date,
+    CAST(julianday(finish_date) - julianday(start_date) AS REAL) AS days
+FROM finished_books
+WHERE user_id = ?1 AND start_date IS NOT NULL
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+ORDER BY days DESC, book_id
+LIMIT ?4
+`
+
+type ListStatsLongestReadsParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+	Limit    int64       `json:"limit"`
+}
+
+type ListStatsLongestReadsRow struct {
+	BookID     int64        `json:"book_id"`
+	Title      string       `json:"title"`
+	Author     string       `json:"author"`
+	StartDate  sql.NullTime `json:"start_date"`
+	FinishDate sql.NullTime `json:"finish_date"`
+	Days       float64      `json:"days"`
+}
+
+func (q *Queries) ListStatsLongestReads(ctx context.Context, arg ListStatsLongestReadsParams) ([]ListStatsLongestReadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsLongestReads,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsLongestReadsRow
+	for rows.Next() {
+		var i ListStatsLongestReadsRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Days,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatsRatings = `-- name: ListStatsRatings :many
+SELECT rating, COUNT(*) AS books
+FROM finished_books
+WHERE user_id = ?1 AND rating IS NOT NULL AND rating > 0
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+GROUP BY rating
+ORDER BY rating
+`
+
+type ListStatsRatingsParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+}
+
+type ListStatsRatingsRow struct {
+	Rating sql.NullInt64 `json:"rating"`
+	Books  int64         `json:"books"`
+}
+
+func (q *Queries) ListStatsRatings(ctx context.Context, arg ListStatsRatingsParams) ([]ListStatsRatingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsRatings, arg.UserID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsRatingsRow
+	for rows.Next() {
+		var i ListStatsRatingsRow
+		if err := rows.Scan(&i.Rating, &i.Books); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatsShortestReads = `-- name: ListStatsShortestReads :many
+SELECT book_id, title, author, start_date, finish_date,
+    CAST(julianday(finish_date) - julianday(start_date) AS REAL) AS days
+FROM finished_books
+WHERE user_id = ?1 AND start_date IS NOT NULL
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+ORDER BY days, book_id
+LIMIT ?4
+`
+
+type ListStatsShortestReadsParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+	Limit    int64       `json:"limit"`
+}
+
+type ListStatsShortestReadsRow struct {
+	BookID     int64        `json:"book_id"`
+	Title      string       `json:"title"`
+	Author     string       `json:"author"`
+	StartDate  sql.NullTime `json:"start_date"`
+	FinishDate sql.NullTime `json:"finish_date"`
+	Days       float64      `json:"days"`
+}
+
+func (q *Queries) ListStatsShortestReads(ctx context.Context, arg ListStatsShortestReadsParams) ([]ListStatsShortestReadsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsShortestReads,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsShortestReadsRow
+	for rows.Next() {
+		var i ListStatsShortestReadsRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.StartDate,
+			&i.FinishDate,
+			&i.Days,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatsTopAuthors = `-- name: ListStatsTopAuthors :many
+SELECT author, COUNT(*) AS books, CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages
+FROM finished_books
+WHERE user_id = ?1 AND author != ''
+    AND datetime(finish_date) >= datetime(?2) AND datetime(finish_date) < datetime(?3)
+GROUP BY author COLLATE NOCASE
+ORDER BY books DESC, pages DESC, author COLLATE NOCASE
+LIMIT ?4
+`
+
+type ListStatsTopAuthorsParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+	Limit    int64       `json:"limit"`
+}
+
+type ListStatsTopAuthorsRow struct {
+	Author string `json:"author"`
+	Books  int64  `json:"books"`
+	Pages  int64  `json:"pages"`
+}
+
+func (q *Queries) ListStatsTopAuthors(ctx context.Context, arg ListStatsTopAuthorsParams) ([]ListStatsTopAuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsTopAuthors,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsTopAuthorsRow
+	for rows.Next() {
+		var i ListStatsTopAuthorsRow
+		if err := rows.Scan(&i.Author, &i.Books, &i.Pages); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatsWeekdays = `-- name: ListStatsWeekdays :many
+SELECT CAST(strftime('%w', created_at) AS INTEGER) AS weekday, COUNT(*) AS updates
+FROM progress_events
+WHERE user_id = ?1
+    AND datetime(created_at) >= datetime(?2) AND datetime(created_at) < datetime(?3)
+GROUP BY weekday
+ORDER BY weekday
+`
+
+type ListStatsWeekdaysParams struct {
+	UserID   int64       `json:"user_id"`
+	FromTime interface{} `json:"from_time"`
+	ToTime   interface{} `json:"to_time"`
+}
+
+type ListStatsWeekdaysRow struct {
+	Weekday int64 `json:"weekday"`
+	Updates int64 `json:"updates"`
+}
+
+// when the user reads, from their progress updates. 0 is Sunday.
+func (q *Queries) ListStatsWeekdays(ctx context.Context, arg ListStatsWeekdaysParams) ([]ListStatsWeekdaysRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatsWeekdays, arg.UserID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStatsWeekdaysRow
+	for rows.Next() {
+		var i ListStatsWeekdaysRow
+		if err := rows.Scan(&i.Weekday, &i.Updates); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
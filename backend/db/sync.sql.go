@@ -0,0 +1,217 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: sync.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const addBookIdentifier = `-- name: AddBookIdentifier :execrows
+INSERT OR IGNORE INTO book_identifiers (book_id, type, value) VALUES (?, ?, ?)
+`
+
+type AddBookIdentifierParams struct {
+	BookID int64  `json:"book_id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+}
+
+func (q *Queries) AddBookIdentifier(ctx context.Context, arg AddBookIdentifierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addBookIdentifier, arg.BookID, arg.Type, arg.Value)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addUserBookTag = `-- name: AddUserBookTag :exec
+INSERT OR IGNORE INTO user_book_tags (user_id, book_id, tag, source) VALUES (?, ?, ?, ?)
+`
+
+type AddUserBookTagParams struct {
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Tag    string `json:"tag"`
+	Source string `json:"source"`
+}
+
+func (q *Queries) AddUserBookTag(ctx context.Context, arg AddUserBookTagParams) error {
+	_, err := q.db.ExecContext(ctx, addUserBookTag,
+		arg.UserID,
+		arg.BookID,
+		arg.Tag,
+		arg.Source,
+	)
+	return err
+}
+
+const clearSourceTags = `-- name: ClearSourceTags :exec
+DELETE FROM user_book_tags WHERE user_id = ? AND book_id = ? AND source = ?
+`
+
+type ClearSourceTagsParams struct {
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Source string `json:"source"`
+}
+
+func (q *Queries) ClearSourceTags(ctx context.Context, arg ClearSourceTagsParams) error {
+	_, err := q.db.ExecContext(ctx, clearSourceTags, arg.UserID, arg.BookID, arg.Source)
+	return err
+}
+
+const getBookByIdentifier = `-- name: GetBookByIdentifier :one
+SELECT b.id, b.isbn, b.title, b.description, b.author, b.image_url
+FROM book_identifiers bi
+JOIN books b ON b.id = bi.book_id
+WHERE bi.type = ? AND bi.value = ?
+`
+
+type GetBookByIdentifierParams struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (q *Queries) GetBookByIdentifier(ctx context.Context, arg GetBookByIdentifierParams) (Book, error) {
+	row := q.db.QueryRowContext(ctx, getBookByIdentifier, arg.Type, arg.Value)
+	var i Book
+	err := row.Scan(
+		&i.ID,
+		&i.Isbn,
+		&i.Title,
+		&i.Description,
+		&i.Author,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getExternalBook = `-- name: GetExternalBook :one
+SELECT book_id FROM external_books WHERE user_id = ? AND source = ? AND external_id = ?
+`
+
+type GetExternalBookParams struct {
+	UserID     int64  `json:"user_id"`
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+}
+
+func (q *Queries) GetExternalBook(ctx context.Context, arg GetExternalBookParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getExternalBook, arg.UserID, arg.Source, arg.ExternalID)
+	var book_id int64
+	err := row.Scan(&book_id)
+	return book_id, err
+}
+
+const linkExternalBook = `-- name: LinkExternalBook :exec
+INSERT INTO external_books (user_id, source, external_id, book_id, synced_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, source, external_id) DO UPDATE SET book_id = excluded.book_id, synced_at = CURRENT_TIMESTAMP
+`
+
+type LinkExternalBookParams struct {
+	UserID     int64  `json:"user_id"`
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+	BookID     int64  `json:"book_id"`
+}
+
+func (q *Queries) LinkExternalBook(ctx context.Context, arg LinkExternalBookParams) error {
+	_, err := q.db.ExecContext(ctx, linkExternalBook,
+		arg.UserID,
+		arg.Source,
+		arg.ExternalID,
+		arg.BookID,
+	)
+	return err
+}
+
+const listSourceTags = `-- name: ListSourceTags :many
+SELECT tag FROM user_book_tags WHERE user_id = ? AND book_id = ? AND source = ? ORDER BY tag
+`
+
+type ListSourceTagsParams struct {
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Source string `json:"source"`
+}
+
+func (q *Queries) ListSourceTags(ctx context.Context, arg ListSourceTagsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSourceTags, arg.UserID, arg.BookID, arg.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSeriesBook = `-- name: SetSeriesBook :execrows
+INSERT INTO series_books (series_id, book_id, position) VALUES (?, ?, ?)
+ON CONFLICT (series_id, book_id, user_id) DO UPDATE SET position = excluded.position
+WHERE series_books.position IS NOT excluded.position
+`
+
+type SetSeriesBookParams struct {
+	SeriesID int64           `json:"series_id"`
+	BookID   int64           `json:"book_id"`
+	Position sql.NullFloat64 `json:"position"`
+}
+
+// the catalog's entry, a later sync moves it
+func (q *Queries) SetSeriesBook(ctx context.Context, arg SetSeriesBookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSeriesBook, arg.SeriesID, arg.BookID, arg.Position)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserBookRating = `-- name: SetUserBookRating :execrows
+UPDATE user_books SET rating = ?1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ?2 AND book_id = ?3 AND (rating IS NULL OR rating != ?1)
+`
+
+type SetUserBookRatingParams struct {
+	Rating sql.NullInt64 `json:"rating"`
+	UserID int64         `json:"user_id"`
+	BookID int64         `json:"book_id"`
+}
+
+func (q *Queries) SetUserBookRating(ctx context.Context, arg SetUserBookRatingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserBookRating, arg.Rating, arg.UserID, arg.BookID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertSeries = `-- name: UpsertSeries :one
+INSERT INTO series (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = series.name
+RETURNING id
+`
+
+func (q *Queries) UpsertSeries(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, upsertSeries, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
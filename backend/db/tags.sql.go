@@ -0,0 +1,46 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: tags.sql
+
+package db
+
+import (
+	"context"
+)
+
+const listUserTags = `-- name: ListUserTags :many
+SELECT tag, COUNT(*) AS books
+FROM user_book_tags
+WHERE user_id = ?
+GROUP BY tag
+ORDER BY tag COLLATE NOCASE
+`
+
+type ListUserTagsRow struct {
+	Tag   string `json:"tag"`
+	Books int64  `json:"books"`
+}
+
+func (q *Queries) ListUserTags(ctx context.Context, userID int64) ([]ListUserTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTags, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserTagsRow
+	for rows.Next() {
+		var i ListUserTagsRow
+		if err := rows.Scan(&i.Tag, &i.Books); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,118 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: tokens.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createAccessToken = `-- name: CreateAccessToken :one
+INSERT INTO access_tokens (user_id, name, token_hash) VALUES (?, ?, ?)
+RETURNING id, user_id, name, token_hash, created_at, last_used_at
+`
+
+type CreateAccessTokenParams struct {
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	TokenHash string `json:"token_hash"`
+}
+
+func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) (AccessToken, error) {
+	row := q.db.QueryRowContext(ctx, createAccessToken, arg.UserID, arg.Name, arg.TokenHash)
+	var i AccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const deleteAccessToken = `-- name: DeleteAccessToken :execrows
+DELETE FROM access_tokens WHERE id = ? AND user_id = ?
+`
+
+type DeleteAccessTokenParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteAccessToken(ctx context.Context, arg DeleteAccessTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAccessTokenByHash = `-- name: GetAccessTokenByHash :one
+SELECT id, user_id, name, token_hash, created_at, last_used_at
+FROM access_tokens
+WHERE token_hash = ?
+`
+
+func (q *Queries) GetAccessTokenByHash(ctx context.Context, tokenHash string) (AccessToken, error) {
+	row := q.db.QueryRowContext(ctx, getAccessTokenByHash, tokenHash)
+	var i AccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const listAccessTokens = `-- name: ListAccessTokens :many
+SELECT id, user_id, name, token_hash, created_at, last_used_at
+FROM access_tokens
+WHERE user_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListAccessTokens(ctx context.Context, userID int64) ([]AccessToken, error) {
+	rows, err := q.db.QueryContext(ctx, listAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccessToken
+	for rows.Next() {
+		var i AccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchAccessToken = `-- name: TouchAccessToken :exec
+UPDATE access_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+func (q *Queries) TouchAccessToken(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, touchAccessToken, id)
+	return err
+}
@@ -0,0 +1,114 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: year_reviews.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const getYearReviewSettings = `-- name: GetYearReviewSettings :one
+SELECT u.id, u.username, CAST(COALESCE(yr.public, FALSE) AS BOOLEAN) AS public
+FROM users u
+LEFT JOIN year_reviews yr ON yr.user_id = u.id AND yr.year = ?1
+WHERE u.username = ?2
+`
+
+type GetYearReviewSettingsParams struct {
+	Year     int64  `json:"year"`
+	Username string `json:"username"`
+}
+
+type GetYearReviewSettingsRow struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Public   bool   `json:"public"`
+}
+
+// the owner of a report by username and whether they shared the year, a year never set is private
+func (q *Queries) GetYearReviewSettings(ctx context.Context, arg GetYearReviewSettingsParams) (GetYearReviewSettingsRow, error) {
+	row := q.db.QueryRowContext(ctx, getYearReviewSettings, arg.Year, arg.Username)
+	var i GetYearReviewSettingsRow
+	err := row.Scan(&i.ID, &i.Username, &i.Public)
+	return i, err
+}
+
+const listYearInReviewBooks = `-- name: ListYearInReviewBooks :many
+SELECT fb.book_id, fb.title, fb.author, b.image_url, fb.finish_date, fb.rating, fb.pages
+FROM finished_books fb
+JOIN books b ON b.id = fb.book_id
+JOIN user_books ub ON ub.user_id = fb.user_id AND ub.book_id = fb.book_id
+WHERE fb.user_id = ?1
+    AND (CAST(?2 AS BOOLEAN) OR ub.visibility = 'public')
+    AND CAST(strftime('%Y', fb.finish_date) AS INTEGER) = CAST(?3 AS INTEGER)
+ORDER BY fb.finish_date, fb.read_id
+`
+
+type ListYearInReviewBooksParams struct {
+	UserID int64 `json:"user_id"`
+	Owner  bool  `json:"owner"`
+	Year   int64 `json:"year"`
+}
+
+type ListYearInReviewBooksRow struct {
+	BookID     int64         `json:"book_id"`
+	Title      string        `json:"title"`
+	Author     string        `json:"author"`
+	ImageUrl   string        `json:"image_url"`
+	FinishDate sql.NullTime  `json:"finish_date"`
+	Rating     sql.NullInt64 `json:"rating"`
+	Pages      int64         `json:"pages"`
+}
+
+// the reads finished in a year with their covers, in the order they were finished, so a book
+// read twice is in it twice. Anyone but the owner only gets the public ones.
+func (q *Queries) ListYearInReviewBooks(ctx context.Context, arg ListYearInReviewBooksParams) ([]ListYearInReviewBooksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listYearInReviewBooks, arg.UserID, arg.Owner, arg.Year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListYearInReviewBooksRow
+	for rows.Next() {
+		var i ListYearInReviewBooksRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.Title,
+			&i.Author,
+			&i.ImageUrl,
+			&i.FinishDate,
+			&i.Rating,
+			&i.Pages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setYearReviewPublic = `-- name: SetYearReviewPublic :exec
+INSERT INTO year_reviews (user_id, year, public)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, year) DO UPDATE SET public = excluded.public, updated_at = CURRENT_TIMESTAMP
+`
+
+type SetYearReviewPublicParams struct {
+	UserID int64 `json:"user_id"`
+	Year   int64 `json:"year"`
+	Public bool  `json:"public"`
+}
+
+func (q *Queries) SetYearReviewPublic(ctx context.Context, arg SetYearReviewPublicParams) error {
+	_, err := q.db.ExecContext(ctx, setYearReviewPublic, arg.UserID, arg.Year, arg.Public)
+	return err
+}
@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/dghubble/gologin/v2 v2.5.0
 	github.com/mattn/go-sqlite3 v1.14.28
+	golang.org/x/image v0.25.0
 	golang.org/x/oauth2 v0.30.0
 	google.golang.org/api v0.232.0
 )
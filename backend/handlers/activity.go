@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"booktrackr/db"
+)
+
+const (
+	defaultTimelinePageSize = 30
+	maxTimelinePageSize     = 100
+	// timelineTimeLayout is how activity_events.created_at is stored
+	timelineTimeLayout = time.DateTime
+)
+
+// ActivityEvent is one thing a user did, as shown in a timeline
+type ActivityEvent struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Username  string `json:"username"`
+	BookID    int    `json:"book_id"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	ImageURL  string `json:"image_url"`
+	Value     *int64 `json:"value,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// timelineCursor is the position of the last event of a page
+type timelineCursor struct {
+	Time string `json:"t"`
+	ID   int64  `json:"id"`
+}
+
+func encodeTimelineCursor(cursor timelineCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTimelineCursor(value string) (timelineCursor, error) {
+	var cursor timelineCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, err
+	}
+	_, err = time.Parse(timelineTimeLayout, cursor.Time)
+	return cursor, err
+}
+
+// where listings start from when there is no cursor, for the newest and the oldest first
+var (
+	newestFirst = timelineCursor{Time: "9999-12-31 23:59:59", ID: math.MaxInt64}
+	oldestFirst = timelineCursor{Time: "0001-01-01 00:00:00", ID: 0}
+)
+
+// pageParams reads the limit and cursor of a listing paginated by time, writing a 422 when they
+// are not valid. start is the position to list from without a cursor.
+func pageParams(w http.ResponseWriter, query url.Values, start timelineCursor) (int64, timelineCursor, bool) {
+	limit := int64(defaultTimelinePageSize)
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 || n > maxTimelinePageSize {
+			WriteValidationErrors(w, map[string]string{"limit": "must be a number between 1 and " + strconv.Itoa(maxTimelinePageSize)})
+			return 0, start, false
+		}
+		limit = n
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodeTimelineCursor(value)
+		if err != nil {
+			WriteValidationErrors(w, map[string]string{"cursor": "is not a cursor returned by this listing"})
+			return 0, start, false
+		}
+		return limit, cursor, true
+	}
+	return limit, start, true
+}
+
+// timelineSource is a user whose events go in the timeline, private entries only show in their own
+type timelineSource struct {
+	userID         int64
+	includePrivate bool
+}
+
+// Timeline implements FollowHandler.
+// The timeline is built when it is read rather than copied to every follower when something
+// happens: each followed user's latest events come off idx_activity_events_user, at most one
+// page per user, and are merged here. That is a few short index scans per page however many
+// events there are in total, which SQLite handles well, and privacy changes apply at once.
+func (h *followHandler) Timeline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+
+		limit, before, ok := pageParams(w, r.URL.Query(), newestFirst)
+		if !ok {
+			return
+		}
+
+		followees, err := h.store.ListTimelineSources(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sources := []timelineSource{{userID: userID, includePrivate: true}}
+		for _, followee := range followees {
+			sources = append(sources, timelineSource{userID: followee})
+		}
+
+		// one row more than a page tells us whether there is another page
+		var events []db.ListUserActivityRow
+		for _, source := range sources {
+			rows, err := h.store.ListUserActivity(ctx, db.ListUserActivityParams{
+				UserID:         source.userID,
+				IncludePrivate: source.includePrivate,
+				BeforeTime:     before.Time,
+				BeforeID:       before.ID,
+				Limit:          limit + 1,
+			})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			events = append(events, rows...)
+		}
+		sort.Slice(events, func(i, j int) bool {
+			if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+				return events[i].CreatedAt.After(events[j].CreatedAt)
+			}
+			return events[i].ID > events[j].ID
+		})
+
+		var nextCursor string
+		if int64(len(events)) > limit {
+			events = events[:limit]
+			last := events[len(events)-1]
+			nextCursor = encodeTimelineCursor(timelineCursor{Time: last.CreatedAt.UTC().Format(timelineTimeLayout), ID: last.ID})
+		}
+		timeline := []ActivityEvent{}
+		for _, event := range events {
+			item := ActivityEvent{
+				ID:        int(event.ID),
+				Type:      event.Type,
+				Username:  event.Username,
+				BookID:    int(event.BookID),
+				Title:     event.Title,
+				Author:    event.Author,
+				ImageURL:  event.ImageUrl,
+				CreatedAt: event.CreatedAt.String(),
+			}
+			if event.Value.Valid {
+				item.Value = &event.Value.Int64
+			}
+			timeline = append(timeline, item)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message:    "Timeline retrieved successfully",
+			Data:       timeline,
+			NextCursor: nextCursor,
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+const (
+	AnnotationQuote     = "quote"
+	AnnotationHighlight = "highlight"
+	AnnotationNote      = "note"
+	// bookmarks only mark a place, they come from Kindle imports
+	AnnotationBookmark = "bookmark"
+
+	maxAnnotationLength = 10000
+	defaultQuotesLimit  = 20
+	maxQuotesLimit      = 100
+)
+
+// Annotation is a quote, highlight or note kept against a book in the library
+type Annotation struct {
+	ID     int    `json:"id"`
+	BookID int    `json:"book_id"`
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	// Page or Location place the annotation in the book, Location is a Kindle location like 170-172
+	Page      int    `json:"page,omitempty"`
+	Location  string `json:"location,omitempty"`
+	Chapter   string `json:"chapter,omitempty"`
+	Private   bool   `json:"private"`
+	Source    string `json:"source,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Quote is an annotation listed across the library, with the book it is from
+type Quote struct {
+	Annotation
+	BookTitle  string `json:"book_title"`
+	BookAuthor string `json:"book_author"`
+}
+
+type annotationRequest struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Page      int64  `json:"page"`
+	Location  string `json:"location"`
+	Chapter   string `json:"chapter"`
+	Private   bool   `json:"private"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toAnnotation(annotation db.Annotation) Annotation {
+	result := Annotation{
+		ID:        int(annotation.ID),
+		BookID:    int(annotation.BookID),
+		Type:      annotation.Type,
+		Text:      annotation.Text,
+		Page:      int(annotation.Page.Int64),
+		Location:  annotation.Location.String,
+		Chapter:   annotation.Chapter.String,
+		Private:   annotation.Private,
+		Source:    annotation.Source,
+		CreatedAt: annotation.CreatedAt.String(),
+	}
+	if annotation.UpdatedAt.Valid {
+		result.UpdatedAt = annotation.UpdatedAt.Time.String()
+	}
+	return result
+}
+
+// toQuote takes the ListQuotes and GetRandomQuote rows, which have the same columns
+func toQuote(row db.ListQuotesRow) Quote {
+	return Quote{
+		Annotation: toAnnotation(db.Annotation{
+			ID:        row.ID,
+			UserID:    row.UserID,
+			BookID:    row.BookID,
+			Type:      row.Type,
+			Text:      row.Text,
+			Page:      row.Page,
+			Location:  row.Location,
+			CreatedAt: row.CreatedAt,
+			Source:    row.Source,
+			Chapter:   row.Chapter,
+			Private:   row.Private,
+			UpdatedAt: row.UpdatedAt,
+		}),
+		BookTitle:  row.BookTitle,
+		BookAuthor: row.BookAuthor,
+	}
+}
+
+// validate returns field errors for an annotation a client wrote
+func (req annotationRequest) validate() map[string]string {
+	fields := map[string]string{}
+	switch req.Type {
+	case AnnotationQuote, AnnotationHighlight, AnnotationNote:
+		if req.Text == "" {
+			fields["text"] = "text is required"
+		}
+	case AnnotationBookmark:
+	default:
+		fields["type"] = "type must be one of quote, highlight, note or bookmark"
+	}
+	if len(req.Text) > maxAnnotationLength {
+		fields["text"] = "text must be at most 10000 characters"
+	}
+	if req.Page < 0 {
+		fields["page"] = "page cannot be negative"
+	}
+	if req.CreatedAt != "" {
+		if _, err := time.Parse(time.RFC3339, req.CreatedAt); err != nil {
+			fields["created_at"] = "created_at must be an RFC3339 timestamp"
+		}
+	}
+	return fields
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// annotationIDs parses the {id} and {annotationID} path wildcards
+func annotationIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	bookID, err := pathID(r, "id")
+	if err != nil {
+		WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	annotationID, err := pathID(r, "annotationID")
+	if err != nil {
+		WriteJSONError(w, "Invalid annotation ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return bookID, annotationID, true
+}
+
+// ListAnnotations implements BookHandler.
+// Annotations come back in reading order.
+func (b *bookHandler) ListAnnotations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		annotations, err := b.store.ListAnnotations(ctx, db.ListAnnotationsParams{
+			UserID: userID,
+			BookID: bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		kind := r.URL.Query().Get("type")
+		result := []Annotation{}
+		for _, annotation := range annotations {
+			if kind != "" && annotation.Type != kind {
+				continue
+			}
+			result = append(result, toAnnotation(annotation))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Annotations retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// CreateAnnotation implements BookHandler.
+func (b *bookHandler) CreateAnnotation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req annotationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fields := req.validate(); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		createdAt := time.Now()
+		if req.CreatedAt != "" {
+			createdAt, _ = time.Parse(time.RFC3339, req.CreatedAt)
+		}
+
+		if _, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		annotationID, err := b.store.CreateAnnotation(ctx, db.CreateAnnotationParams{
+			UserID:    userID,
+			BookID:    bookID,
+			Type:      req.Type,
+			Text:      req.Text,
+			Page:      sql.NullInt64{Int64: req.Page, Valid: req.Page > 0},
+			Location:  nullString(req.Location),
+			Chapter:   nullString(req.Chapter),
+			Private:   req.Private,
+			CreatedAt: createdAt.UTC(),
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		annotation, err := b.store.GetAnnotation(ctx, db.GetAnnotationParams{ID: annotationID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Added %s %d to book %d for user %d", req.Type, annotationID, bookID, userID)
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Annotation created successfully",
+			Data:    toAnnotation(annotation),
+		})
+	}
+}
+
+// GetAnnotation implements BookHandler.
+func (b *bookHandler) GetAnnotation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, annotationID, ok := annotationIDs(w, r)
+		if !ok {
+			return
+		}
+		annotation, err := b.store.GetAnnotation(ctx, db.GetAnnotationParams{ID: annotationID, UserID: userID})
+		if err != nil || annotation.BookID != bookID {
+			WriteJSONError(w, "Annotation not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Annotation retrieved successfully",
+			Data:    toAnnotation(annotation),
+		})
+	}
+}
+
+// UpdateAnnotation implements BookHandler.
+// The body replaces the annotation, its created time is kept.
+func (b *bookHandler) UpdateAnnotation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, annotationID, ok := annotationIDs(w, r)
+		if !ok {
+			return
+		}
+		var req annotationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields := req.validate()
+		if req.CreatedAt != "" {
+			fields["created_at"] = "created_at cannot be changed"
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		updated, err := b.store.UpdateAnnotation(ctx, db.UpdateAnnotationParams{
+			Type:     req.Type,
+			Text:     req.Text,
+			Page:     sql.NullInt64{Int64: req.Page, Valid: req.Page > 0},
+			Location: nullString(req.Location),
+			Chapter:  nullString(req.Chapter),
+			Private:  req.Private,
+			ID:       annotationID,
+			UserID:   userID,
+			BookID:   bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if updated == 0 {
+			WriteJSONError(w, "Annotation not found", http.StatusNotFound)
+			return
+		}
+		annotation, err := b.store.GetAnnotation(ctx, db.GetAnnotationParams{ID: annotationID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Annotation updated successfully",
+			Data:    toAnnotation(annotation),
+		})
+	}
+}
+
+// DeleteAnnotation implements BookHandler.
+func (b *bookHandler) DeleteAnnotation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, annotationID, ok := annotationIDs(w, r)
+		if !ok {
+			return
+		}
+		deleted, err := b.store.DeleteAnnotation(ctx, db.DeleteAnnotationParams{
+			ID:     annotationID,
+			UserID: userID,
+			BookID: bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Annotation not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Annotation deleted successfully",
+		})
+	}
+}
+
+// ListQuotes implements BookHandler.
+// Quotes and highlights from the whole library, newest first. next_cursor pages through them.
+func (b *bookHandler) ListQuotes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		query := r.URL.Query()
+		limit := int64(defaultQuotesLimit)
+		if value := query.Get("limit"); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 || n > maxQuotesLimit {
+				WriteJSONError(w, "limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		// the cursor is the id of the last quote on the previous page
+		beforeID := int64(math.MaxInt64)
+		if value := query.Get("cursor"); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 {
+				WriteJSONError(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			beforeID = n
+		}
+
+		total, err := b.store.CountQuotes(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows, err := b.store.ListQuotes(ctx, db.ListQuotesParams{
+			UserID:   userID,
+			BeforeID: beforeID,
+			Limit:    limit + 1,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		nextCursor := ""
+		if int64(len(rows)) > limit {
+			rows = rows[:limit]
+			nextCursor = strconv.FormatInt(rows[len(rows)-1].ID, 10)
+		}
+		quotes := []Quote{}
+		for _, row := range rows {
+			quotes = append(quotes, toQuote(row))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message:    "Quotes retrieved successfully",
+			Data:       quotes,
+			NextCursor: nextCursor,
+			Total:      &total,
+		})
+	}
+}
+
+// RandomQuote implements BookHandler.
+func (b *bookHandler) RandomQuote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		row, err := b.store.GetRandomQuote(ctx, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "No quotes saved yet", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Quote retrieved successfully",
+			Data:    toQuote(db.ListQuotesRow(row)),
+		})
+	}
+}
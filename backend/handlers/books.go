@@ -15,6 +15,7 @@ import (
 	gBooks "google.golang.org/api/books/v1"
 
 	books "booktrackr/pkg/googlebooks"
+	"booktrackr/pkg/openlibrary"
 )
 
 type UserBook struct {
@@ -29,6 +30,27 @@ type UserBook struct {
 	FinishDate  string `json:"finish_date"`
 	Rating      int    `json:"rating"`
 	Review      string `json:"review"`
+	AddedAt     string `json:"added_at,omitempty"`
+	Visibility  string `json:"visibility,omitempty"`
+	// Forecast is only given for books being read, when there is enough to estimate from
+	Forecast *Forecast `json:"forecast,omitempty"`
+}
+
+func toUserBook(book db.GetUserBookRow) UserBook {
+	return UserBook{
+		ID:          int(book.BookID),
+		Isbn:        book.Isbn,
+		Title:       book.Title,
+		Description: book.Description,
+		Author:      book.Author,
+		ImageURL:    book.ImageUrl,
+		Progress:    int(book.Progress),
+		StartDate:   book.StartDate.Time.String(),
+		FinishDate:  book.FinishDate.Time.String(),
+		Rating:      int(book.Rating.Int64),
+		Review:      book.Review.String,
+		Visibility:  book.Visibility,
+	}
 }
 
 type BookHandler interface {
@@ -43,11 +65,28 @@ type BookHandler interface {
 	ListUserBooks() http.HandlerFunc
 	GetBookByUserID() http.HandlerFunc
 	UpdateUserBook() http.HandlerFunc
+	ListReads() http.HandlerFunc
+	StartRead() http.HandlerFunc
+	UpdateRead() http.HandlerFunc
+	ListProgress() http.HandlerFunc
+	RecordProgress() http.HandlerFunc
+	PatchUserBook() http.HandlerFunc
+	SearchLibrary() http.HandlerFunc
+	ListAnnotations() http.HandlerFunc
+	CreateAnnotation() http.HandlerFunc
+	GetAnnotation() http.HandlerFunc
+	UpdateAnnotation() http.HandlerFunc
+	DeleteAnnotation() http.HandlerFunc
+	ListQuotes() http.HandlerFunc
+	RandomQuote() http.HandlerFunc
 }
 
 type bookHandler struct {
+	conn  *sql.DB
 	store *db.Queries
 	svc   books.BookService
+	// Open Library knows the series of books Google Books doesn't name
+	editions openlibrary.BookService
 }
 
 // UpdateUserBook implements BookHandler.
@@ -74,20 +113,30 @@ func (b *bookHandler) UpdateUserBook() http.HandlerFunc {
 			return
 		}
 
-		var finishDate sql.NullTime
-		var progress sql.NullInt64
-		var rating sql.NullInt64
-		var review sql.NullString
+		current, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !checkIfMatch(w, r, current) {
+			return
+		}
+
+		// PUT keeps its old meaning, a zero rating or empty review clears the column
+		update := userBookUpdate{StartDate: current.StartDate, Visibility: current.Visibility}
 		if req.Progress != 0 {
 			// Check if the progress is between 0 and 100
 			if req.Progress < 0 || req.Progress > 100 {
 				WriteJSONError(w, "Progress must be between 0 and 100", http.StatusBadRequest)
 				return
 			}
-			// convert to sql.NullInt64
-			progress = sql.NullInt64{Int64: int64(req.Progress), Valid: true}
-		} else {
-			progress = sql.NullInt64{Int64: 0, Valid: false}
+			progress := int64(req.Progress)
+			update.Progress = &progress
 		}
 
 		if req.Rating != 0 {
@@ -97,38 +146,41 @@ func (b *bookHandler) UpdateUserBook() http.HandlerFunc {
 				return
 			}
 			// convert to sql.NullInt64
-			rating = sql.NullInt64{Int64: int64(req.Rating), Valid: true}
-		} else {
-			rating = sql.NullInt64{Int64: 0, Valid: false}
+			update.Rating = sql.NullInt64{Int64: int64(req.Rating), Valid: true}
 		}
 
 		if req.Review != "" {
-			review = sql.NullString{String: req.Review, Valid: true}
-		} else {
-			review = sql.NullString{String: "", Valid: false}
+			update.Review = sql.NullString{String: req.Review, Valid: true}
+		}
+
+		if req.StartDate != "" {
+			startDate, err := parseDate(req.StartDate)
+			if err != nil {
+				WriteJSONError(w, "Invalid start date", http.StatusBadRequest)
+				return
+			}
+			update.StartDate = sql.NullTime{Time: startDate, Valid: true}
 		}
 
 		if req.FinishDate != "" {
-			// convert string to time.Time
-			parseDate, err := time.Parse(time.RFC3339, req.FinishDate)
+			finishDate, err := parseDate(req.FinishDate)
 			if err != nil {
-				log.Error("Error parsing finish date: %v", err)
+				WriteJSONError(w, "Invalid finish date", http.StatusBadRequest)
+				return
 			}
-			finishDate = sql.NullTime{Time: parseDate, Valid: true}
+			update.FinishDate = sql.NullTime{Time: finishDate, Valid: true}
 		}
 
-		err = b.store.UpdateUserBook(ctx, db.UpdateUserBookParams{
-			UserID:     userID,
-			BookID:     bookID,
-			Progress:   progress,
-			FinishDate: finishDate,
-			Rating:     rating,
-			Review:     review,
-		})
+		err = b.saveUserBook(ctx, userID, bookID, current.Version, update)
+		if err == errVersionConflict {
+			b.writeVersionConflict(ctx, w, userID, bookID)
+			return
+		}
 		if err != nil {
 			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		w.Header().Set("ETag", userBookETag(current.Version+1))
 		WriteJSON(w, http.StatusOK, JSONResponse{
 			Message: "Book updated successfully",
 			Data:    nil,
@@ -177,21 +229,17 @@ func (b *bookHandler) GetBookByUserID() http.HandlerFunc {
 			return
 		}
 		log.Info("Book retrieved: %+v", book)
+		forecasts, err := readingForecasts(ctx, b.store, userID, bookID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		userBook := toUserBook(book)
+		userBook.Forecast = forecasts[bookID]
+		w.Header().Set("ETag", userBookETag(book.Version))
 		WriteJSON(w, http.StatusOK, JSONResponse{
 			Message: "Book retrieved successfully",
-			Data: UserBook{
-				ID:          int(book.BookID),
-				Isbn:        book.Isbn,
-				Title:       book.Title,
-				Description: book.Description,
-				Author:      book.Author,
-				ImageURL:    book.ImageUrl,
-				Progress:    int(book.Progress.Int64),
-				StartDate:   book.StartDate.Time.String(),
-				FinishDate:  book.FinishDate.Time.String(),
-				Rating:      int(book.Rating.Int64),
-				Review:      book.Review.String,
-			},
+			Data:    userBook,
 		})
 	}
 }
@@ -231,12 +279,44 @@ func (b *bookHandler) ListUserBooks() http.HandlerFunc {
 		// extract userID from context
 		userID := GetUserID(r.Context())
 		ctx := r.Context()
-		books, err := b.store.ListBooksByUser(ctx, userID)
+		params, fields := parseLibraryQuery(r.URL.Query())
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		params.UserID = userID
+
+		total, err := b.store.CountLibrary(ctx, userID, params.Filter)
 		if err != nil {
 			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		var userBooks []UserBook
+		// one extra row tells us whether there is another page
+		limit := params.Limit
+		params.Limit++
+		books, err := b.store.ListLibrary(ctx, params)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var nextCursor string
+		if int64(len(books)) > limit {
+			books = books[:limit]
+			last := books[len(books)-1]
+			nextCursor = encodeLibraryCursor(db.LibraryCursor{
+				Sort:   params.Sort,
+				Desc:   params.Desc,
+				Key:    last.SortKey,
+				BookID: last.ID,
+			})
+		}
+
+		forecasts, err := readingForecasts(ctx, b.store, userID, 0)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		userBooks := []UserBook{}
 		for _, book := range books {
 			userBooks = append(userBooks, UserBook{
 				ID:          int(book.ID),
@@ -245,22 +325,24 @@ func (b *bookHandler) ListUserBooks() http.HandlerFunc {
 				Description: book.Description,
 				Author:      book.Author,
 				ImageURL:    book.ImageUrl,
-				Progress:    int(book.Progress.Int64),
+				Progress:    int(book.Progress),
 				StartDate:   book.StartDate.Time.String(),
 				FinishDate:  book.FinishDate.Time.String(),
 				Rating:      int(book.Rating.Int64),
+				Review:      book.Review.String,
+				AddedAt:     book.AddedAt.Time.String(),
+				Forecast:    forecasts[book.ID],
 			})
 		}
-		if len(userBooks) == 0 {
-			WriteJSON(w, http.StatusOK, JSONResponse{
-				Message: "No books found",
-				Data:    []any{},
-			})
-			return
+		message := "Books retrieved successfully"
+		if total == 0 {
+			message = "No books found"
 		}
 		WriteJSON(w, http.StatusOK, JSONResponse{
-			Message: "Books retrieved successfully",
-			Data:    userBooks,
+			Message:    message,
+			Data:       userBooks,
+			NextCursor: nextCursor,
+			Total:      &total,
 		})
 	}
 }
@@ -270,7 +352,31 @@ func (b *bookHandler) CreateBook(ctx context.Context, params db.CreateBookParams
 	panic("unimplemented")
 }
 
+// providerSeries is the series Open Library has for an ISBN, empty when it has none
+func (b *bookHandler) providerSeries(isbn string) (string, *float64) {
+	if isbn == "" {
+		return "", nil
+	}
+	edition, err := b.editions.GetBook(isbn)
+	if err != nil {
+		if err != openlibrary.ErrNotFound {
+			log.Info("No series from Open Library for %s: %v", isbn, err)
+		}
+		return "", nil
+	}
+	name := strings.TrimSpace(edition.Series)
+	if name == "" || len(name) > maxSeriesName {
+		return "", nil
+	}
+	if edition.SeriesPosition <= 0 {
+		return name, nil
+	}
+	return name, &edition.SeriesPosition
+}
+
 // CreateUserBook implements BookHandler.
+// The series given with the book is the user's own, without one the book goes in the series the
+// provider has for its ISBN, for everyone.
 func (b *bookHandler) CreateUserBook() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// extract userID from context
@@ -282,12 +388,37 @@ func (b *bookHandler) CreateUserBook() http.HandlerFunc {
 			Description string `json:"description"`
 			Author      string `json:"author"`
 			ImageURL    string `json:"image_url"`
+			// the series the user puts the book in, if they know it
+			Series         string   `json:"series"`
+			SeriesPosition *float64 `json:"series_position"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			WriteJSONError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		bookID, err := b.store.CreateBook(ctx, db.CreateBookParams{
+		req.Series = strings.TrimSpace(req.Series)
+		if len(req.Series) > maxSeriesName {
+			WriteValidationErrors(w, map[string]string{"series": "is too long"})
+			return
+		}
+		if req.SeriesPosition != nil && (req.Series == "" || *req.SeriesPosition <= 0) {
+			WriteValidationErrors(w, map[string]string{"series_position": "must be greater than 0 and comes with a series"})
+			return
+		}
+		seriesUserID := userID
+		if req.Series == "" {
+			seriesUserID = 0
+			req.Series, req.SeriesPosition = b.providerSeries(req.Isbn)
+		}
+		// the book, the library entry and its first read are created together or not at all
+		tx, err := b.conn.BeginTx(ctx, nil)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := b.store.WithTx(tx)
+		bookID, err := qtx.CreateBook(ctx, db.CreateBookParams{
 			Isbn:        req.Isbn,
 			Title:       req.Title,
 			Description: req.Description,
@@ -298,7 +429,7 @@ func (b *bookHandler) CreateUserBook() http.HandlerFunc {
 			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		err = b.store.CreateUserBook(ctx, db.CreateUserBookParams{
+		err = qtx.CreateUserBook(ctx, db.CreateUserBookParams{
 			UserID: userID,
 			BookID: bookID,
 		})
@@ -306,6 +437,27 @@ func (b *bookHandler) CreateUserBook() http.HandlerFunc {
 			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if req.Series != "" {
+			if err := setSeries(ctx, qtx, seriesUserID, bookID, req.Series, req.SeriesPosition); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		// adding a book starts its first read
+		_, err = qtx.CreateRead(ctx, db.CreateReadParams{
+			UserID:    userID,
+			BookID:    bookID,
+			StartDate: sql.NullTime{Time: time.Now(), Valid: true},
+			Status:    ReadStatusReading,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		WriteJSON(w, http.StatusCreated, JSONResponse{
 			Message: "Book created successfully",
 			Data: map[string]interface{}{
@@ -315,6 +467,7 @@ func (b *bookHandler) CreateUserBook() http.HandlerFunc {
 				"description": req.Description,
 				"author":      req.Author,
 				"image_url":   req.ImageURL,
+				"series":      req.Series,
 			},
 		})
 	}
@@ -345,14 +498,16 @@ func (b *bookHandler) UpdateBook(ctx context.Context, params db.UpdateBookParams
 	panic("unimplemented")
 }
 
-func NewBookHandler(store *db.Queries) BookHandler {
+func NewBookHandler(conn *sql.DB, store *db.Queries) BookHandler {
 	svc, err := books.NewGoogleBooksService()
 	if err != nil {
 		log.Fatal("Failed to create Google Books service: %v", err)
 	}
 	return &bookHandler{
-		store: store,
-		svc:   svc,
+		conn:     conn,
+		store:    store,
+		svc:      svc,
+		editions: openlibrary.NewOpenLibraryService(),
 	}
 }
 
@@ -386,7 +541,7 @@ func BooksHandler(store *db.Queries) http.HandlerFunc {
 					Description: book.Description,
 					Author:      book.Author,
 					ImageURL:    book.ImageUrl,
-					Progress:    int(book.Progress.Int64),
+					Progress:    int(book.Progress),
 					StartDate:   book.StartDate.Time.String(),
 					FinishDate:  book.FinishDate.Time.String(),
 					Rating:      int(book.Rating.Int64),
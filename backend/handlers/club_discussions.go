@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// ClubPost is a post in a section's discussion with its replies. Deleted posts stay in the
+// thread without their body so the replies to them keep their place.
+type ClubPost struct {
+	ID        int         `json:"id"`
+	Username  string      `json:"username"`
+	Body      string      `json:"body"`
+	Deleted   bool        `json:"deleted,omitempty"`
+	CreatedAt string      `json:"created_at"`
+	Replies   []*ClubPost `json:"replies"`
+}
+
+// ClubThread is a section's discussion. While it is locked the posts are left out, the
+// section still says how many there are.
+type ClubThread struct {
+	Section ClubSection `json:"section"`
+	Locked  bool        `json:"locked"`
+	Posts   []*ClubPost `json:"posts"`
+}
+
+// threadPosts nests posts under the ones they reply to, posts come oldest first
+func threadPosts(rows []db.ListClubPostsRow) []*ClubPost {
+	byID := map[int64]*ClubPost{}
+	roots := []*ClubPost{}
+	for _, row := range rows {
+		post := &ClubPost{
+			ID:        int(row.ID),
+			Username:  row.Username,
+			Body:      row.Body,
+			Deleted:   row.DeletedAt.Valid,
+			CreatedAt: row.CreatedAt.String(),
+			Replies:   []*ClubPost{},
+		}
+		byID[row.ID] = post
+		if parent, ok := byID[row.ParentID.Int64]; row.ParentID.Valid && ok {
+			parent.Replies = append(parent.Replies, post)
+		} else {
+			roots = append(roots, post)
+		}
+	}
+	return roots
+}
+
+// sectionThread finds the section in the path and whether the current user may see its
+// discussion yet, which is once their progress reaches the end of the section
+func (h *clubHandler) sectionThread(w http.ResponseWriter, r *http.Request) (db.ClubMember, db.ClubSection, bool, bool) {
+	club, member, ok := h.clubMembership(w, r)
+	if !ok {
+		return member, db.ClubSection{}, false, false
+	}
+	section, ok := h.sectionByPath(w, r, club)
+	if !ok {
+		return member, section, false, false
+	}
+	percent, err := h.readerPercent(r, club, section.BookID)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return member, section, false, false
+	}
+	return member, section, sectionUnlocked(club, section.BookID, section.EndAt, percent), true
+}
+
+// ListPosts implements ClubHandler.
+// Until the user has read to the end of the section the discussion is locked, so nobody is
+// spoiled by members who read ahead.
+func (h *clubHandler) ListPosts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, section, unlocked, ok := h.sectionThread(w, r)
+		if !ok {
+			return
+		}
+		rows, err := h.store.ListClubPosts(r.Context(), section.ID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var count int64
+		for _, row := range rows {
+			if !row.DeletedAt.Valid {
+				count++
+			}
+		}
+
+		thread := ClubThread{
+			Section: ClubSection{
+				ID:       int(section.ID),
+				Title:    section.Title,
+				Start:    int(section.StartAt),
+				End:      int(section.EndAt),
+				Posts:    count,
+				Unlocked: unlocked,
+			},
+			Locked: !unlocked,
+			Posts:  []*ClubPost{},
+		}
+		if section.Deadline.Valid {
+			thread.Section.Deadline = section.Deadline.Time.String()
+		}
+		message := "Discussion retrieved successfully"
+		if unlocked {
+			thread.Posts = threadPosts(rows)
+		} else {
+			message = "Read to the end of " + section.Title + " to see its discussion"
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: message,
+			Data:    thread,
+		})
+	}
+}
+
+// CreatePost implements ClubHandler.
+// A post with a parent_id replies to another post of the section.
+func (h *clubHandler) CreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		member, section, unlocked, ok := h.sectionThread(w, r)
+		if !ok {
+			return
+		}
+		if !unlocked {
+			WriteJSONError(w, "Read to the end of "+section.Title+" to join its discussion", http.StatusForbidden)
+			return
+		}
+		var req struct {
+			Body     string `json:"body"`
+			ParentID int64  `json:"parent_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields := map[string]string{}
+		if strings.TrimSpace(req.Body) == "" {
+			fields["body"] = "is required"
+		} else if len(req.Body) > 5000 {
+			fields["body"] = "must be at most 5000 characters"
+		}
+		var parentID sql.NullInt64
+		if req.ParentID != 0 {
+			parent, err := h.store.GetClubPost(ctx, db.GetClubPostParams{ID: req.ParentID, SectionID: section.ID})
+			if err != nil && err != sql.ErrNoRows {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err == sql.ErrNoRows || parent.DeletedAt.Valid {
+				fields["parent_id"] = "is not a post in this discussion"
+			}
+			parentID = sql.NullInt64{Int64: req.ParentID, Valid: true}
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		postID, err := h.store.CreateClubPost(ctx, db.CreateClubPostParams{
+			SectionID: section.ID,
+			UserID:    member.UserID,
+			ParentID:  parentID,
+			Body:      req.Body,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		post, err := h.store.GetClubPost(ctx, db.GetClubPostParams{ID: postID, SectionID: section.ID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user, err := h.store.GetProfile(ctx, member.UserID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Post created successfully",
+			Data: ClubPost{
+				ID:        int(post.ID),
+				Username:  user.Username,
+				Body:      post.Body,
+				CreatedAt: post.CreatedAt.String(),
+				Replies:   []*ClubPost{},
+			},
+		})
+	}
+}
+
+// DeletePost implements ClubHandler.
+// Authors can delete their own posts and moderators anyone's.
+func (h *clubHandler) DeletePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, member, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		section, ok := h.sectionByPath(w, r, club)
+		if !ok {
+			return
+		}
+		postID, err := pathID(r, "postID")
+		if err != nil {
+			WriteJSONError(w, "Invalid post ID", http.StatusBadRequest)
+			return
+		}
+		post, err := h.store.GetClubPost(ctx, db.GetClubPostParams{ID: postID, SectionID: section.ID})
+		if err == sql.ErrNoRows || (err == nil && post.DeletedAt.Valid) {
+			WriteJSONError(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if post.UserID != member.UserID && clubRoleRank(member.Role) < clubRoleRank(ClubRoleModerator) {
+			WriteJSONError(w, "You can only delete your own posts", http.StatusForbidden)
+			return
+		}
+		if err := h.store.DeleteClubPost(ctx, post.ID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d deleted post %d of club %d", member.UserID, post.ID, club.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Post deleted successfully"})
+	}
+}
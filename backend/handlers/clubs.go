@@ -0,0 +1,974 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+const (
+	ClubRoleOwner     = "owner"
+	ClubRoleModerator = "moderator"
+	ClubRoleMember    = "member"
+)
+
+// what a club's schedule is counted in
+const (
+	ScheduleUnitChapter = "chapter"
+	ScheduleUnitPage    = "page"
+)
+
+// ClubMemberHidden is the status of a member whose progress the viewer may not see
+const ClubMemberHidden = "hidden"
+
+// clubRoleRank orders roles, a higher rank may do everything a lower one can
+func clubRoleRank(role string) int {
+	switch role {
+	case ClubRoleOwner:
+		return 3
+	case ClubRoleModerator:
+		return 2
+	case ClubRoleMember:
+		return 1
+	}
+	return 0
+}
+
+type ClubHandler interface {
+	CreateClub() http.HandlerFunc
+	ListClubs() http.HandlerFunc
+	GetClub() http.HandlerFunc
+	UpdateClub() http.HandlerFunc
+	DeleteClub() http.HandlerFunc
+	SetBook() http.HandlerFunc
+	ListMembers() http.HandlerFunc
+	AddMember() http.HandlerFunc
+	RemoveMember() http.HandlerFunc
+	ListInvites() http.HandlerFunc
+	AcceptInvite() http.HandlerFunc
+	DeclineInvite() http.HandlerFunc
+	CreateSection() http.HandlerFunc
+	UpdateSection() http.HandlerFunc
+	DeleteSection() http.HandlerFunc
+	ListPosts() http.HandlerFunc
+	CreatePost() http.HandlerFunc
+	DeletePost() http.HandlerFunc
+}
+
+type clubHandler struct {
+	conn  *sql.DB
+	store *db.Queries
+}
+
+func NewClubHandler(conn *sql.DB, store *db.Queries) ClubHandler {
+	return &clubHandler{conn: conn, store: store}
+}
+
+type ClubBook struct {
+	ID       int    `json:"id"`
+	Isbn     string `json:"isbn"`
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	ImageURL string `json:"image_url"`
+}
+
+type Club struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Role          string        `json:"role"`
+	Members       int64         `json:"members,omitempty"`
+	Book          *ClubBook     `json:"book"`
+	ScheduleUnit  string        `json:"schedule_unit,omitempty"`
+	ScheduleTotal int           `json:"schedule_total,omitempty"`
+	Schedule      []ClubSection `json:"schedule,omitempty"`
+	CreatedAt     string        `json:"created_at"`
+}
+
+// ClubSection is one stretch of the schedule, Start and End are chapters or pages and both included.
+// Unlocked is whether the viewer has read far enough to see its discussion.
+type ClubSection struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Deadline string `json:"deadline,omitempty"`
+	Posts    int64  `json:"posts"`
+	Unlocked bool   `json:"unlocked"`
+}
+
+// ClubMember is a member and where they are in the club's book. Position is in the schedule's
+// unit, worked out from their latest progress whatever unit they track it in. Members who keep the
+// book from the viewer, the way their profile would, are hidden without any progress.
+type ClubMember struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+	Percent     int    `json:"percent"`
+	Position    int    `json:"position"`
+	Section     string `json:"section,omitempty"`
+	Behind      bool   `json:"behind"`
+	JoinedAt    string `json:"joined_at"`
+}
+
+// ClubInvite is an invite to join a club, as the role it was sent for
+type ClubInvite struct {
+	ClubID      int    `json:"club_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Role        string `json:"role"`
+	InvitedBy   string `json:"invited_by"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// clubProgressVisible reports whether the viewer may see a member's progress through the book.
+// It is shown where the member's entry for the book would be on their profile, members without the
+// book have nothing to hide.
+func clubProgressVisible(row db.ListClubMemberProgressRow, viewerID int64) bool {
+	if row.Visibility == "" {
+		return true
+	}
+	owner := db.GetProfileRow{ID: row.ID, ProfileVisibility: row.ProfileVisibility}
+	visibilities, ok := profileAccess(owner, viewerID, row.Followed)
+	return ok && slices.Contains(visibilities, row.Visibility)
+}
+
+// clubReaderPercent is how far a member is through the club's book, a finished read counts as all of it
+func clubReaderPercent(status, unit string, value, total int64) int {
+	if status == ReadStatusFinished {
+		return 100
+	}
+	if unit == "" {
+		return 0
+	}
+	return progressPercent(unit, value, total)
+}
+
+// sectionUnlocked reports whether a reader percent through the section's book has read to its end.
+// Sections of books the club read before only unlock once the book is finished, as the schedule
+// total is the current book's.
+func sectionUnlocked(club db.GetClubRow, bookID, endAt int64, percent int) bool {
+	if percent >= 100 {
+		return true
+	}
+	if !club.BookID.Valid || club.BookID.Int64 != bookID || club.ScheduleTotal <= 0 {
+		return false
+	}
+	return int64(percent)*club.ScheduleTotal >= endAt*100
+}
+
+func toClubSection(club db.GetClubRow, section db.ListClubSectionsRow, percent int) ClubSection {
+	result := ClubSection{
+		ID:       int(section.ID),
+		Title:    section.Title,
+		Start:    int(section.StartAt),
+		End:      int(section.EndAt),
+		Posts:    section.Posts,
+		Unlocked: sectionUnlocked(club, section.BookID, section.EndAt, percent),
+	}
+	if section.Deadline.Valid {
+		result.Deadline = section.Deadline.Time.String()
+	}
+	return result
+}
+
+// clubMembership finds the club in the path and the current user's membership of it. Clubs are
+// only visible to their members, anyone else gets the same 404 as for a club that doesn't exist.
+func (h *clubHandler) clubMembership(w http.ResponseWriter, r *http.Request) (db.GetClubRow, db.ClubMember, bool) {
+	ctx := r.Context()
+	clubID, err := pathID(r, "id")
+	if err != nil {
+		WriteJSONError(w, "Invalid club ID", http.StatusBadRequest)
+		return db.GetClubRow{}, db.ClubMember{}, false
+	}
+	member, err := h.store.GetClubMember(ctx, db.GetClubMemberParams{ClubID: clubID, UserID: GetUserID(ctx)})
+	if err == sql.ErrNoRows {
+		WriteJSONError(w, "Club not found", http.StatusNotFound)
+		return db.GetClubRow{}, db.ClubMember{}, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return db.GetClubRow{}, db.ClubMember{}, false
+	}
+	club, err := h.store.GetClub(ctx, clubID)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return db.GetClubRow{}, db.ClubMember{}, false
+	}
+	return club, member, true
+}
+
+// clubModerator is clubMembership for changes only moderators and the owner may make
+func (h *clubHandler) clubModerator(w http.ResponseWriter, r *http.Request) (db.GetClubRow, db.ClubMember, bool) {
+	club, member, ok := h.clubMembership(w, r)
+	if !ok {
+		return club, member, false
+	}
+	if clubRoleRank(member.Role) < clubRoleRank(ClubRoleModerator) {
+		WriteJSONError(w, "Only the club's moderators can do this", http.StatusForbidden)
+		return club, member, false
+	}
+	return club, member, true
+}
+
+// readerPercent is how far the current user is through a book the club reads
+func (h *clubHandler) readerPercent(r *http.Request, club db.GetClubRow, bookID int64) (int, error) {
+	userID := GetUserID(r.Context())
+	members, err := h.store.ListClubMemberProgress(r.Context(), db.ListClubMemberProgressParams{ViewerID: userID, BookID: bookID, ClubID: club.ID})
+	if err != nil {
+		return 0, err
+	}
+	for _, member := range members {
+		if member.ID == userID {
+			return clubReaderPercent(member.Status, member.Unit, member.Value, member.Total), nil
+		}
+	}
+	return 0, nil
+}
+
+func (h *clubHandler) toClub(r *http.Request, club db.GetClubRow, role string) (Club, error) {
+	result := Club{
+		ID:          int(club.ID),
+		Name:        club.Name,
+		Description: club.Description,
+		Role:        role,
+		CreatedAt:   club.CreatedAt.String(),
+	}
+	if !club.BookID.Valid {
+		return result, nil
+	}
+	result.Book = &ClubBook{
+		ID:       int(club.BookID.Int64),
+		Isbn:     club.Isbn.String,
+		Title:    club.Title.String,
+		Author:   club.Author.String,
+		ImageURL: club.ImageUrl.String,
+	}
+	result.ScheduleUnit = club.ScheduleUnit
+	result.ScheduleTotal = int(club.ScheduleTotal)
+
+	percent, err := h.readerPercent(r, club, club.BookID.Int64)
+	if err != nil {
+		return result, err
+	}
+	sections, err := h.store.ListClubSections(r.Context(), db.ListClubSectionsParams{ClubID: club.ID, BookID: club.BookID.Int64})
+	if err != nil {
+		return result, err
+	}
+	result.Schedule = []ClubSection{}
+	for _, section := range sections {
+		result.Schedule = append(result.Schedule, toClubSection(club, section, percent))
+	}
+	return result, nil
+}
+
+// CreateClub implements ClubHandler.
+// The user who creates a club is its owner.
+func (h *clubHandler) CreateClub() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		var req struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fields := validateClub(req.Name, req.Description); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		clubID, err := h.store.CreateClub(ctx, db.CreateClubParams{
+			Name:        strings.TrimSpace(req.Name),
+			Description: req.Description,
+			CreatedBy:   userID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		err = h.store.AddClubMember(ctx, db.AddClubMemberParams{ClubID: clubID, UserID: userID, Role: ClubRoleOwner})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		club, err := h.store.GetClub(ctx, clubID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result, err := h.toClub(r, club, ClubRoleOwner)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d created club %d", userID, clubID)
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Club created successfully",
+			Data:    result,
+		})
+	}
+}
+
+func validateClub(name, description string) map[string]string {
+	fields := map[string]string{}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fields["name"] = "is required"
+	} else if len(name) > 100 {
+		fields["name"] = "must be at most 100 characters"
+	}
+	if len(description) > 2000 {
+		fields["description"] = "must be at most 2000 characters"
+	}
+	return fields
+}
+
+// ListClubs implements ClubHandler.
+func (h *clubHandler) ListClubs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		rows, err := h.store.ListUserClubs(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		clubs := []Club{}
+		for _, row := range rows {
+			clubs = append(clubs, Club{
+				ID:          int(row.ID),
+				Name:        row.Name,
+				Description: row.Description,
+				Role:        row.Role,
+				Members:     row.Members,
+				CreatedAt:   row.CreatedAt.String(),
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Clubs retrieved successfully",
+			Data:    clubs,
+		})
+	}
+}
+
+// GetClub implements ClubHandler.
+// It includes the schedule for the current book, with the sections the user may discuss unlocked.
+func (h *clubHandler) GetClub() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		club, member, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		result, err := h.toClub(r, club, member.Role)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Club retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// UpdateClub implements ClubHandler.
+func (h *clubHandler) UpdateClub() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, member, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fields := validateClub(req.Name, req.Description); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		err := h.store.UpdateClub(ctx, db.UpdateClubParams{
+			Name:        strings.TrimSpace(req.Name),
+			Description: req.Description,
+			ID:          club.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		club, err = h.store.GetClub(ctx, club.ID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result, err := h.toClub(r, club, member.Role)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Club updated successfully",
+			Data:    result,
+		})
+	}
+}
+
+// DeleteClub implements ClubHandler.
+// Only the owner can delete a club, its schedules and discussions go with it.
+func (h *clubHandler) DeleteClub() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		club, member, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		if member.Role != ClubRoleOwner {
+			WriteJSONError(w, "Only the club's owner can delete it", http.StatusForbidden)
+			return
+		}
+		if err := h.store.DeleteClub(r.Context(), club.ID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d deleted club %d", member.UserID, club.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Club deleted successfully"})
+	}
+}
+
+// SetBook implements ClubHandler.
+// The book is picked from the catalog. Picking another book starts an empty schedule, the
+// schedule and discussions of the previous book are kept and come back if it is picked again.
+func (h *clubHandler) SetBook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, member, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			BookID        int64  `json:"book_id"`
+			ScheduleUnit  string `json:"schedule_unit"`
+			ScheduleTotal int64  `json:"schedule_total"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.ScheduleUnit == "" {
+			req.ScheduleUnit = ScheduleUnitChapter
+		}
+		fields := map[string]string{}
+		if _, err := h.store.GetBook(ctx, req.BookID); err == sql.ErrNoRows {
+			fields["book_id"] = "is not a book in the catalog"
+		} else if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if req.ScheduleUnit != ScheduleUnitChapter && req.ScheduleUnit != ScheduleUnitPage {
+			fields["schedule_unit"] = "must be chapter or page"
+		}
+		if req.ScheduleTotal < 1 {
+			fields["schedule_total"] = "must be the number of chapters or pages in the book"
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		err := h.store.SetClubBook(ctx, db.SetClubBookParams{
+			BookID:        sql.NullInt64{Int64: req.BookID, Valid: true},
+			ScheduleUnit:  req.ScheduleUnit,
+			ScheduleTotal: req.ScheduleTotal,
+			ID:            club.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		club, err = h.store.GetClub(ctx, club.ID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result, err := h.toClub(r, club, member.Role)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Club %d is now reading book %d", club.ID, req.BookID)
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Club book updated successfully",
+			Data:    result,
+		})
+	}
+}
+
+// ListMembers implements ClubHandler.
+// Each member comes with their progress through the current book against the schedule: the
+// furthest section they have read and whether they are behind a deadline that has passed.
+func (h *clubHandler) ListMembers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, _, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		rows, err := h.store.ListClubMemberProgress(ctx, db.ListClubMemberProgressParams{
+			ViewerID: GetUserID(ctx),
+			BookID:   club.BookID.Int64,
+			ClubID:   club.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var sections []db.ListClubSectionsRow
+		if club.BookID.Valid {
+			sections, err = h.store.ListClubSections(ctx, db.ListClubSectionsParams{ClubID: club.ID, BookID: club.BookID.Int64})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		now := time.Now()
+		members := []ClubMember{}
+		for _, row := range rows {
+			member := ClubMember{
+				Username:    row.Username,
+				DisplayName: row.DisplayName,
+				Role:        row.Role,
+				Status:      row.Status,
+				JoinedAt:    row.JoinedAt.String(),
+			}
+			if member.Status == "" {
+				member.Status = "not-started"
+			}
+			if !clubProgressVisible(row, GetUserID(ctx)) {
+				member.Status = ClubMemberHidden
+			} else if club.BookID.Valid {
+				member.Percent = clubReaderPercent(row.Status, row.Unit, row.Value, row.Total)
+				member.Position = member.Percent * int(club.ScheduleTotal) / 100
+				for _, section := range sections {
+					if sectionUnlocked(club, section.BookID, section.EndAt, member.Percent) {
+						member.Section = section.Title
+					} else if section.Deadline.Valid && section.Deadline.Time.Before(now) {
+						member.Behind = true
+					}
+				}
+			}
+			members = append(members, member)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Members retrieved successfully",
+			Data:    members,
+		})
+	}
+}
+
+// AddMember implements ClubHandler.
+// Moderators invite members, who join once they accept. The owner can also invite moderators and
+// make members moderators or demote them again.
+func (h *clubHandler) AddMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, member, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Role == "" {
+			req.Role = ClubRoleMember
+		}
+		if req.Role != ClubRoleMember && req.Role != ClubRoleModerator {
+			WriteValidationErrors(w, map[string]string{"role": "must be member or moderator"})
+			return
+		}
+		user, err := h.store.GetProfileByUsername(ctx, req.Username)
+		if err == sql.ErrNoRows {
+			WriteValidationErrors(w, map[string]string{"username": "is not a user"})
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		existing, err := h.store.GetClubMember(ctx, db.GetClubMemberParams{ClubID: club.ID, UserID: user.ID})
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		isMember := err == nil
+		if isMember && existing.Role == ClubRoleOwner {
+			WriteJSONError(w, "The owner's role cannot be changed", http.StatusConflict)
+			return
+		}
+		if member.Role != ClubRoleOwner && (isMember || req.Role != ClubRoleMember) {
+			WriteJSONError(w, "Only the club's owner can change roles", http.StatusForbidden)
+			return
+		}
+
+		if !isMember {
+			err = h.store.CreateClubInvite(ctx, db.CreateClubInviteParams{
+				ClubID:    club.ID,
+				UserID:    user.ID,
+				Role:      req.Role,
+				InvitedBy: member.UserID,
+			})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Info("User %d invited user %d to club %d as a %s", member.UserID, user.ID, club.ID, req.Role)
+			WriteJSON(w, http.StatusOK, JSONResponse{Message: user.Username + " is invited to " + club.Name + " as a " + req.Role})
+			return
+		}
+		err = h.store.AddClubMember(ctx, db.AddClubMemberParams{ClubID: club.ID, UserID: user.ID, Role: req.Role})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d made user %d a %s of club %d", member.UserID, user.ID, req.Role, club.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: user.Username + " is now a " + req.Role + " of " + club.Name})
+	}
+}
+
+// RemoveMember implements ClubHandler.
+// Members can leave, moderators can remove members and the owner can remove anyone. The owner
+// cannot leave, they delete the club instead.
+func (h *clubHandler) RemoveMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, member, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		user, err := h.store.GetProfileByUsername(ctx, r.PathValue("username"))
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var target db.ClubMember
+		if err == nil {
+			target, err = h.store.GetClubMember(ctx, db.GetClubMemberParams{ClubID: club.ID, UserID: user.ID})
+		}
+		if err == sql.ErrNoRows {
+			WriteJSONError(w, "Not a member of "+club.Name, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if target.Role == ClubRoleOwner {
+			WriteJSONError(w, "The owner cannot leave the club", http.StatusConflict)
+			return
+		}
+		if target.UserID != member.UserID && clubRoleRank(member.Role) <= clubRoleRank(target.Role) {
+			WriteJSONError(w, "You cannot remove this member", http.StatusForbidden)
+			return
+		}
+
+		if _, err := h.store.RemoveClubMember(ctx, db.RemoveClubMemberParams{ClubID: club.ID, UserID: target.UserID}); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d removed user %d from club %d", member.UserID, target.UserID, club.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: user.Username + " is no longer a member of " + club.Name})
+	}
+}
+
+// ListInvites implements ClubHandler.
+// It lists the clubs the user is invited to, newest invite first.
+func (h *clubHandler) ListInvites() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		rows, err := h.store.ListUserClubInvites(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		invites := []ClubInvite{}
+		for _, row := range rows {
+			invites = append(invites, ClubInvite{
+				ClubID:      int(row.ClubID),
+				Name:        row.Name,
+				Description: row.Description,
+				Role:        row.Role,
+				InvitedBy:   row.InvitedBy,
+				CreatedAt:   row.CreatedAt.String(),
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Club invites retrieved successfully",
+			Data:    invites,
+		})
+	}
+}
+
+// AcceptInvite implements ClubHandler.
+// The user joins the club as the role they were invited as.
+func (h *clubHandler) AcceptInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		clubID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid club ID", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := h.conn.BeginTx(ctx, nil)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.store.WithTx(tx)
+		invite, err := qtx.GetClubInvite(ctx, db.GetClubInviteParams{ClubID: clubID, UserID: userID})
+		if err == sql.ErrNoRows {
+			WriteJSONError(w, "Invite not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := qtx.DeleteClubInvite(ctx, db.DeleteClubInviteParams{ClubID: clubID, UserID: userID}); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// a member who was invited again keeps the role they have
+		if _, err := qtx.GetClubMember(ctx, db.GetClubMemberParams{ClubID: clubID, UserID: userID}); err == sql.ErrNoRows {
+			err = qtx.AddClubMember(ctx, db.AddClubMemberParams{ClubID: clubID, UserID: userID, Role: invite.Role})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		club, member, ok := h.clubMembership(w, r)
+		if !ok {
+			return
+		}
+		result, err := h.toClub(r, club, member.Role)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d joined club %d as a %s", userID, clubID, member.Role)
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Joined " + club.Name,
+			Data:    result,
+		})
+	}
+}
+
+// DeclineInvite implements ClubHandler.
+func (h *clubHandler) DeclineInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		clubID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid club ID", http.StatusBadRequest)
+			return
+		}
+		deleted, err := h.store.DeleteClubInvite(r.Context(), db.DeleteClubInviteParams{ClubID: clubID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Invite not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Invite declined"})
+	}
+}
+
+type clubSectionRequest struct {
+	Title    string `json:"title"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Deadline string `json:"deadline"`
+}
+
+// validate checks a section against the club's book, deadline is only set when one was given
+func (req clubSectionRequest) validate(club db.GetClubRow) (deadline sql.NullTime, fields map[string]string) {
+	fields = map[string]string{}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		fields["title"] = "is required"
+	} else if len(req.Title) > 200 {
+		fields["title"] = "must be at most 200 characters"
+	}
+	if req.Start < 1 || req.Start > club.ScheduleTotal {
+		fields["start"] = "must be a " + club.ScheduleUnit + " of the book"
+	}
+	if req.End < req.Start || req.End > club.ScheduleTotal {
+		fields["end"] = "must be a " + club.ScheduleUnit + " of the book, not before start"
+	}
+	if req.Deadline != "" {
+		t, err := parseDate(req.Deadline)
+		if err != nil {
+			fields["deadline"] = "must be an RFC 3339 timestamp or a date"
+		}
+		deadline = sql.NullTime{Time: t, Valid: err == nil}
+	}
+	return deadline, fields
+}
+
+// sectionByPath finds the section in the path among the club's
+func (h *clubHandler) sectionByPath(w http.ResponseWriter, r *http.Request, club db.GetClubRow) (db.ClubSection, bool) {
+	sectionID, err := pathID(r, "sectionID")
+	if err != nil {
+		WriteJSONError(w, "Invalid section ID", http.StatusBadRequest)
+		return db.ClubSection{}, false
+	}
+	section, err := h.store.GetClubSection(r.Context(), db.GetClubSectionParams{ID: sectionID, ClubID: club.ID})
+	if err == sql.ErrNoRows {
+		WriteJSONError(w, "Section not found", http.StatusNotFound)
+		return section, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return section, false
+	}
+	return section, true
+}
+
+// CreateSection implements ClubHandler.
+// Sections are added to the schedule of the club's current book.
+func (h *clubHandler) CreateSection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		club, _, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		if !club.BookID.Valid {
+			WriteJSONError(w, "Pick a book before scheduling it", http.StatusConflict)
+			return
+		}
+		var req clubSectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		deadline, fields := req.validate(club)
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		section, err := h.store.CreateClubSection(r.Context(), db.CreateClubSectionParams{
+			ClubID:   club.ID,
+			BookID:   club.BookID.Int64,
+			Title:    strings.TrimSpace(req.Title),
+			StartAt:  req.Start,
+			EndAt:    req.End,
+			Deadline: deadline,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		percent, err := h.readerPercent(r, club, club.BookID.Int64)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Section created successfully",
+			Data:    toClubSection(club, db.ListClubSectionsRow{ID: section.ID, BookID: section.BookID, Title: section.Title, StartAt: section.StartAt, EndAt: section.EndAt, Deadline: section.Deadline}, percent),
+		})
+	}
+}
+
+// UpdateSection implements ClubHandler.
+func (h *clubHandler) UpdateSection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		club, _, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		section, ok := h.sectionByPath(w, r, club)
+		if !ok {
+			return
+		}
+		if !club.BookID.Valid || section.BookID != club.BookID.Int64 {
+			WriteJSONError(w, "Only the schedule of the current book can be changed", http.StatusConflict)
+			return
+		}
+		var req clubSectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		deadline, fields := req.validate(club)
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		err := h.store.UpdateClubSection(ctx, db.UpdateClubSectionParams{
+			Title:    strings.TrimSpace(req.Title),
+			StartAt:  req.Start,
+			EndAt:    req.End,
+			Deadline: deadline,
+			ID:       section.ID,
+			ClubID:   club.ID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Section updated successfully"})
+	}
+}
+
+// DeleteSection implements ClubHandler.
+// The section's discussion is deleted with it.
+func (h *clubHandler) DeleteSection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		club, _, ok := h.clubModerator(w, r)
+		if !ok {
+			return
+		}
+		section, ok := h.sectionByPath(w, r, club)
+		if !ok {
+			return
+		}
+		if err := h.store.DeleteClubSection(r.Context(), db.DeleteClubSectionParams{ID: section.ID, ClubID: club.ID}); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Section deleted successfully"})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"booktrackr/db"
+)
+
+// errVersionConflict means the library entry changed after the client last read it
+var errVersionConflict = errors.New("book was changed by another update")
+
+// userBookETag is the strong entity tag for a library entry's version
+func userBookETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// matchesIfMatch reports whether an If-Match header value allows writing over etag
+func matchesIfMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch rejects writes that don't carry the entry's current ETag.
+// It writes the response and returns false when the write must not go ahead.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, current db.GetUserBookRow) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		WriteJSONError(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return false
+	}
+	if !matchesIfMatch(header, userBookETag(current.Version)) {
+		writePreconditionFailed(w, current)
+		return false
+	}
+	return true
+}
+
+// writePreconditionFailed sends back the current entry so the client can merge and retry
+func writePreconditionFailed(w http.ResponseWriter, current db.GetUserBookRow) {
+	w.Header().Set("ETag", userBookETag(current.Version))
+	WriteJSON(w, http.StatusPreconditionFailed, JSONResponse{
+		Error: "Book has been changed since it was last fetched",
+		Data:  toUserBook(current),
+	})
+}
+
+// writeVersionConflict handles a save that lost the race after passing checkIfMatch
+func (b *bookHandler) writeVersionConflict(ctx context.Context, w http.ResponseWriter, userID, bookID int64) {
+	current, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID})
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePreconditionFailed(w, current)
+}
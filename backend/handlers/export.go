@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+	"booktrackr/pkg/exporter"
+)
+
+// flush the response every so many rows so big libraries download steadily
+const exportFlushRows = 100
+
+type ExportHandler interface {
+	ExportLibrary() http.HandlerFunc
+}
+
+type exportHandler struct {
+	store *db.Queries
+}
+
+func NewExportHandler(store *db.Queries) ExportHandler {
+	return &exportHandler{store: store}
+}
+
+// ExportLibrary implements ExportHandler.
+// Rows are written as they come out of the database, the file is never held in memory.
+func (h *exportHandler) ExportLibrary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		name := r.URL.Query().Get("format")
+		if name == "" {
+			name = "csv"
+		}
+		format, ok := exporter.Lookup(name)
+		if !ok {
+			WriteJSONError(w, fmt.Sprintf("Unknown export format, use one of %s", strings.Join(exporter.Names(), ", ")), http.StatusBadRequest)
+			return
+		}
+
+		filename := fmt.Sprintf("booktrackr-%s-%s.%s", name, time.Now().Format(time.DateOnly), format.Extension)
+		w.Header().Set("Content-Type", format.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		flusher, _ := w.(http.Flusher)
+		writer := format.New(w)
+		rows := 0
+		err := h.store.ExportLibrary(ctx, userID, func(entry db.ExportLibraryRow) error {
+			if err := writer.Write(entry); err != nil {
+				return err
+			}
+			rows++
+			if flusher != nil && rows%exportFlushRows == 0 {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = writer.Close()
+		}
+		// the status line is already sent, all we can do is stop and log
+		if err != nil {
+			log.Error("Library export for user %d stopped after %d rows: %v", userID, rows, err)
+			return
+		}
+		log.Info("Exported %d library entries for user %d as %s", rows, userID, name)
+	}
+}
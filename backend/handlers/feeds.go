@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"booktrackr/config"
+	"booktrackr/db"
+	"booktrackr/pkg/feed"
+)
+
+// feedItems is how many events a feed carries, feed readers only look at the latest
+const feedItems = 50
+
+type FeedHandler interface {
+	UserFeed(format string) http.HandlerFunc
+	GetFeedSettings() http.HandlerFunc
+	UpdateFeedSettings() http.HandlerFunc
+}
+
+type feedHandler struct {
+	store *db.Queries
+}
+
+func NewFeedHandler(store *db.Queries) FeedHandler {
+	return &feedHandler{store: store}
+}
+
+type FeedSettings struct {
+	Enabled        bool              `json:"enabled"`
+	IncludeReviews bool              `json:"include_reviews"`
+	URLs           map[string]string `json:"urls"`
+}
+
+// requestOrigin is the scheme and host the request came in on, for absolute links in feeds
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func feedURL(r *http.Request, username, format string) string {
+	return fmt.Sprintf("%s/users/%s/feed.%s", requestOrigin(r), url.PathEscape(username), format)
+}
+
+func toFeedSettings(r *http.Request, settings db.GetFeedSettingsRow) FeedSettings {
+	result := FeedSettings{
+		Enabled:        settings.FeedEnabled,
+		IncludeReviews: settings.FeedReviews,
+		URLs:           map[string]string{},
+	}
+	for _, format := range feed.Names() {
+		result.URLs[format] = feedURL(r, settings.Username, format)
+	}
+	return result
+}
+
+// GetFeedSettings implements FeedHandler.
+func (h *feedHandler) GetFeedSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		settings, err := h.store.GetFeedSettings(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Feed settings retrieved successfully",
+			Data:    toFeedSettings(r, settings),
+		})
+	}
+}
+
+// UpdateFeedSettings implements FeedHandler.
+func (h *feedHandler) UpdateFeedSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		var req struct {
+			Enabled        bool `json:"enabled"`
+			IncludeReviews bool `json:"include_reviews"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err := h.store.UpdateFeedSettings(ctx, db.UpdateFeedSettingsParams{
+			FeedEnabled: req.Enabled,
+			FeedReviews: req.IncludeReviews,
+			ID:          userID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		settings, err := h.store.GetFeedSettings(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Feed settings updated successfully",
+			Data:    toFeedSettings(r, settings),
+		})
+	}
+}
+
+// feedItemsFromReads turns reads into started and finished events, newest first
+func feedItemsFromReads(username string, includeReviews bool, reads []db.ListFeedReadsRow, profile string) []feed.Item {
+	items := []feed.Item{}
+	for _, read := range reads {
+		book := read.Title
+		if read.Author != "" {
+			book = fmt.Sprintf("%s by %s", read.Title, read.Author)
+		}
+		link := profile
+		if hasISBN(read.Isbn) {
+			link = "https://openlibrary.org/isbn/" + read.Isbn
+		}
+		if read.StartDate.Valid {
+			items = append(items, feed.Item{
+				ID:        fmt.Sprintf("urn:booktrackr:read:%d:started", read.ID),
+				Title:     fmt.Sprintf("%s started reading %s", username, book),
+				Link:      link,
+				ImageURL:  read.ImageUrl,
+				Published: read.StartDate.Time,
+				Updated:   read.StartDate.Time,
+			})
+		}
+		if read.Status == ReadStatusFinished && read.FinishDate.Valid {
+			var content []string
+			if read.Rating.Valid {
+				content = append(content, fmt.Sprintf("Rated %d out of 5.", read.Rating.Int64))
+			}
+			if includeReviews && read.Review.Valid && strings.TrimSpace(read.Review.String) != "" {
+				content = append(content, read.Review.String)
+			}
+			items = append(items, feed.Item{
+				ID:        fmt.Sprintf("urn:booktrackr:read:%d:finished", read.ID),
+				Title:     fmt.Sprintf("%s finished reading %s", username, book),
+				Link:      link,
+				Content:   strings.Join(content, "\n\n"),
+				ImageURL:  read.ImageUrl,
+				Published: read.FinishDate.Time,
+				Updated:   read.FinishDate.Time,
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	if len(items) > feedItems {
+		items = items[:feedItems]
+	}
+	return items
+}
+
+// notModified answers a conditional GET. If-None-Match wins over If-Modified-Since when
+// both are sent, as RFC 9110 asks.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// UserFeed implements FeedHandler.
+// Feeds are public but only exist for users with a public profile who turned them on, for
+// everyone else it is a 404.
+func (h *feedHandler) UserFeed(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		writer, ok := feed.Lookup(format)
+		if !ok {
+			WriteJSONError(w, "Unknown feed format", http.StatusNotFound)
+			return
+		}
+		settings, err := h.store.GetFeedSettingsByUsername(ctx, r.PathValue("username"))
+		if err == sql.ErrNoRows || (err == nil && !settings.FeedEnabled) {
+			WriteJSONError(w, "Feed not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reads, err := h.store.ListFeedReads(ctx, db.ListFeedReadsParams{UserID: settings.ID, Limit: feedItems})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		libraryUpdated, err := h.store.GetLibraryUpdatedAt(ctx, settings.ID)
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		profile := fmt.Sprintf("%s/users/%s", config.FRONTEND_HOSTNAME, url.PathEscape(settings.Username))
+		items := feedItemsFromReads(settings.Username, settings.FeedReviews, reads, profile)
+
+		// the feed changes when a read is added or edited, or when the settings change
+		var updated time.Time
+		for _, t := range []sql.NullTime{libraryUpdated, settings.FeedUpdatedAt} {
+			if t.Valid && t.Time.After(updated) {
+				updated = t.Time
+			}
+		}
+		for _, item := range items {
+			if item.Updated.After(updated) {
+				updated = item.Updated
+			}
+		}
+		updated = updated.UTC().Truncate(time.Second)
+
+		var body bytes.Buffer
+		err = writer.Write(&body, feed.Feed{
+			ID:          fmt.Sprintf("urn:booktrackr:user:%d:activity", settings.ID),
+			Title:       fmt.Sprintf("%s's reading on booktrackr", settings.Username),
+			Description: fmt.Sprintf("Books %s started and finished", settings.Username),
+			Link:        profile,
+			FeedURL:     feedURL(r, settings.Username, format),
+			Author:      settings.Username,
+			Updated:     updated,
+			Items:       items,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256(body.Bytes())
+		etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if notModified(r, etag, updated) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", writer.ContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
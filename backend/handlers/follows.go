@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+const (
+	FollowPending  = "pending"
+	FollowAccepted = "accepted"
+)
+
+type FollowHandler interface {
+	Follow() http.HandlerFunc
+	Unfollow() http.HandlerFunc
+	ListFollowers() http.HandlerFunc
+	ListFollowing() http.HandlerFunc
+	ApproveFollower() http.HandlerFunc
+	RemoveFollower() http.HandlerFunc
+	Timeline() http.HandlerFunc
+}
+
+type followHandler struct {
+	store *db.Queries
+}
+
+func NewFollowHandler(store *db.Queries) FollowHandler {
+	return &followHandler{store: store}
+}
+
+type Follow struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// isFollower reports whether followerID is an approved follower of followeeID
+func isFollower(r *http.Request, store *db.Queries, followerID, followeeID int64) (bool, error) {
+	if followerID == 0 {
+		return false, nil
+	}
+	follow, err := store.GetFollow(r.Context(), db.GetFollowParams{FollowerID: followerID, FolloweeID: followeeID})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return follow.Status == FollowAccepted, nil
+}
+
+// userByPath finds the user named in the path, writing a 404 if there is none
+func (h *followHandler) userByPath(w http.ResponseWriter, r *http.Request) (db.GetProfileByUsernameRow, bool) {
+	user, err := h.store.GetProfileByUsername(r.Context(), r.PathValue("username"))
+	if err == sql.ErrNoRows {
+		WriteJSONError(w, "User not found", http.StatusNotFound)
+		return user, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return user, false
+	}
+	return user, true
+}
+
+// Follow implements FollowHandler.
+// Public profiles can be followed straight away, the others get a request to approve.
+func (h *followHandler) Follow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		followee, ok := h.userByPath(w, r)
+		if !ok {
+			return
+		}
+		if followee.ID == userID {
+			WriteJSONError(w, "You cannot follow yourself", http.StatusBadRequest)
+			return
+		}
+
+		params := db.CreateFollowParams{FollowerID: userID, FolloweeID: followee.ID, Status: FollowPending}
+		if followee.ProfileVisibility == VisibilityPublic {
+			params.Status = FollowAccepted
+			params.AcceptedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+		// following again leaves an existing follow or request as it is
+		if err := h.store.CreateFollow(ctx, params); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		follow, err := h.store.GetFollow(ctx, db.GetFollowParams{FollowerID: userID, FolloweeID: followee.ID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d follows user %d: %s", userID, followee.ID, follow.Status)
+		message := "Following " + followee.Username
+		if follow.Status == FollowPending {
+			message = "Follow request sent to " + followee.Username
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: message,
+			Data: Follow{
+				Username:    followee.Username,
+				DisplayName: followee.DisplayName,
+				Status:      follow.Status,
+				CreatedAt:   follow.CreatedAt.String(),
+			},
+		})
+	}
+}
+
+// Unfollow implements FollowHandler.
+// It also withdraws a follow request that was not approved yet.
+func (h *followHandler) Unfollow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		followee, ok := h.userByPath(w, r)
+		if !ok {
+			return
+		}
+		deleted, err := h.store.DeleteFollow(r.Context(), db.DeleteFollowParams{FollowerID: userID, FolloweeID: followee.ID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Not following "+followee.Username, http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Unfollowed " + followee.Username})
+	}
+}
+
+// ListFollowers implements FollowHandler.
+// ?status=pending lists the requests waiting for approval.
+func (h *followHandler) ListFollowers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = FollowAccepted
+		}
+		if status != FollowAccepted && status != FollowPending {
+			WriteValidationErrors(w, map[string]string{"status": "must be accepted or pending"})
+			return
+		}
+		rows, err := h.store.ListFollowers(r.Context(), db.ListFollowersParams{FolloweeID: userID, Status: status})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		followers := []Follow{}
+		for _, row := range rows {
+			followers = append(followers, Follow{
+				Username:    row.Username,
+				DisplayName: row.DisplayName,
+				Status:      row.Status,
+				CreatedAt:   row.CreatedAt.String(),
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Followers retrieved successfully",
+			Data:    followers,
+		})
+	}
+}
+
+// ListFollowing implements FollowHandler.
+func (h *followHandler) ListFollowing() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		rows, err := h.store.ListFollowing(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		following := []Follow{}
+		for _, row := range rows {
+			following = append(following, Follow{
+				Username:    row.Username,
+				DisplayName: row.DisplayName,
+				Status:      row.Status,
+				CreatedAt:   row.CreatedAt.String(),
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Following retrieved successfully",
+			Data:    following,
+		})
+	}
+}
+
+// ApproveFollower implements FollowHandler.
+func (h *followHandler) ApproveFollower() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		follower, ok := h.userByPath(w, r)
+		if !ok {
+			return
+		}
+		approved, err := h.store.AcceptFollow(r.Context(), db.AcceptFollowParams{FollowerID: follower.ID, FolloweeID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if approved == 0 {
+			WriteJSONError(w, "No follow request from "+follower.Username, http.StatusNotFound)
+			return
+		}
+		log.Info("User %d approved follower %d", userID, follower.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: follower.Username + " is now following you"})
+	}
+}
+
+// RemoveFollower implements FollowHandler.
+// It turns down a follow request or removes a follower.
+func (h *followHandler) RemoveFollower() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		follower, ok := h.userByPath(w, r)
+		if !ok {
+			return
+		}
+		deleted, err := h.store.DeleteFollow(r.Context(), db.DeleteFollowParams{FollowerID: follower.ID, FolloweeID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, follower.Username+" is not following you", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Removed follower " + follower.Username})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"booktrackr/db"
+	"booktrackr/pkg/forecast"
+)
+
+// Forecast is when a book being read is likely to be finished, the finish date falls between
+// Earliest and Latest. PerDay is the pace it assumes in Unit.
+type Forecast struct {
+	FinishDate string  `json:"finish_date"`
+	Earliest   string  `json:"earliest"`
+	Latest     string  `json:"latest"`
+	PerDay     float64 `json:"per_day"`
+	Unit       string  `json:"unit"`
+	Basis      string  `json:"basis"`
+}
+
+// usualPace is the user's pace over the books they finished in a progress unit, 0 when unknown
+func usualPace(pace db.GetUsualPaceRow, unit string) float64 {
+	switch {
+	case unit == ProgressUnitPages && pace.PageDays > 0:
+		return float64(pace.Pages) / pace.PageDays
+	case unit == ProgressUnitPercent && pace.Days > 0:
+		return 100 * float64(pace.Books) / pace.Days
+	}
+	// finished books have no durations to go by
+	return 0
+}
+
+// readingForecasts estimates when the books being read will be finished, bookID 0 for all of them.
+// Books without an estimate are left out. Progress in the unit last used is what counts.
+func readingForecasts(ctx context.Context, store *db.Queries, userID, bookID int64) (map[int64]*Forecast, error) {
+	rows, err := store.ListReadingProgress(ctx, db.ListReadingProgressParams{UserID: userID, BookID: bookID})
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	pace, err := store.GetUsualPace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := userLocation(ctx, store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byBook := map[int64][]db.ListReadingProgressRow{}
+	for _, row := range rows {
+		byBook[row.BookID] = append(byBook[row.BookID], row)
+	}
+	now := time.Now()
+	forecasts := map[int64]*Forecast{}
+	for id, events := range byBook {
+		unit := events[len(events)-1].Unit
+		in := forecast.Input{HistoricalPerDay: usualPace(pace, unit), Now: now}
+		if events[0].StartDate.Valid {
+			in.Started = events[0].StartDate.Time
+		}
+		for _, event := range events {
+			if event.Unit != unit {
+				continue
+			}
+			in.History = append(in.History, forecast.Point{At: event.CreatedAt, Value: float64(event.Value)})
+			if event.Total.Valid {
+				in.Total = float64(event.Total.Int64)
+			}
+		}
+		estimate, ok := forecast.Predict(in)
+		if !ok {
+			continue
+		}
+		forecasts[id] = &Forecast{
+			FinishDate: estimate.Finish.In(loc).Format(time.DateOnly),
+			Earliest:   estimate.Earliest.In(loc).Format(time.DateOnly),
+			Latest:     estimate.Latest.In(loc).Format(time.DateOnly),
+			PerDay:     math.Round(estimate.PerDay*10) / 10,
+			Unit:       unit,
+			Basis:      estimate.Basis,
+		}
+	}
+	return forecasts, nil
+}
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+	"booktrackr/pkg/challenge"
+)
+
+// what a reading goal counts
+const (
+	GoalUnitBooks   = "books"
+	GoalUnitPages   = "pages"
+	GoalUnitMinutes = "minutes"
+)
+
+// how a goal or challenge is doing against a steady pace through its year
+const (
+	PaceComplete = "complete"
+	PaceAhead    = "ahead"
+	PaceOnTrack  = "on-track"
+	PaceBehind   = "behind"
+)
+
+type GoalHandler interface {
+	ListGoals() http.HandlerFunc
+	SetGoal() http.HandlerFunc
+	DeleteGoal() http.HandlerFunc
+	ListChallenges() http.HandlerFunc
+	CreateChallenge() http.HandlerFunc
+	GetChallenge() http.HandlerFunc
+	UpdateChallenge() http.HandlerFunc
+	DeleteChallenge() http.HandlerFunc
+}
+
+type goalHandler struct {
+	store *db.Queries
+}
+
+func NewGoalHandler(store *db.Queries) GoalHandler {
+	return &goalHandler{store: store}
+}
+
+// Progress is how far a goal or challenge has got. Expected is where a steady pace through the
+// year would be by the end of today, Projected is where the current pace ends the year.
+type Progress struct {
+	Done      int64  `json:"done"`
+	Percent   int    `json:"percent"`
+	Expected  int64  `json:"expected"`
+	Projected int64  `json:"projected"`
+	Pace      string `json:"pace"`
+}
+
+// Goal is a yearly goal, Unknown is the number of finished books without a page count or
+// duration, which a pages or minutes goal can't count
+type Goal struct {
+	Year    int    `json:"year"`
+	Unit    string `json:"unit"`
+	Target  int64  `json:"target"`
+	Unknown int    `json:"unknown,omitempty"`
+	Progress
+}
+
+type ChallengeBook struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	FinishDate string `json:"finish_date"`
+}
+
+type Challenge struct {
+	ID     int              `json:"id"`
+	Year   int              `json:"year"`
+	Name   string           `json:"name"`
+	Target int64            `json:"target"`
+	Rules  []challenge.Rule `json:"rules"`
+	Books  []ChallengeBook  `json:"books,omitempty"`
+	Progress
+}
+
+// goalProgress works out the pace of a yearly target as of now
+func goalProgress(year int, target, done int64, now time.Time) Progress {
+	progress := Progress{Done: done, Projected: done}
+	if target > 0 {
+		progress.Percent = int(min(done*100/target, 100))
+	}
+	switch {
+	case now.Year() > year:
+		progress.Expected = target
+	case now.Year() == year:
+		days := int64(time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay())
+		day := int64(now.YearDay())
+		progress.Expected = target * day / days
+		progress.Projected = done * days / day
+	}
+	switch {
+	case done >= target:
+		progress.Pace = PaceComplete
+	case done > progress.Expected:
+		progress.Pace = PaceAhead
+	case done == progress.Expected:
+		progress.Pace = PaceOnTrack
+	default:
+		progress.Pace = PaceBehind
+	}
+	return progress
+}
+
+// parseYear reads ?year=, the current year when it is not given
+func parseYear(value string) (int, bool) {
+	if value == "" {
+		return time.Now().UTC().Year(), true
+	}
+	year, err := strconv.Atoi(value)
+	return year, err == nil && year >= 1900 && year <= 9999
+}
+
+// finishedBooks lists the books the user finished in a year as challenge rules see them
+func (h *goalHandler) finishedBooks(ctx context.Context, userID int64, year int) ([]db.ListFinishedBooksRow, []challenge.Book, error) {
+	rows, err := h.store.ListFinishedBooks(ctx, db.ListFinishedBooksParams{UserID: userID, Year: int64(year)})
+	if err != nil {
+		return nil, nil, err
+	}
+	authors, err := h.store.ListAuthorsReadBefore(ctx, db.ListAuthorsReadBeforeParams{UserID: userID, Year: int64(year)})
+	if err != nil {
+		return nil, nil, err
+	}
+	readBefore := map[string]bool{}
+	for _, author := range authors {
+		readBefore[strings.ToLower(strings.TrimSpace(author))] = true
+	}
+	books := make([]challenge.Book, 0, len(rows))
+	for _, row := range rows {
+		book := challenge.Book{
+			Title:     row.Title,
+			Author:    row.Author,
+			Series:    row.Series,
+			Pages:     row.Pages,
+			Minutes:   row.Minutes,
+			Rating:    row.Rating.Int64,
+			NewAuthor: !readBefore[strings.ToLower(strings.TrimSpace(row.Author))],
+		}
+		if row.Tags != "" {
+			book.Tags = strings.Split(row.Tags, "\x1f")
+		}
+		books = append(books, book)
+	}
+	return rows, books, nil
+}
+
+func toGoal(goal db.ReadingGoal, books []challenge.Book, now time.Time) Goal {
+	result := Goal{Year: int(goal.Year), Unit: goal.Unit, Target: goal.Target}
+	var done int64
+	for _, book := range books {
+		switch goal.Unit {
+		case GoalUnitBooks:
+			done++
+		case GoalUnitPages:
+			done += book.Pages
+			if book.Pages == 0 {
+				result.Unknown++
+			}
+		case GoalUnitMinutes:
+			done += book.Minutes
+			if book.Minutes == 0 {
+				result.Unknown++
+			}
+		}
+	}
+	result.Progress = goalProgress(result.Year, goal.Target, done, now)
+	return result
+}
+
+// toChallenge evaluates a challenge against the books finished in its year, with the matching
+// books when withBooks is set
+func toChallenge(c db.Challenge, rows []db.ListFinishedBooksRow, books []challenge.Book, withBooks bool, now time.Time) (Challenge, error) {
+	rules, err := challenge.Parse([]byte(c.Rules))
+	if err != nil {
+		return Challenge{}, err
+	}
+	result := Challenge{ID: int(c.ID), Year: int(c.Year), Name: c.Name, Target: c.Target, Rules: rules}
+	var done int64
+	for i, book := range books {
+		if !challenge.MatchAll(rules, book) {
+			continue
+		}
+		done++
+		if withBooks {
+			result.Books = append(result.Books, ChallengeBook{
+				ID:         int(rows[i].BookID),
+				Title:      rows[i].Title,
+				Author:     rows[i].Author,
+				FinishDate: rows[i].FinishDate.Time.String(),
+			})
+		}
+	}
+	result.Progress = goalProgress(result.Year, c.Target, done, now)
+	return result, nil
+}
+
+// ListGoals implements GoalHandler.
+// It lists the goals of ?year=, this year by default, with how they are going.
+func (h *goalHandler) ListGoals() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		year, ok := parseYear(r.URL.Query().Get("year"))
+		if !ok {
+			WriteValidationErrors(w, map[string]string{"year": "must be a year"})
+			return
+		}
+		goals, err := h.store.ListReadingGoals(ctx, db.ListReadingGoalsParams{UserID: userID, Year: int64(year)})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, books, err := h.finishedBooks(ctx, userID, year)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().UTC()
+		result := []Goal{}
+		for _, goal := range goals {
+			result = append(result, toGoal(goal, books, now))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Goals retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// SetGoal implements GoalHandler.
+// It creates or changes the goal for the year and unit in the path.
+func (h *goalHandler) SetGoal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		var req struct {
+			Target int64 `json:"target"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fields := map[string]string{}
+		year, ok := parseYear(r.PathValue("year"))
+		if !ok {
+			fields["year"] = "must be a year"
+		}
+		unit := r.PathValue("unit")
+		if unit != GoalUnitBooks && unit != GoalUnitPages && unit != GoalUnitMinutes {
+			fields["unit"] = "must be one of books, pages or minutes"
+		}
+		if req.Target < 1 {
+			fields["target"] = "must be at least 1"
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		goal, err := h.store.SetReadingGoal(ctx, db.SetReadingGoalParams{
+			UserID: userID,
+			Year:   int64(year),
+			Unit:   unit,
+			Target: req.Target,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, books, err := h.finishedBooks(ctx, userID, year)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Goal saved successfully",
+			Data:    toGoal(goal, books, time.Now().UTC()),
+		})
+	}
+}
+
+// DeleteGoal implements GoalHandler.
+func (h *goalHandler) DeleteGoal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		year, ok := parseYear(r.PathValue("year"))
+		if !ok {
+			WriteValidationErrors(w, map[string]string{"year": "must be a year"})
+			return
+		}
+		deleted, err := h.store.DeleteReadingGoal(r.Context(), db.DeleteReadingGoalParams{
+			UserID: userID,
+			Year:   int64(year),
+			Unit:   r.PathValue("unit"),
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Goal not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Goal deleted successfully"})
+	}
+}
+
+type challengeRequest struct {
+	Year   int             `json:"year"`
+	Name   string          `json:"name"`
+	Target int64           `json:"target"`
+	Rules  json.RawMessage `json:"rules"`
+}
+
+// validate checks a challenge and returns its rules as they are stored
+func (req *challengeRequest) validate() (string, map[string]string) {
+	fields := map[string]string{}
+	if req.Year == 0 {
+		req.Year = time.Now().UTC().Year()
+	}
+	if req.Year < 1900 || req.Year > 9999 {
+		fields["year"] = "must be a year"
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		fields["name"] = "is required"
+	} else if len(req.Name) > 200 {
+		fields["name"] = "must be at most 200 characters"
+	}
+	if req.Target < 1 {
+		fields["target"] = "must be at least 1"
+	}
+	rules, err := challenge.Parse(req.Rules)
+	if err != nil {
+		fields["rules"] = err.Error()
+		return "", fields
+	}
+	stored, _ := json.Marshal(rules)
+	return string(stored), fields
+}
+
+// ListChallenges implements GoalHandler.
+// It lists the challenges of ?year=, this year by default, with how they are going.
+func (h *goalHandler) ListChallenges() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		year, ok := parseYear(r.URL.Query().Get("year"))
+		if !ok {
+			WriteValidationErrors(w, map[string]string{"year": "must be a year"})
+			return
+		}
+		challenges, err := h.store.ListChallenges(ctx, db.ListChallengesParams{UserID: userID, Year: int64(year)})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows, books, err := h.finishedBooks(ctx, userID, year)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().UTC()
+		result := []Challenge{}
+		for _, c := range challenges {
+			item, err := toChallenge(c, rows, books, false, now)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result = append(result, item)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Challenges retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// respondChallenge writes a challenge with the books that count towards it
+func (h *goalHandler) respondChallenge(w http.ResponseWriter, r *http.Request, status int, message string, c db.Challenge) {
+	rows, books, err := h.finishedBooks(r.Context(), c.UserID, int(c.Year))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := toChallenge(c, rows, books, true, time.Now().UTC())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, status, JSONResponse{
+		Message: message,
+		Data:    result,
+	})
+}
+
+// CreateChallenge implements GoalHandler.
+// A challenge counts the books finished in its year that match all of its rules.
+func (h *goalHandler) CreateChallenge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		var req challengeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules, fields := req.validate()
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		c, err := h.store.CreateChallenge(r.Context(), db.CreateChallengeParams{
+			UserID: userID,
+			Year:   int64(req.Year),
+			Name:   req.Name,
+			Target: req.Target,
+			Rules:  rules,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.respondChallenge(w, r, http.StatusCreated, "Challenge created successfully", c)
+	}
+}
+
+// GetChallenge implements GoalHandler.
+func (h *goalHandler) GetChallenge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		challengeID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid challenge ID", http.StatusBadRequest)
+			return
+		}
+		c, err := h.store.GetChallenge(r.Context(), db.GetChallengeParams{ID: challengeID, UserID: userID})
+		if err == sql.ErrNoRows {
+			WriteJSONError(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.respondChallenge(w, r, http.StatusOK, "Challenge retrieved successfully", c)
+	}
+}
+
+// UpdateChallenge implements GoalHandler.
+func (h *goalHandler) UpdateChallenge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		challengeID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid challenge ID", http.StatusBadRequest)
+			return
+		}
+		var req challengeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules, fields := req.validate()
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		c, err := h.store.UpdateChallenge(r.Context(), db.UpdateChallengeParams{
+			Year:   int64(req.Year),
+			Name:   req.Name,
+			Target: req.Target,
+			Rules:  rules,
+			ID:     challengeID,
+			UserID: userID,
+		})
+		if err == sql.ErrNoRows {
+			WriteJSONError(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.respondChallenge(w, r, http.StatusOK, "Challenge updated successfully", c)
+	}
+}
+
+// DeleteChallenge implements GoalHandler.
+func (h *goalHandler) DeleteChallenge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		challengeID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid challenge ID", http.StatusBadRequest)
+			return
+		}
+		deleted, err := h.store.DeleteChallenge(r.Context(), db.DeleteChallengeParams{ID: challengeID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Challenge deleted successfully"})
+	}
+}
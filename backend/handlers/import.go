@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"booktrackr/config"
+	"booktrackr/db"
+	log "booktrackr/logging"
+	"booktrackr/pkg/importer"
+)
+
+const (
+	// maxImportSize caps uploaded export files
+	maxImportSize = 32 << 20
+	// files bigger than this are imported in the background
+	backgroundImportSize = 1 << 20
+	// a Calibre metadata.db is a whole database, it gets more room
+	maxCalibreSize = 512 << 20
+)
+
+type ImportHandler interface {
+	Import() http.HandlerFunc
+	GetImportJob() http.HandlerFunc
+	ImportKindleClippings() http.HandlerFunc
+	ImportCalibre() http.HandlerFunc
+}
+
+type importHandler struct {
+	store    *db.Queries
+	pipeline *importer.Pipeline
+	jobs     *importer.Jobs
+}
+
+// ImportJob is the pollable state of a background import
+type ImportJob struct {
+	ID            int              `json:"id"`
+	Format        string           `json:"format"`
+	DryRun        bool             `json:"dry_run"`
+	Status        string           `json:"status"`
+	TotalRows     int              `json:"total_rows"`
+	ProcessedRows int              `json:"processed_rows"`
+	Report        *importer.Report `json:"report,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	CreatedAt     string           `json:"created_at"`
+	FinishedAt    string           `json:"finished_at,omitempty"`
+}
+
+func NewImportHandler(conn *sql.DB, store *db.Queries) ImportHandler {
+	pipeline := importer.NewPipeline(conn, store)
+	jobs := importer.NewJobs(pipeline, store)
+	if err := jobs.Recover(context.Background()); err != nil {
+		log.Error("Failed to clean up interrupted import jobs: %v", err)
+	}
+	return &importHandler{
+		store:    store,
+		pipeline: pipeline,
+		jobs:     jobs,
+	}
+}
+
+// uploadedFile returns the export from a multipart "file" field or, failing that, the raw body
+func uploadedFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	return r.Body, nil
+}
+
+func queryBool(r *http.Request, name string) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get(name))
+	return value
+}
+
+func toImportJob(job db.ImportJob) ImportJob {
+	result := ImportJob{
+		ID:            int(job.ID),
+		Format:        job.Format,
+		DryRun:        job.DryRun,
+		Status:        job.Status,
+		TotalRows:     int(job.TotalRows),
+		ProcessedRows: int(job.ProcessedRows),
+		Error:         job.Error.String,
+		CreatedAt:     job.CreatedAt.String(),
+	}
+	if job.FinishedAt.Valid {
+		result.FinishedAt = job.FinishedAt.Time.String()
+	}
+	if job.Report.Valid {
+		var report importer.Report
+		if err := json.Unmarshal([]byte(job.Report.String), &report); err == nil {
+			result.Report = &report
+		}
+	}
+	return result
+}
+
+// Import implements ImportHandler.
+// Small files are imported straight away, large ones or ?async=true start a job.
+func (h *importHandler) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		format, ok := importer.Lookup(r.PathValue("format"))
+		if !ok {
+			WriteJSONError(w, fmt.Sprintf("Unknown import format, use one of %s", strings.Join(importer.Formats(), ", ")), http.StatusNotFound)
+			return
+		}
+		file, err := uploadedFile(w, r)
+		if err != nil {
+			WriteJSONError(w, "Upload the export as the file field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				WriteJSONError(w, "Export file is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dryRun := queryBool(r, "dry_run")
+
+		if queryBool(r, "async") || len(data) > backgroundImportSize {
+			jobID, err := h.jobs.Start(ctx, userID, format, data, dryRun)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			job, err := h.store.GetImportJob(ctx, db.GetImportJobParams{ID: jobID, UserID: userID})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Location", fmt.Sprintf("/user/import/jobs/%d", jobID))
+			WriteJSON(w, http.StatusAccepted, JSONResponse{
+				Message: "Import started, poll the job for its report",
+				Data:    toImportJob(job),
+			})
+			return
+		}
+
+		records, err := format.Parse(bytes.NewReader(data))
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		report, err := h.pipeline.Run(ctx, userID, records, dryRun)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("%s import for user %d: %+v (dry run %t)", format.Name(), userID, report.Summary, dryRun)
+
+		message := "Library imported successfully"
+		if dryRun {
+			message = "Dry run completed, nothing was saved"
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: message,
+			Data:    report,
+		})
+	}
+}
+
+// GetImportJob implements ImportHandler.
+func (h *importHandler) GetImportJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		jobID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid job ID", http.StatusBadRequest)
+			return
+		}
+		job, err := h.store.GetImportJob(ctx, db.GetImportJobParams{ID: jobID, UserID: userID})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Import job not found", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Import job retrieved successfully",
+			Data:    toImportJob(job),
+		})
+	}
+}
+
+// ImportKindleClippings implements ImportHandler.
+// Highlights, notes and bookmarks from "My Clippings.txt" are saved against their books.
+func (h *importHandler) ImportKindleClippings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		file, err := uploadedFile(w, r)
+		if err != nil {
+			WriteJSONError(w, "Upload My Clippings.txt as the file field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		clippings, err := importer.ParseKindleClippings(file)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				WriteJSONError(w, "Clippings file is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(clippings) == 0 {
+			WriteJSONError(w, "No clippings found in the file", http.StatusBadRequest)
+			return
+		}
+		dryRun := queryBool(r, "dry_run")
+
+		report, err := h.pipeline.ImportClippings(ctx, userID, clippings, dryRun)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Kindle clippings import for user %d: %+v (dry run %t)", userID, report.Summary, dryRun)
+
+		message := "Clippings imported successfully"
+		if dryRun {
+			message = "Dry run completed, nothing was saved"
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: message,
+			Data:    report,
+		})
+	}
+}
+
+// calibreLibraryPath resolves a server path to a metadata.db inside CALIBRE_LIBRARY_DIR.
+// The path may name the library folder or the metadata.db in it. Symlinks are resolved
+// before checking, so a link cannot lead out of the directory.
+func calibreLibraryPath(path string) (string, error) {
+	if config.CALIBRE_LIBRARY_DIR == "" {
+		return "", errors.New("importing from a server path is not enabled, upload metadata.db instead")
+	}
+	root, err := filepath.EvalSymlinks(config.CALIBRE_LIBRARY_DIR)
+	if err != nil {
+		return "", err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "metadata.db")
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errors.New("no metadata.db found at that path")
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("path must be inside the Calibre library directory")
+	}
+	return resolved, nil
+}
+
+// ImportCalibre implements ImportHandler.
+// The library is an uploaded metadata.db or, with ?path=, one on the server. Importing the
+// same library again updates the books it brought in before.
+func (h *importHandler) ImportCalibre() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		query := r.URL.Query()
+		options := importer.CalibreOptions{
+			ReadColumn:     query.Get("read_column"),
+			DateReadColumn: query.Get("date_read_column"),
+		}
+
+		path := query.Get("path")
+		if path != "" {
+			var err error
+			path, err = calibreLibraryPath(path)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			// sqlite needs a file, so the upload is copied to a temporary one
+			r.Body = http.MaxBytesReader(w, r.Body, maxCalibreSize)
+			var upload io.ReadCloser = r.Body
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+				file, _, err := r.FormFile("file")
+				if err != nil {
+					WriteJSONError(w, "Upload metadata.db as the file field", http.StatusBadRequest)
+					return
+				}
+				upload = file
+			}
+			defer upload.Close()
+			tmp, err := os.CreateTemp("", "calibre-*.db")
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer os.Remove(tmp.Name())
+			_, err = io.Copy(tmp, upload)
+			tmp.Close()
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					WriteJSONError(w, "metadata.db is too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			path = tmp.Name()
+		}
+
+		books, err := importer.ReadCalibreLibrary(path, options)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dryRun := queryBool(r, "dry_run")
+		report, err := h.pipeline.SyncCalibre(ctx, userID, books, dryRun)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Calibre import for user %d: %+v (dry run %t)", userID, report.Summary, dryRun)
+
+		message := "Calibre library imported successfully"
+		if dryRun {
+			message = "Dry run completed, nothing was saved"
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: message,
+			Data:    report,
+		})
+	}
+}
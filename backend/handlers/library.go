@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"booktrackr/db"
+)
+
+const (
+	defaultLibraryPageSize = 50
+	maxLibraryPageSize     = 200
+)
+
+func encodeLibraryCursor(cursor db.LibraryCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeLibraryCursor(value string) (*db.LibraryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor db.LibraryCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// parseLibraryQuery reads the pagination, sort and filter parameters of GET /user/books.
+// The returned map holds a message for every parameter that is invalid.
+func parseLibraryQuery(query url.Values) (db.ListLibraryParams, map[string]string) {
+	fields := map[string]string{}
+	params := db.ListLibraryParams{
+		Sort:  db.LibrarySortAdded,
+		Limit: defaultLibraryPageSize,
+	}
+
+	intParam := func(name string, min, max int64) int64 {
+		value := query.Get(name)
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < min || n > max {
+			fields[name] = "must be a number between " + strconv.FormatInt(min, 10) + " and " + strconv.FormatInt(max, 10)
+			return 0
+		}
+		return n
+	}
+
+	if limit := intParam("limit", 1, maxLibraryPageSize); limit > 0 {
+		params.Limit = limit
+	}
+	if sort := query.Get("sort"); sort != "" {
+		params.Sort = db.LibrarySort(sort)
+		if !db.ValidLibrarySort(params.Sort) {
+			fields["sort"] = "must be one of title, author, added, finished or rating"
+		}
+	}
+	// dates and ratings read best newest or highest first
+	switch params.Sort {
+	case db.LibrarySortAdded, db.LibrarySortFinished, db.LibrarySortRating:
+		params.Desc = true
+	}
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		params.Desc = false
+	case "desc":
+		params.Desc = true
+	default:
+		fields["order"] = "must be asc or desc"
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeLibraryCursor(cursor)
+		if err != nil {
+			fields["cursor"] = "is not a cursor returned by this listing"
+		} else if after.Sort != params.Sort || after.Desc != params.Desc {
+			fields["cursor"] = "was returned for a different sort order"
+		}
+		params.After = after
+	}
+
+	params.Filter = db.LibraryFilter{
+		RatingMin:    intParam("rating_min", 1, 5),
+		RatingMax:    intParam("rating_max", 1, 5),
+		FinishedYear: int(intParam("finished_year", 1, 9999)),
+		Author:       query.Get("author"),
+		Status:       query.Get("status"),
+		Tag:          query.Get("tag"),
+	}
+	if status := params.Filter.Status; status != "" && status != db.LibraryStatusToRead && !validReadStatus(status) {
+		fields["status"] = "must be one of to-read, reading, finished or abandoned"
+	}
+	if params.Filter.RatingMin > 0 && params.Filter.RatingMax > 0 && params.Filter.RatingMin > params.Filter.RatingMax {
+		fields["rating_min"] = "cannot be more than rating_max"
+	}
+	return params, fields
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"net/http"
 	"strconv"
 
 	"booktrackr/auth"
+	"booktrackr/db"
 	log "booktrackr/logging"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -49,3 +51,59 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r.WithContext(ctx))
 	}
 }
+
+// OptionalAuthMiddleware is for public routes that show a signed in user more
+func OptionalAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if userID, err := auth.GetSessionIDFromRequest(r); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+		}
+		next(w, r)
+	}
+}
+
+// GetViewerID is the signed in user behind OptionalAuthMiddleware, 0 for anyone else
+func GetViewerID(ctx context.Context) int64 {
+	if _, ok := ctx.Value(UserIDKey).(string); !ok {
+		return 0
+	}
+	return GetUserID(ctx)
+}
+
+// TokenAuthMiddleware protects routes used by apps that sign in with a personal access token
+// rather than a session. A failure asks for Basic auth so e-readers prompt for the token.
+func TokenAuthMiddleware(store *db.Queries, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetAccessTokenFromRequest(r)
+		var accessToken db.AccessToken
+		if err == nil {
+			accessToken, err = store.GetAccessTokenByHash(r.Context(), auth.HashAccessToken(token))
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="booktrackr", charset="UTF-8"`)
+			WriteJSONError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := store.TouchAccessToken(r.Context(), accessToken.ID); err != nil {
+			log.Error("Failed to record use of access token %d: %v", accessToken.ID, err)
+		}
+		ctx := context.WithValue(r.Context(), UserIDKey, strconv.FormatInt(accessToken.UserID, 10))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// AdminMiddleware protects moderation routes, it goes inside AuthMiddleware
+func AdminMiddleware(store *db.Queries, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		isAdmin, err := store.IsAdmin(r.Context(), GetUserID(r.Context()))
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !isAdmin {
+			WriteJSONError(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	"booktrackr/db"
+)
+
+type NotificationHandler interface {
+	ListNotifications() http.HandlerFunc
+	MarkNotificationsRead() http.HandlerFunc
+}
+
+type notificationHandler struct {
+	store *db.Queries
+}
+
+func NewNotificationHandler(store *db.Queries) NotificationHandler {
+	return &notificationHandler{store: store}
+}
+
+// Notification tells a user about something that happened to a review, Reviewer and BookID say which
+type Notification struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Actor     string `json:"actor"`
+	Reviewer  string `json:"reviewer"`
+	BookID    int    `json:"book_id"`
+	Title     string `json:"title"`
+	CommentID int    `json:"comment_id,omitempty"`
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListNotifications implements NotificationHandler.
+// Notifications come newest first. ?unread=true leaves out the ones already read, the total is
+// then the number of unread notifications.
+func (h *notificationHandler) ListNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		limit, before, ok := pageParams(w, r.URL.Query(), newestFirst)
+		if !ok {
+			return
+		}
+		unreadOnly := r.URL.Query().Get("unread") == "true"
+		rows, err := h.store.ListNotifications(ctx, db.ListNotificationsParams{
+			UserID:      userID,
+			IncludeRead: !unreadOnly,
+			BeforeTime:  before.Time,
+			BeforeID:    before.ID,
+			Limit:       limit + 1,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var nextCursor string
+		if int64(len(rows)) > limit {
+			rows = rows[:limit]
+			last := rows[len(rows)-1]
+			nextCursor = encodeTimelineCursor(timelineCursor{Time: last.CreatedAt.UTC().Format(timelineTimeLayout), ID: last.ID})
+		}
+		notifications := []Notification{}
+		for _, row := range rows {
+			notifications = append(notifications, Notification{
+				ID:        int(row.ID),
+				Type:      row.Type,
+				Actor:     row.Actor,
+				Reviewer:  row.Reviewer,
+				BookID:    int(row.BookID),
+				Title:     row.Title,
+				CommentID: int(row.CommentID.Int64),
+				Read:      row.ReadAt.Valid,
+				CreatedAt: row.CreatedAt.String(),
+			})
+		}
+		response := JSONResponse{
+			Message:    "Notifications retrieved successfully",
+			Data:       notifications,
+			NextCursor: nextCursor,
+		}
+		if unreadOnly {
+			unread, err := h.store.CountUnreadNotifications(ctx, userID)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			response.Total = &unread
+		}
+		WriteJSON(w, http.StatusOK, response)
+	}
+}
+
+// MarkNotificationsRead implements NotificationHandler.
+// It marks notifications up to up_to_id as read, so ones that came in since the client last
+// listed them stay unread. Without up_to_id it marks all of them.
+func (h *notificationHandler) MarkNotificationsRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		var req struct {
+			UpToID int64 `json:"up_to_id"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.UpToID <= 0 {
+			req.UpToID = math.MaxInt64
+		}
+		marked, err := h.store.MarkNotificationsRead(r.Context(), db.MarkNotificationsReadParams{UserID: userID, ID: req.UpToID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: fmt.Sprintf("%d notifications marked as read", marked)})
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+	"booktrackr/pkg/opds"
+)
+
+const (
+	opdsRoot       = "/opds/"
+	opdsPageSize   = 50
+	opdsSearchPath = "/opds/search"
+	opdsSearchDoc  = "/opds/opensearch.xml"
+)
+
+// opdsStatuses are the status feeds, in the order the root lists them
+var opdsStatuses = []struct {
+	status string
+	title  string
+}{
+	{ReadStatusReading, "Currently reading"},
+	{db.LibraryStatusToRead, "Want to read"},
+	{ReadStatusFinished, "Finished"},
+	{ReadStatusAbandoned, "Abandoned"},
+}
+
+type OPDSHandler interface {
+	Root() http.HandlerFunc
+	Shelves() http.HandlerFunc
+	Shelf() http.HandlerFunc
+	Status() http.HandlerFunc
+	All() http.HandlerFunc
+	Search() http.HandlerFunc
+	OpenSearch() http.HandlerFunc
+}
+
+type opdsHandler struct {
+	store *db.Queries
+}
+
+func NewOPDSHandler(store *db.Queries) OPDSHandler {
+	return &opdsHandler{store: store}
+}
+
+func writeOPDS(w http.ResponseWriter, contentType string, document interface{}) {
+	w.Header().Set("Content-Type", contentType+";charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	opds.Write(w, document)
+}
+
+// commonLinks are the links every feed carries
+func commonLinks(r *http.Request, kind string) []opds.Link {
+	return []opds.Link{
+		{Rel: opds.RelSelf, Href: r.URL.RequestURI(), Type: kind},
+		{Rel: opds.RelStart, Href: opdsRoot, Type: opds.NavigationType},
+		{Rel: opds.RelSearch, Href: opdsSearchDoc, Type: opds.OpenSearchType},
+	}
+}
+
+func navigationEntry(id, title, href, kind, content string, updated time.Time) opds.Entry {
+	return opds.Entry{
+		Title:   title,
+		ID:      id,
+		Updated: opds.Time(updated),
+		Content: &opds.Content{Type: "text", Text: content},
+		Links:   []opds.Link{{Rel: opds.RelSubsection, Href: href, Type: kind}},
+	}
+}
+
+func booksCount(n int64) string {
+	if n == 1 {
+		return "1 book"
+	}
+	return fmt.Sprintf("%d books", n)
+}
+
+// hasISBN is false for books imported without an ISBN, they are keyed by "source:id"
+func hasISBN(isbn string) bool {
+	return isbn != "" && !strings.Contains(isbn, ":")
+}
+
+// bookEntry describes a library entry. booktrackr keeps no book files,
+// so entries carry metadata, covers and a link to the book's page on Open Library.
+func bookEntry(book db.Book, updated time.Time) opds.Entry {
+	entry := opds.Entry{
+		Title:   book.Title,
+		ID:      fmt.Sprintf("urn:booktrackr:book:%d", book.ID),
+		Updated: opds.Time(updated),
+		Links:   []opds.Link{},
+	}
+	if book.Author != "" {
+		entry.Authors = []opds.Author{{Name: book.Author}}
+	}
+	if book.Description != "" {
+		entry.Content = &opds.Content{Type: "text", Text: book.Description}
+	}
+	if hasISBN(book.Isbn) {
+		entry.Identifier = "urn:isbn:" + book.Isbn
+		entry.Links = append(entry.Links, opds.Link{
+			Rel:   opds.RelAlternate,
+			Href:  "https://openlibrary.org/isbn/" + book.Isbn,
+			Type:  "text/html",
+			Title: "Open Library",
+		})
+	}
+	if book.ImageUrl != "" {
+		entry.Links = append(entry.Links,
+			opds.Link{Rel: opds.RelImage, Href: book.ImageUrl, Type: "image/jpeg"},
+			opds.Link{Rel: opds.RelThumbnail, Href: book.ImageUrl, Type: "image/jpeg"},
+		)
+	}
+	return entry
+}
+
+// Root implements OPDSHandler.
+// The start of the catalog links to a feed per status, the shelves and all books.
+func (h *opdsHandler) Root() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		now := time.Now()
+		feed := opds.NewFeed("urn:booktrackr:catalog", "booktrackr", now)
+		feed.Links = commonLinks(r, opds.NavigationType)
+
+		for _, s := range opdsStatuses {
+			count, err := h.store.CountLibrary(ctx, userID, db.LibraryFilter{Status: s.status})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			feed.Entries = append(feed.Entries, navigationEntry(
+				"urn:booktrackr:status:"+s.status, s.title, opdsRoot+"status/"+s.status,
+				opds.AcquisitionType, booksCount(count), now,
+			))
+		}
+		feed.Entries = append(feed.Entries, navigationEntry(
+			"urn:booktrackr:shelves", "Shelves", opdsRoot+"shelves", opds.NavigationType, "Books by shelf", now,
+		))
+		total, err := h.store.CountLibrary(ctx, userID, db.LibraryFilter{})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		feed.Entries = append(feed.Entries, navigationEntry(
+			"urn:booktrackr:all", "All books", opdsRoot+"all", opds.AcquisitionType, booksCount(total), now,
+		))
+		writeOPDS(w, opds.NavigationType, feed)
+	}
+}
+
+// Shelves implements OPDSHandler.
+func (h *opdsHandler) Shelves() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		tags, err := h.store.ListUserTags(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		feed := opds.NewFeed("urn:booktrackr:shelves", "Shelves", now)
+		feed.Links = append(commonLinks(r, opds.NavigationType), opds.Link{Rel: opds.RelUp, Href: opdsRoot, Type: opds.NavigationType})
+		for _, tag := range tags {
+			feed.Entries = append(feed.Entries, navigationEntry(
+				"urn:booktrackr:shelf:"+url.PathEscape(tag.Tag), tag.Tag, opdsRoot+"shelves/"+url.PathEscape(tag.Tag),
+				opds.AcquisitionType, booksCount(tag.Books), now,
+			))
+		}
+		writeOPDS(w, opds.NavigationType, feed)
+	}
+}
+
+// libraryFeed writes one page of the library as an acquisition feed. Pages are linked with
+// first and next as in RFC 5005, the cursor keeps pages stable while books are added.
+func (h *opdsHandler) libraryFeed(w http.ResponseWriter, r *http.Request, id, title string, filter db.LibraryFilter) {
+	userID := GetUserID(r.Context())
+	ctx := r.Context()
+	params := db.ListLibraryParams{
+		UserID: userID,
+		Filter: filter,
+		Sort:   db.LibrarySortAdded,
+		Desc:   true,
+		Limit:  opdsPageSize + 1,
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		after, err := decodeLibraryCursor(cursor)
+		if err != nil || after.Sort != params.Sort || after.Desc != params.Desc {
+			WriteJSONError(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.After = after
+	}
+
+	total, err := h.store.CountLibrary(ctx, userID, filter)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rows, err := h.store.ListLibrary(ctx, params)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	feed := opds.NewFeed(id, title, now)
+	feed.Links = append(commonLinks(r, opds.AcquisitionType),
+		opds.Link{Rel: opds.RelUp, Href: opdsRoot, Type: opds.NavigationType},
+		opds.Link{Rel: opds.RelFirst, Href: r.URL.Path, Type: opds.AcquisitionType},
+	)
+	feed.TotalResults = total
+	feed.ItemsPerPage = opdsPageSize
+	if len(rows) > opdsPageSize {
+		rows = rows[:opdsPageSize]
+		last := rows[len(rows)-1]
+		next := encodeLibraryCursor(db.LibraryCursor{Sort: params.Sort, Desc: params.Desc, Key: last.SortKey, BookID: last.ID})
+		feed.Links = append(feed.Links, opds.Link{Rel: opds.RelNext, Href: r.URL.Path + "?cursor=" + next, Type: opds.AcquisitionType})
+	}
+	for _, row := range rows {
+		updated := now
+		if row.AddedAt.Valid {
+			updated = row.AddedAt.Time
+		}
+		feed.Entries = append(feed.Entries, bookEntry(db.Book{
+			ID:          row.ID,
+			Isbn:        row.Isbn,
+			Title:       row.Title,
+			Description: row.Description,
+			Author:      row.Author,
+			ImageUrl:    row.ImageUrl,
+		}, updated))
+	}
+	writeOPDS(w, opds.AcquisitionType, feed)
+}
+
+// Status implements OPDSHandler.
+func (h *opdsHandler) Status() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.PathValue("status")
+		for _, s := range opdsStatuses {
+			if s.status == status {
+				h.libraryFeed(w, r, "urn:booktrackr:status:"+status, s.title, db.LibraryFilter{Status: status})
+				return
+			}
+		}
+		WriteJSONError(w, "Unknown status", http.StatusNotFound)
+	}
+}
+
+// Shelf implements OPDSHandler.
+func (h *opdsHandler) Shelf() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shelf := r.PathValue("shelf")
+		h.libraryFeed(w, r, "urn:booktrackr:shelf:"+url.PathEscape(shelf), shelf, db.LibraryFilter{Tag: shelf})
+	}
+}
+
+// All implements OPDSHandler.
+func (h *opdsHandler) All() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.libraryFeed(w, r, "urn:booktrackr:all", "All books", db.LibraryFilter{})
+	}
+}
+
+// Search implements OPDSHandler.
+// Results come from the library search, best matches first, on a single page.
+func (h *opdsHandler) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		terms := r.URL.Query().Get("q")
+		match := ftsQuery(terms)
+		if match == "" {
+			WriteJSONError(w, "Search terms are required", http.StatusBadRequest)
+			return
+		}
+		results, err := h.store.SearchLibrary(ctx, db.SearchLibraryParams{
+			UserID: userID,
+			Match:  match,
+			Limit:  opdsPageSize,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		feed := opds.NewFeed("urn:booktrackr:search:"+url.QueryEscape(terms), "Search: "+terms, now)
+		feed.Links = append(commonLinks(r, opds.AcquisitionType), opds.Link{Rel: opds.RelUp, Href: opdsRoot, Type: opds.NavigationType})
+		feed.TotalResults = int64(len(results))
+		feed.ItemsPerPage = opdsPageSize
+		for _, result := range results {
+			book, err := h.store.GetBook(ctx, result.BookID)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			feed.Entries = append(feed.Entries, bookEntry(book, now))
+		}
+		writeOPDS(w, opds.AcquisitionType, feed)
+	}
+}
+
+// OpenSearch implements OPDSHandler.
+func (h *opdsHandler) OpenSearch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeOPDS(w, opds.OpenSearchType, opds.NewOpenSearchDescription(opdsSearchPath+"?q={searchTerms}"))
+	}
+}
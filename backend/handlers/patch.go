@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// userBookUpdate is the state of a library entry once an update has been applied
+type userBookUpdate struct {
+	StartDate  sql.NullTime
+	FinishDate sql.NullTime
+	Rating     sql.NullInt64
+	Review     sql.NullString
+	// Progress is a percentage, nil leaves the progress history alone
+	Progress   *int64
+	Visibility string
+}
+
+var jsonNull = []byte("null")
+
+// parseDate accepts a full RFC3339 timestamp or a plain date
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// sameDate reports whether two nullable dates are both unset or the same instant
+func sameDate(a, b sql.NullTime) bool {
+	return a.Valid == b.Valid && (!a.Valid || a.Time.Equal(b.Time))
+}
+
+// saveUserBook writes an update to the library entry and its current read together.
+// It returns errVersionConflict if the entry is no longer at version.
+func (b *bookHandler) saveUserBook(ctx context.Context, userID, bookID, version int64, update userBookUpdate) error {
+	tx, err := b.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := b.store.WithTx(tx)
+
+	updated, err := qtx.UpdateUserBook(ctx, db.UpdateUserBookParams{
+		StartDate:  update.StartDate,
+		FinishDate: update.FinishDate,
+		Rating:     update.Rating,
+		Review:     update.Review,
+		Visibility: update.Visibility,
+		UserID:     userID,
+		BookID:     bookID,
+		Version:    version,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errVersionConflict
+	}
+	if update.Progress != nil {
+		// progress is a percentage here, pages and minutes go through RecordProgress
+		_, err = recordProgress(ctx, qtx, userID, bookID, ProgressUnitPercent, *update.Progress, 100, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	// keep the current read in step with the library entry. Its status only follows the finish
+	// date when that changes, rating an abandoned book leaves it abandoned.
+	status := ReadStatusReading
+	if update.FinishDate.Valid {
+		status = ReadStatusFinished
+	}
+	current, err := qtx.GetCurrentRead(ctx, db.GetCurrentReadParams{UserID: userID, BookID: bookID})
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil && sameDate(current.FinishDate, update.FinishDate) {
+		status = current.Status
+	}
+	err = qtx.UpdateCurrentRead(ctx, db.UpdateCurrentReadParams{
+		StartDate:  update.StartDate,
+		FinishDate: update.FinishDate,
+		Status:     status,
+		Rating:     update.Rating,
+		Review:     update.Review,
+		UserID:     userID,
+		BookID:     bookID,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyPatch merges a JSON merge-patch (RFC 7396) body onto update.
+// A field that is absent stays as it is and a null clears it.
+// The returned map holds a message for every field that could not be applied.
+func applyPatch(patch map[string]json.RawMessage, update *userBookUpdate) map[string]string {
+	fields := map[string]string{}
+	for name, raw := range patch {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), jsonNull)
+		switch name {
+		case "id":
+			// the old PUT body carried the id, it is ignored here
+		case "start_date", "finish_date":
+			date := sql.NullTime{}
+			if !isNull {
+				var value string
+				if err := json.Unmarshal(raw, &value); err != nil {
+					fields[name] = "must be a string"
+					continue
+				}
+				parsed, err := parseDate(value)
+				if err != nil {
+					fields[name] = "must be an RFC3339 timestamp or a YYYY-MM-DD date"
+					continue
+				}
+				date = sql.NullTime{Time: parsed, Valid: true}
+			}
+			if name == "start_date" {
+				update.StartDate = date
+			} else {
+				update.FinishDate = date
+			}
+		case "progress":
+			// clearing progress records it back at zero
+			var progress int64
+			if !isNull {
+				if err := json.Unmarshal(raw, &progress); err != nil {
+					fields[name] = "must be an integer"
+					continue
+				}
+				if progress < 0 || progress > 100 {
+					fields[name] = "must be between 0 and 100"
+					continue
+				}
+			}
+			update.Progress = &progress
+		case "rating":
+			update.Rating = sql.NullInt64{}
+			if !isNull {
+				var rating int64
+				if err := json.Unmarshal(raw, &rating); err != nil {
+					fields[name] = "must be an integer"
+					continue
+				}
+				if rating < 1 || rating > 5 {
+					fields[name] = "must be between 1 and 5"
+					continue
+				}
+				update.Rating = sql.NullInt64{Int64: rating, Valid: true}
+			}
+		case "review":
+			update.Review = sql.NullString{}
+			if !isNull {
+				var review string
+				if err := json.Unmarshal(raw, &review); err != nil {
+					fields[name] = "must be a string"
+					continue
+				}
+				update.Review = sql.NullString{String: review, Valid: true}
+			}
+		case "visibility":
+			// clearing it shows the entry to everyone who can see the profile
+			update.Visibility = VisibilityPublic
+			if !isNull {
+				var visibility string
+				if err := json.Unmarshal(raw, &visibility); err != nil {
+					fields[name] = "must be a string"
+					continue
+				}
+				if !validVisibility(visibility) {
+					fields[name] = "must be one of private, followers or public"
+					continue
+				}
+				update.Visibility = visibility
+			}
+		default:
+			fields[name] = "is not a field that can be updated"
+		}
+	}
+
+	_, badStart := fields["start_date"]
+	_, badFinish := fields["finish_date"]
+	if !badStart && !badFinish && update.StartDate.Valid && update.FinishDate.Valid &&
+		update.FinishDate.Time.Before(update.StartDate.Time) {
+		fields["finish_date"] = "cannot be before start_date"
+	}
+	return fields
+}
+
+// PatchUserBook implements BookHandler.
+func (b *bookHandler) PatchUserBook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+
+		var patch map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+			WriteJSONError(w, "Body must be a JSON object", http.StatusBadRequest)
+			return
+		}
+
+		current, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !checkIfMatch(w, r, current) {
+			return
+		}
+
+		update := userBookUpdate{
+			StartDate:  current.StartDate,
+			FinishDate: current.FinishDate,
+			Rating:     current.Rating,
+			Review:     current.Review,
+			Visibility: current.Visibility,
+		}
+		if fields := applyPatch(patch, &update); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		err = b.saveUserBook(ctx, userID, bookID, current.Version, update)
+		if err == errVersionConflict {
+			b.writeVersionConflict(ctx, w, userID, bookID)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		book, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Book %d patched for user %d", bookID, userID)
+		w.Header().Set("ETag", userBookETag(book.Version))
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Book updated successfully",
+			Data:    toUserBook(book),
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// who a profile or a library entry is shown to
+const (
+	VisibilityPrivate   = "private"
+	VisibilityFollowers = "followers"
+	VisibilityPublic    = "public"
+)
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPrivate, VisibilityFollowers, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+type ProfileHandler interface {
+	GetProfile() http.HandlerFunc
+	UpdateProfile() http.HandlerFunc
+	GetPublicProfile() http.HandlerFunc
+	ListPublicBooks() http.HandlerFunc
+}
+
+type profileHandler struct {
+	store *db.Queries
+}
+
+func NewProfileHandler(store *db.Queries) ProfileHandler {
+	return &profileHandler{store: store}
+}
+
+type Profile struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	Visibility  string `json:"visibility"`
+	JoinedAt    string `json:"joined_at"`
+}
+
+type Shelf struct {
+	Tag   string `json:"tag"`
+	Books int64  `json:"books"`
+}
+
+// PublicProfile is a profile as someone else sees it, counts only take in the entries shown to them
+type PublicProfile struct {
+	Profile
+	Counts  map[string]int64 `json:"counts"`
+	Shelves []Shelf          `json:"shelves"`
+}
+
+// PublicBook is a library entry as someone else sees it
+type PublicBook struct {
+	ID         int    `json:"id"`
+	Isbn       string `json:"isbn"`
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	ImageURL   string `json:"image_url"`
+	StartDate  string `json:"start_date,omitempty"`
+	FinishDate string `json:"finish_date,omitempty"`
+	Rating     int    `json:"rating,omitempty"`
+	Review     string `json:"review,omitempty"`
+	AddedAt    string `json:"added_at,omitempty"`
+}
+
+func toProfile(user db.GetProfileRow) Profile {
+	return Profile{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Visibility:  user.ProfileVisibility,
+		JoinedAt:    user.CreatedAt.Time.String(),
+	}
+}
+
+// profileAccess works out what a viewer may see of owner's library. When ok is false the profile
+// is hidden from them, otherwise visibilities are the entry levels shown to them.
+// follower is whether the viewer is an approved follower of owner.
+func profileAccess(owner db.GetProfileRow, viewerID int64, follower bool) (visibilities []string, ok bool) {
+	if owner.ID == viewerID {
+		return []string{VisibilityPublic, VisibilityFollowers, VisibilityPrivate}, true
+	}
+	if follower && owner.ProfileVisibility != VisibilityPrivate {
+		return []string{VisibilityPublic, VisibilityFollowers}, true
+	}
+	if owner.ProfileVisibility == VisibilityPublic {
+		return []string{VisibilityPublic}, true
+	}
+	return nil, false
+}
+
+// publicProfileOwner finds the profile in the path and what the viewer may see of it.
+// It writes a 404 for profiles that are missing or hidden, so private ones can't be told apart.
+func (h *profileHandler) publicProfileOwner(w http.ResponseWriter, r *http.Request) (db.GetProfileRow, []string, bool) {
+	user, err := h.store.GetProfileByUsername(r.Context(), r.PathValue("username"))
+	if err == sql.ErrNoRows {
+		WriteJSONError(w, "Profile not found", http.StatusNotFound)
+		return db.GetProfileRow{}, nil, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return db.GetProfileRow{}, nil, false
+	}
+	owner := db.GetProfileRow(user)
+	viewerID := GetViewerID(r.Context())
+	follower, err := isFollower(r, h.store, viewerID, owner.ID)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return db.GetProfileRow{}, nil, false
+	}
+	visibilities, ok := profileAccess(owner, viewerID, follower)
+	if !ok {
+		WriteJSONError(w, "Profile not found", http.StatusNotFound)
+		return db.GetProfileRow{}, nil, false
+	}
+	return owner, visibilities, true
+}
+
+// GetProfile implements ProfileHandler.
+func (h *profileHandler) GetProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		user, err := h.store.GetProfile(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Profile retrieved successfully",
+			Data:    toProfile(user),
+		})
+	}
+}
+
+// UpdateProfile implements ProfileHandler.
+func (h *profileHandler) UpdateProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		var req struct {
+			DisplayName string `json:"display_name"`
+			Bio         string `json:"bio"`
+			Visibility  string `json:"visibility"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.DisplayName = strings.TrimSpace(req.DisplayName)
+		fields := map[string]string{}
+		if len(req.DisplayName) > 100 {
+			fields["display_name"] = "must be at most 100 characters"
+		}
+		if len(req.Bio) > 1000 {
+			fields["bio"] = "must be at most 1000 characters"
+		}
+		if !validVisibility(req.Visibility) {
+			fields["visibility"] = "must be one of private, followers or public"
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		err := h.store.UpdateProfile(ctx, db.UpdateProfileParams{
+			DisplayName:       req.DisplayName,
+			Bio:               req.Bio,
+			ProfileVisibility: req.Visibility,
+			ID:                userID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user, err := h.store.GetProfile(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Profile of user %d is now %s", userID, user.ProfileVisibility)
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Profile updated successfully",
+			Data:    toProfile(user),
+		})
+	}
+}
+
+// GetPublicProfile implements ProfileHandler.
+func (h *profileHandler) GetPublicProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		owner, visibilities, ok := h.publicProfileOwner(w, r)
+		if !ok {
+			return
+		}
+
+		profile := PublicProfile{
+			Profile: toProfile(owner),
+			Counts:  map[string]int64{},
+			Shelves: []Shelf{},
+		}
+		for _, status := range []string{"", db.LibraryStatusToRead, ReadStatusReading, ReadStatusFinished, ReadStatusAbandoned} {
+			count, err := h.store.CountLibrary(ctx, owner.ID, db.LibraryFilter{Status: status, Visibilities: visibilities})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if status == "" {
+				status = "total"
+			}
+			profile.Counts[status] = count
+		}
+		tags, err := h.store.ListVisibleUserTags(ctx, db.ListVisibleUserTagsParams{UserID: owner.ID, Visibilities: visibilities})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, tag := range tags {
+			profile.Shelves = append(profile.Shelves, Shelf{Tag: tag.Tag, Books: tag.Books})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Profile retrieved successfully",
+			Data:    profile,
+		})
+	}
+}
+
+// ListPublicBooks implements ProfileHandler.
+// It takes the same parameters as GET /user/books, entries the viewer may not see are left out.
+func (h *profileHandler) ListPublicBooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		owner, visibilities, ok := h.publicProfileOwner(w, r)
+		if !ok {
+			return
+		}
+		params, fields := parseLibraryQuery(r.URL.Query())
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		params.UserID = owner.ID
+		params.Filter.Visibilities = visibilities
+
+		total, err := h.store.CountLibrary(ctx, owner.ID, params.Filter)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		limit := params.Limit
+		params.Limit++
+		books, err := h.store.ListLibrary(ctx, params)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var nextCursor string
+		if int64(len(books)) > limit {
+			books = books[:limit]
+			last := books[len(books)-1]
+			nextCursor = encodeLibraryCursor(db.LibraryCursor{
+				Sort:   params.Sort,
+				Desc:   params.Desc,
+				Key:    last.SortKey,
+				BookID: last.ID,
+			})
+		}
+
+		publicBooks := []PublicBook{}
+		for _, book := range books {
+			publicBook := PublicBook{
+				ID:       int(book.ID),
+				Isbn:     book.Isbn,
+				Title:    book.Title,
+				Author:   book.Author,
+				ImageURL: book.ImageUrl,
+				Rating:   int(book.Rating.Int64),
+				Review:   book.Review.String,
+			}
+			if book.StartDate.Valid {
+				publicBook.StartDate = book.StartDate.Time.String()
+			}
+			if book.FinishDate.Valid {
+				publicBook.FinishDate = book.FinishDate.Time.String()
+			}
+			if book.AddedAt.Valid {
+				publicBook.AddedAt = book.AddedAt.Time.String()
+			}
+			publicBooks = append(publicBooks, publicBook)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message:    "Books retrieved successfully",
+			Data:       publicBooks,
+			NextCursor: nextCursor,
+			Total:      &total,
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"booktrackr/db"
+)
+
+const (
+	ProgressUnitPages   = "pages"
+	ProgressUnitPercent = "percent"
+	ProgressUnitMinutes = "minutes"
+)
+
+// ProgressEvent is one entry in a book's progress timeline.
+// Total is the edition's page count or audiobook duration in minutes.
+type ProgressEvent struct {
+	ID        int    `json:"id"`
+	ReadID    int    `json:"read_id"`
+	Unit      string `json:"unit"`
+	Value     int    `json:"value"`
+	Total     int    `json:"total"`
+	Percent   int    `json:"percent"`
+	CreatedAt string `json:"created_at"`
+}
+
+// progressError is a progress update the client got wrong, as opposed to a storage failure
+type progressError string
+
+func (e progressError) Error() string {
+	return string(e)
+}
+
+func toProgressEvent(event db.ProgressEvent) ProgressEvent {
+	return ProgressEvent{
+		ID:        int(event.ID),
+		ReadID:    int(event.ReadID.Int64),
+		Unit:      event.Unit,
+		Value:     int(event.Value),
+		Total:     int(event.Total.Int64),
+		Percent:   progressPercent(event.Unit, event.Value, event.Total.Int64),
+		CreatedAt: event.CreatedAt.String(),
+	}
+}
+
+// progressPercent converts a progress value to a percentage of the book
+func progressPercent(unit string, value, total int64) int {
+	if unit == ProgressUnitPercent {
+		return int(value)
+	}
+	if total <= 0 {
+		return 0
+	}
+	return int(value * 100 / total)
+}
+
+// validateProgress checks a progress update, total is only needed for pages and minutes
+func validateProgress(unit string, value, total int64) error {
+	switch unit {
+	case ProgressUnitPercent:
+		if value < 0 || value > 100 {
+			return progressError("Progress must be between 0 and 100")
+		}
+	case ProgressUnitPages, ProgressUnitMinutes:
+		if value < 0 {
+			return progressError("Progress cannot be negative")
+		}
+		if total <= 0 {
+			return progressError("Total is required for pages and minutes")
+		}
+		if value > total {
+			return progressError("Progress cannot be more than the total")
+		}
+	default:
+		return progressError("Unit must be one of pages, percent or minutes")
+	}
+	return nil
+}
+
+// recordProgress stores a progress update against the current read of a book.
+// A missing total for pages or minutes falls back to the last one given in that unit.
+func recordProgress(ctx context.Context, store *db.Queries, userID, bookID int64, unit string, value, total int64, at time.Time) (db.ProgressEvent, error) {
+	if unit == ProgressUnitPercent {
+		total = 100
+	} else if total == 0 {
+		latest, err := store.GetLatestProgressTotal(ctx, db.GetLatestProgressTotalParams{
+			UserID: userID,
+			BookID: bookID,
+			Unit:   unit,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return db.ProgressEvent{}, err
+		}
+		total = latest.Int64
+	}
+	if err := validateProgress(unit, value, total); err != nil {
+		return db.ProgressEvent{}, err
+	}
+
+	var readID sql.NullInt64
+	read, err := store.GetCurrentRead(ctx, db.GetCurrentReadParams{UserID: userID, BookID: bookID})
+	if err != nil && err != sql.ErrNoRows {
+		return db.ProgressEvent{}, err
+	}
+	if err == nil {
+		readID = sql.NullInt64{Int64: read.ID, Valid: true}
+	}
+
+	return store.CreateProgressEvent(ctx, db.CreateProgressEventParams{
+		UserID:    userID,
+		BookID:    bookID,
+		ReadID:    readID,
+		Unit:      unit,
+		Value:     value,
+		Total:     sql.NullInt64{Int64: total, Valid: true},
+		CreatedAt: at.UTC(),
+	})
+}
+
+// ListProgress implements BookHandler.
+func (b *bookHandler) ListProgress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		events, err := b.store.ListProgressEvents(ctx, db.ListProgressEventsParams{
+			UserID: userID,
+			BookID: bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		timeline := []ProgressEvent{}
+		for _, event := range events {
+			timeline = append(timeline, toProgressEvent(event))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Progress retrieved successfully",
+			Data:    timeline,
+		})
+	}
+}
+
+// RecordProgress implements BookHandler.
+func (b *bookHandler) RecordProgress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Unit       string `json:"unit"`
+			Value      int64  `json:"value"`
+			Total      int64  `json:"total"`
+			RecordedAt string `json:"recorded_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		recordedAt := time.Now()
+		if req.RecordedAt != "" {
+			recordedAt, err = time.Parse(time.RFC3339, req.RecordedAt)
+			if err != nil {
+				WriteJSONError(w, "recorded_at must be an RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if _, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// the event and the entry's progress and version it derives are saved together
+		tx, err := b.conn.BeginTx(ctx, nil)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := b.store.WithTx(tx)
+		event, err := recordProgress(ctx, qtx, userID, bookID, req.Unit, req.Value, req.Total, recordedAt)
+		var invalid progressError
+		if errors.As(err, &invalid) {
+			WriteJSONError(w, invalid.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// the entry's progress changed, so its ETag has to as well
+		if err := qtx.TouchUserBook(ctx, db.TouchUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Progress recorded successfully",
+			Data:    toProgressEvent(event),
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	log "booktrackr/logging"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"booktrackr/db"
+)
+
+const (
+	ReadStatusReading   = "reading"
+	ReadStatusFinished  = "finished"
+	ReadStatusAbandoned = "abandoned"
+)
+
+// Read is one pass through a book, a book read twice has two of these
+type Read struct {
+	ID         int    `json:"id"`
+	BookID     int    `json:"book_id"`
+	StartDate  string `json:"start_date"`
+	FinishDate string `json:"finish_date"`
+	Status     string `json:"status"`
+	Rating     int    `json:"rating"`
+	Review     string `json:"review"`
+}
+
+func toRead(read db.Read) Read {
+	return Read{
+		ID:         int(read.ID),
+		BookID:     int(read.BookID),
+		StartDate:  read.StartDate.Time.String(),
+		FinishDate: read.FinishDate.Time.String(),
+		Status:     read.Status,
+		Rating:     int(read.Rating.Int64),
+		Review:     read.Review.String,
+	}
+}
+
+func validReadStatus(status string) bool {
+	switch status {
+	case ReadStatusReading, ReadStatusFinished, ReadStatusAbandoned:
+		return true
+	}
+	return false
+}
+
+// pathID parses a numeric path wildcard such as {id}
+func pathID(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
+}
+
+// ListReads implements BookHandler.
+func (b *bookHandler) ListReads() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		reads, err := b.store.ListReadsByUserBook(ctx, db.ListReadsByUserBookParams{
+			UserID: userID,
+			BookID: bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result := []Read{}
+		for _, read := range reads {
+			result = append(result, toRead(read))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Reads retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// StartRead implements BookHandler.
+func (b *bookHandler) StartRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			StartDate string `json:"start_date"`
+		}
+		// the body is optional, an empty one starts the read now
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		startDate := time.Now()
+		if req.StartDate != "" {
+			startDate, err = time.Parse(time.RFC3339, req.StartDate)
+			if err != nil {
+				WriteJSONError(w, "start_date must be an RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// only books already in the library can be re-read
+		if _, err := b.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tx, err := b.conn.BeginTx(ctx, nil)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := b.store.WithTx(tx)
+
+		readID, err := qtx.CreateRead(ctx, db.CreateReadParams{
+			UserID:    userID,
+			BookID:    bookID,
+			StartDate: sql.NullTime{Time: startDate, Valid: true},
+			Status:    ReadStatusReading,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// user_books mirrors the current read's dates, progress, rating and review
+		err = qtx.StartUserBookRead(ctx, db.StartUserBookReadParams{
+			StartDate: sql.NullTime{Time: startDate, Valid: true},
+			UserID:    userID,
+			BookID:    bookID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		read, err := b.store.GetRead(ctx, db.GetReadParams{ID: readID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Started read %d of book %d for user %d", readID, bookID, userID)
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Read started successfully",
+			Data:    toRead(read),
+		})
+	}
+}
+
+// UpdateRead implements BookHandler.
+func (b *bookHandler) UpdateRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		readID, err := pathID(r, "readID")
+		if err != nil {
+			WriteJSONError(w, "Invalid read ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			StartDate  string `json:"start_date"`
+			FinishDate string `json:"finish_date"`
+			Status     string `json:"status"`
+			Rating     int    `json:"rating"`
+			Review     string `json:"review"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		read, err := b.store.GetRead(ctx, db.GetReadParams{ID: readID, UserID: userID})
+		if err != nil || read.BookID != bookID {
+			WriteJSONError(w, "Read not found", http.StatusNotFound)
+			return
+		}
+
+		params := db.UpdateReadParams{
+			StartDate:  read.StartDate,
+			FinishDate: read.FinishDate,
+			Status:     read.Status,
+			Rating:     read.Rating,
+			Review:     read.Review,
+			ID:         readID,
+			UserID:     userID,
+		}
+		if req.StartDate != "" {
+			startDate, err := time.Parse(time.RFC3339, req.StartDate)
+			if err != nil {
+				WriteJSONError(w, "start_date must be an RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			params.StartDate = sql.NullTime{Time: startDate, Valid: true}
+		}
+		if req.FinishDate != "" {
+			finishDate, err := time.Parse(time.RFC3339, req.FinishDate)
+			if err != nil {
+				WriteJSONError(w, "finish_date must be an RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			params.FinishDate = sql.NullTime{Time: finishDate, Valid: true}
+			params.Status = ReadStatusFinished
+		}
+		if req.Status != "" {
+			if !validReadStatus(req.Status) {
+				WriteJSONError(w, "Status must be one of reading, finished or abandoned", http.StatusBadRequest)
+				return
+			}
+			params.Status = req.Status
+		}
+		if req.Rating != 0 {
+			if req.Rating < 1 || req.Rating > 5 {
+				WriteJSONError(w, "Rating must be between 1 and 5", http.StatusBadRequest)
+				return
+			}
+			params.Rating = sql.NullInt64{Int64: int64(req.Rating), Valid: true}
+		}
+		if req.Review != "" {
+			params.Review = sql.NullString{String: req.Review, Valid: true}
+		}
+
+		tx, err := b.conn.BeginTx(ctx, nil)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		qtx := b.store.WithTx(tx)
+		if err := qtx.UpdateRead(ctx, params); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// only the current read is mirrored on the library entry, syncing for an older one
+		// would bump the entry's version for nothing
+		current, err := qtx.GetCurrentRead(ctx, db.GetCurrentReadParams{UserID: userID, BookID: bookID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if current.ID == readID {
+			err = qtx.SyncUserBookWithCurrentRead(ctx, db.SyncUserBookWithCurrentReadParams{
+				UserID: userID,
+				BookID: bookID,
+			})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		read, err = b.store.GetRead(ctx, db.GetReadParams{ID: readID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Read updated successfully",
+			Data:    toRead(read),
+		})
+	}
+}
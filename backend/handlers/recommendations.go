@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"booktrackr/db"
+	"booktrackr/pkg/recommend"
+)
+
+const defaultRecommendationLimit = 20
+
+type RecommendationHandler interface {
+	ListRecommendations() http.HandlerFunc
+}
+
+type recommendationHandler struct {
+	store *db.Queries
+}
+
+// NewRecommendationHandler serves the recommendations a recommend.Refresher computed last
+func NewRecommendationHandler(store *db.Queries) RecommendationHandler {
+	return &recommendationHandler{store: store}
+}
+
+// Recommendation is a book the user doesn't have yet and the liked books that led to it
+type Recommendation struct {
+	BookID   int                `json:"book_id"`
+	Title    string             `json:"title"`
+	Author   string             `json:"author"`
+	ImageURL string             `json:"image_url"`
+	Score    float64            `json:"score"`
+	Reasons  []recommend.Reason `json:"reasons"`
+}
+
+// ListRecommendations implements RecommendationHandler.
+// Last-Modified is when the recommendations were computed.
+func (h *recommendationHandler) ListRecommendations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		limit := int64(defaultRecommendationLimit)
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 || n > recommend.Limit {
+				WriteJSONError(w, "limit must be between 1 and "+strconv.Itoa(recommend.Limit), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		rows, err := h.store.ListRecommendations(ctx, db.ListRecommendationsParams{UserID: userID, Limit: limit})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		recommendations := []Recommendation{}
+		for _, row := range rows {
+			recommendation := Recommendation{
+				BookID:   int(row.BookID),
+				Title:    row.Title,
+				Author:   row.Author,
+				ImageURL: row.ImageUrl,
+				Score:    row.Score,
+			}
+			if err := json.Unmarshal([]byte(row.Reasons), &recommendation.Reasons); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			recommendations = append(recommendations, recommendation)
+		}
+		if len(rows) == 0 {
+			WriteJSON(w, http.StatusOK, JSONResponse{
+				Message: "No recommendations yet, rate books you liked 4 or 5 stars to get some",
+				Data:    recommendations,
+			})
+			return
+		}
+		w.Header().Set("Last-Modified", rows[0].ComputedAt.UTC().Format(http.TimeFormat))
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Recommendations retrieved successfully", Data: recommendations})
+	}
+}
@@ -11,6 +11,9 @@ type JSONResponse struct {
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+	// NextCursor and Total are only set on paginated listings
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
 }
 
 // WriteJSON writes a JSON response with the given status code
@@ -34,3 +37,12 @@ func WriteJSONError(w http.ResponseWriter, message string, status int) {
 		Error: message,
 	})
 }
+
+// WriteValidationErrors writes a 422 response describing what is wrong with each field
+func WriteValidationErrors(w http.ResponseWriter, fields map[string]string) {
+	log.Error("Validation failed: %v", fields)
+	WriteJSON(w, http.StatusUnprocessableEntity, JSONResponse{
+		Error: "Validation failed",
+		Data:  fields,
+	})
+}
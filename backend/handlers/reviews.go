@@ -0,0 +1,631 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// the reactions a review can get, each reader can give each of them once
+var reviewReactions = []string{"like", "love", "laugh", "wow", "sad"}
+
+const maxCommentLength = 2000
+
+type ReviewHandler interface {
+	GetReview() http.HandlerFunc
+	ListComments() http.HandlerFunc
+	CreateComment() http.HandlerFunc
+	UpdateComment() http.HandlerFunc
+	DeleteComment() http.HandlerFunc
+	ReportComment() http.HandlerFunc
+	AddReaction() http.HandlerFunc
+	RemoveReaction() http.HandlerFunc
+	ListReportedComments() http.HandlerFunc
+	HideComment() http.HandlerFunc
+	RestoreComment() http.HandlerFunc
+}
+
+type reviewHandler struct {
+	store *db.Queries
+}
+
+func NewReviewHandler(store *db.Queries) ReviewHandler {
+	return &reviewHandler{store: store}
+}
+
+type ReactionCount struct {
+	Reaction string `json:"reaction"`
+	Count    int64  `json:"count"`
+	Reacted  bool   `json:"reacted"`
+}
+
+type Review struct {
+	Username  string          `json:"username"`
+	BookID    int             `json:"book_id"`
+	Isbn      string          `json:"isbn"`
+	Title     string          `json:"title"`
+	Author    string          `json:"author"`
+	ImageURL  string          `json:"image_url"`
+	Rating    int             `json:"rating,omitempty"`
+	Review    string          `json:"review"`
+	UpdatedAt string          `json:"updated_at,omitempty"`
+	Comments  int64           `json:"comments"`
+	Reactions []ReactionCount `json:"reactions"`
+}
+
+// ReviewComment is a comment on a review. Deleted and hidden comments keep their place in the
+// listing without a body.
+type ReviewComment struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	Body      string `json:"body"`
+	Edited    bool   `json:"edited,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Hidden    bool   `json:"hidden,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ReportedComment is a comment in the moderation queue
+type ReportedComment struct {
+	ID        int      `json:"id"`
+	Username  string   `json:"username"`
+	Reviewer  string   `json:"reviewer"`
+	BookID    int      `json:"book_id"`
+	Body      string   `json:"body"`
+	Reports   int64    `json:"reports"`
+	Reasons   []string `json:"reasons"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// reviewOnPath is the review in the path with the user who wrote it
+type reviewOnPath struct {
+	reviewer db.GetProfileByUsernameRow
+	review   db.GetReviewRow
+}
+
+// reviewByPath finds the review in the path. Reviews follow the privacy of their entry and the
+// reviewer's profile, the ones the viewer may not see get the same 404 as a missing one.
+func (h *reviewHandler) reviewByPath(w http.ResponseWriter, r *http.Request) (reviewOnPath, bool) {
+	ctx := r.Context()
+	bookID, err := pathID(r, "id")
+	if err != nil {
+		WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+		return reviewOnPath{}, false
+	}
+	reviewer, err := h.store.GetProfileByUsername(ctx, r.PathValue("username"))
+	if err == sql.ErrNoRows {
+		WriteJSONError(w, "Review not found", http.StatusNotFound)
+		return reviewOnPath{}, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return reviewOnPath{}, false
+	}
+	viewerID := GetViewerID(ctx)
+	follower, err := isFollower(r, h.store, viewerID, reviewer.ID)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return reviewOnPath{}, false
+	}
+	visibilities, ok := profileAccess(db.GetProfileRow(reviewer), viewerID, follower)
+	var review db.GetReviewRow
+	if ok {
+		review, err = h.store.GetReview(ctx, db.GetReviewParams{UserID: reviewer.ID, BookID: bookID})
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return reviewOnPath{}, false
+		}
+	}
+	if !ok || err != nil || strings.TrimSpace(review.Review.String) == "" || !slices.Contains(visibilities, review.Visibility) {
+		WriteJSONError(w, "Review not found", http.StatusNotFound)
+		return reviewOnPath{}, false
+	}
+	return reviewOnPath{reviewer: reviewer, review: review}, true
+}
+
+// commentOnReview finds the comment in the path, which has to be on the review in the path
+func (h *reviewHandler) commentOnReview(w http.ResponseWriter, r *http.Request, found reviewOnPath) (db.ReviewComment, bool) {
+	commentID, err := pathID(r, "commentID")
+	if err != nil {
+		WriteJSONError(w, "Invalid comment ID", http.StatusBadRequest)
+		return db.ReviewComment{}, false
+	}
+	comment, err := h.store.GetReviewComment(r.Context(), commentID)
+	if err == sql.ErrNoRows || (err == nil && (comment.ReviewerID != found.reviewer.ID || comment.BookID != found.review.BookID || comment.DeletedAt.Valid)) {
+		WriteJSONError(w, "Comment not found", http.StatusNotFound)
+		return db.ReviewComment{}, false
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return db.ReviewComment{}, false
+	}
+	return comment, true
+}
+
+func (h *reviewHandler) reactionCounts(r *http.Request, found reviewOnPath) ([]ReactionCount, error) {
+	rows, err := h.store.ListReviewReactions(r.Context(), db.ListReviewReactionsParams{
+		ViewerID:   GetViewerID(r.Context()),
+		ReviewerID: found.reviewer.ID,
+		BookID:     found.review.BookID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reactions := []ReactionCount{}
+	for _, row := range rows {
+		reactions = append(reactions, ReactionCount{Reaction: row.Reaction, Count: row.Reactions, Reacted: row.Reacted})
+	}
+	return reactions, nil
+}
+
+func validateComment(body string) map[string]string {
+	fields := map[string]string{}
+	if strings.TrimSpace(body) == "" {
+		fields["body"] = "is required"
+	} else if len(body) > maxCommentLength {
+		fields["body"] = "must be at most 2000 characters"
+	}
+	return fields
+}
+
+func toReviewComment(comment db.ReviewComment, username string) ReviewComment {
+	return ReviewComment{
+		ID:        int(comment.ID),
+		Username:  username,
+		Body:      comment.Body,
+		Edited:    comment.UpdatedAt.Valid,
+		CreatedAt: comment.CreatedAt.String(),
+	}
+}
+
+// GetReview implements ReviewHandler.
+func (h *reviewHandler) GetReview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		comments, err := h.store.CountReviewComments(ctx, db.CountReviewCommentsParams{ReviewerID: found.reviewer.ID, BookID: found.review.BookID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reactions, err := h.reactionCounts(r, found)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		review := Review{
+			Username:  found.reviewer.Username,
+			BookID:    int(found.review.BookID),
+			Isbn:      found.review.Isbn,
+			Title:     found.review.Title,
+			Author:    found.review.Author,
+			ImageURL:  found.review.ImageUrl,
+			Rating:    int(found.review.Rating.Int64),
+			Review:    found.review.Review.String,
+			Comments:  comments,
+			Reactions: reactions,
+		}
+		if found.review.UpdatedAt.Valid {
+			review.UpdatedAt = found.review.UpdatedAt.Time.String()
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Review retrieved successfully",
+			Data:    review,
+		})
+	}
+}
+
+// ListComments implements ReviewHandler.
+// Comments come oldest first, a page at a time.
+func (h *reviewHandler) ListComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		limit, after, ok := pageParams(w, r.URL.Query(), oldestFirst)
+		if !ok {
+			return
+		}
+		total, err := h.store.CountReviewComments(ctx, db.CountReviewCommentsParams{ReviewerID: found.reviewer.ID, BookID: found.review.BookID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows, err := h.store.ListReviewComments(ctx, db.ListReviewCommentsParams{
+			ReviewerID: found.reviewer.ID,
+			BookID:     found.review.BookID,
+			AfterTime:  after.Time,
+			AfterID:    after.ID,
+			Limit:      limit + 1,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var nextCursor string
+		if int64(len(rows)) > limit {
+			rows = rows[:limit]
+			last := rows[len(rows)-1]
+			nextCursor = encodeTimelineCursor(timelineCursor{Time: last.CreatedAt.UTC().Format(timelineTimeLayout), ID: last.ID})
+		}
+
+		comments := []ReviewComment{}
+		for _, row := range rows {
+			comment := ReviewComment{
+				ID:        int(row.ID),
+				Username:  row.Username,
+				Body:      row.Body,
+				Edited:    row.UpdatedAt.Valid,
+				Deleted:   row.DeletedAt.Valid,
+				Hidden:    row.HiddenAt.Valid,
+				CreatedAt: row.CreatedAt.String(),
+			}
+			if comment.Deleted || comment.Hidden {
+				comment.Body = ""
+			}
+			comments = append(comments, comment)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message:    "Comments retrieved successfully",
+			Data:       comments,
+			NextCursor: nextCursor,
+			Total:      &total,
+		})
+	}
+}
+
+// CreateComment implements ReviewHandler.
+// The reviewer and the others who commented on the review are notified.
+func (h *reviewHandler) CreateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fields := validateComment(req.Body); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		comment, err := h.store.CreateReviewComment(ctx, db.CreateReviewCommentParams{
+			ReviewerID: found.reviewer.ID,
+			BookID:     found.review.BookID,
+			UserID:     userID,
+			Body:       req.Body,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		err = h.store.NotifyReviewComment(ctx, db.NotifyReviewCommentParams{
+			ActorID:    userID,
+			ReviewerID: found.reviewer.ID,
+			BookID:     found.review.BookID,
+			CommentID:  sql.NullInt64{Int64: comment.ID, Valid: true},
+		})
+		if err != nil {
+			// the comment is saved, a missed notification is not worth failing it for
+			log.Error("Failed to send notifications for comment %d: %v", comment.ID, err)
+		}
+		user, err := h.store.GetProfile(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Comment created successfully",
+			Data:    toReviewComment(comment, user.Username),
+		})
+	}
+}
+
+// UpdateComment implements ReviewHandler.
+// Only the author can edit a comment, and not once an admin has hidden it.
+func (h *reviewHandler) UpdateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		comment, ok := h.commentOnReview(w, r, found)
+		if !ok {
+			return
+		}
+		if comment.UserID != userID {
+			WriteJSONError(w, "You can only edit your own comments", http.StatusForbidden)
+			return
+		}
+		if comment.HiddenAt.Valid {
+			WriteJSONError(w, "This comment was hidden by a moderator", http.StatusConflict)
+			return
+		}
+		var req struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fields := validateComment(req.Body); len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+		comment, err := h.store.UpdateReviewComment(ctx, db.UpdateReviewCommentParams{Body: req.Body, ID: comment.ID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user, err := h.store.GetProfile(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Comment updated successfully",
+			Data:    toReviewComment(comment, user.Username),
+		})
+	}
+}
+
+// DeleteComment implements ReviewHandler.
+// The author, the reviewer and admins can delete a comment. It stays in the listing, marked deleted.
+func (h *reviewHandler) DeleteComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		comment, ok := h.commentOnReview(w, r, found)
+		if !ok {
+			return
+		}
+		if comment.UserID != userID && found.reviewer.ID != userID {
+			isAdmin, err := h.store.IsAdmin(ctx, userID)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !isAdmin {
+				WriteJSONError(w, "You cannot delete this comment", http.StatusForbidden)
+				return
+			}
+		}
+		if err := h.store.DeleteReviewComment(ctx, comment.ID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d deleted comment %d", userID, comment.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Comment deleted successfully"})
+	}
+}
+
+// ReportComment implements ReviewHandler.
+// Reported comments wait in the moderation queue until an admin hides them or dismisses the reports.
+func (h *reviewHandler) ReportComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		comment, ok := h.commentOnReview(w, r, found)
+		if !ok {
+			return
+		}
+		if comment.UserID == userID {
+			WriteJSONError(w, "You cannot report your own comment", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Reason) > 500 {
+			WriteValidationErrors(w, map[string]string{"reason": "must be at most 500 characters"})
+			return
+		}
+		err := h.store.ReportReviewComment(r.Context(), db.ReportReviewCommentParams{
+			CommentID: comment.ID,
+			UserID:    userID,
+			Reason:    strings.TrimSpace(req.Reason),
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("User %d reported comment %d", userID, comment.ID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Comment reported, thank you"})
+	}
+}
+
+// reaction handles AddReaction and RemoveReaction, which only differ in the query they run
+func (h *reviewHandler) reaction(add bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		found, ok := h.reviewByPath(w, r)
+		if !ok {
+			return
+		}
+		reaction := r.PathValue("reaction")
+		if !slices.Contains(reviewReactions, reaction) {
+			WriteValidationErrors(w, map[string]string{"reaction": "must be one of " + strings.Join(reviewReactions, ", ")})
+			return
+		}
+		var err error
+		if add {
+			err = h.store.AddReviewReaction(ctx, db.AddReviewReactionParams{
+				ReviewerID: found.reviewer.ID,
+				BookID:     found.review.BookID,
+				UserID:     userID,
+				Reaction:   reaction,
+			})
+		} else {
+			err = h.store.RemoveReviewReaction(ctx, db.RemoveReviewReactionParams{
+				ReviewerID: found.reviewer.ID,
+				BookID:     found.review.BookID,
+				UserID:     userID,
+				Reaction:   reaction,
+			})
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reactions, err := h.reactionCounts(r, found)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Reactions updated successfully",
+			Data:    reactions,
+		})
+	}
+}
+
+// AddReaction implements ReviewHandler.
+func (h *reviewHandler) AddReaction() http.HandlerFunc {
+	return h.reaction(true)
+}
+
+// RemoveReaction implements ReviewHandler.
+func (h *reviewHandler) RemoveReaction() http.HandlerFunc {
+	return h.reaction(false)
+}
+
+// ListReportedComments implements ReviewHandler.
+// It is the moderation queue for admins, newest comments first.
+func (h *reviewHandler) ListReportedComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, before, ok := pageParams(w, r.URL.Query(), newestFirst)
+		if !ok {
+			return
+		}
+		rows, err := h.store.ListReportedComments(r.Context(), db.ListReportedCommentsParams{
+			BeforeTime: before.Time,
+			BeforeID:   before.ID,
+			Limit:      limit + 1,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var nextCursor string
+		if int64(len(rows)) > limit {
+			rows = rows[:limit]
+			last := rows[len(rows)-1]
+			nextCursor = encodeTimelineCursor(timelineCursor{Time: last.CreatedAt.UTC().Format(timelineTimeLayout), ID: last.ID})
+		}
+		comments := []ReportedComment{}
+		for _, row := range rows {
+			reasons := []string{}
+			for _, reason := range strings.Split(row.Reasons, "\n") {
+				if reason != "" {
+					reasons = append(reasons, reason)
+				}
+			}
+			comments = append(comments, ReportedComment{
+				ID:        int(row.ID),
+				Username:  row.Username,
+				Reviewer:  row.Reviewer,
+				BookID:    int(row.BookID),
+				Body:      row.Body,
+				Reports:   row.Reports,
+				Reasons:   reasons,
+				CreatedAt: row.CreatedAt.String(),
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message:    "Reported comments retrieved successfully",
+			Data:       comments,
+			NextCursor: nextCursor,
+		})
+	}
+}
+
+// HideComment implements ReviewHandler.
+// A hidden comment keeps its place in the listing without its body, and leaves the queue.
+func (h *reviewHandler) HideComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		commentID, err := pathID(r, "commentID")
+		if err != nil {
+			WriteJSONError(w, "Invalid comment ID", http.StatusBadRequest)
+			return
+		}
+		hidden, err := h.store.HideReviewComment(ctx, db.HideReviewCommentParams{
+			HiddenBy: sql.NullInt64{Int64: userID, Valid: true},
+			ID:       commentID,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if hidden == 0 {
+			WriteJSONError(w, "No shown comment with this ID", http.StatusNotFound)
+			return
+		}
+		log.Info("Admin %d hid comment %d", userID, commentID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Comment hidden successfully"})
+	}
+}
+
+// RestoreComment implements ReviewHandler.
+// It shows a hidden comment again and dismisses its reports, for a comment that was never hidden
+// that takes it out of the queue.
+func (h *reviewHandler) RestoreComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		commentID, err := pathID(r, "commentID")
+		if err != nil {
+			WriteJSONError(w, "Invalid comment ID", http.StatusBadRequest)
+			return
+		}
+		if _, err := h.store.GetReviewComment(ctx, commentID); err == sql.ErrNoRows {
+			WriteJSONError(w, "Comment not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := h.store.RestoreReviewComment(ctx, commentID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := h.store.DismissCommentReports(ctx, commentID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Admin %d restored comment %d", userID, commentID)
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Comment restored successfully"})
+	}
+}
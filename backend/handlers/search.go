@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"booktrackr/db"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResult is one library entry matching a search, best matches come first
+type SearchResult struct {
+	BookID         int     `json:"book_id"`
+	Title          string  `json:"title"`
+	Author         string  `json:"author"`
+	ImageURL       string  `json:"image_url"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// ftsQuery turns what the user typed into a safe FTS5 query.
+// "quoted text" is a phrase, a word ending in * matches as a prefix and
+// everything else must appear as a whole word. All terms have to match.
+func ftsQuery(input string) string {
+	var terms []string
+	quote := func(term string) string {
+		return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	rest := input
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		if rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				terms = append(terms, quote(phrase))
+			}
+			rest = after
+			continue
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+
+		prefix := strings.HasSuffix(word, "*")
+		// keep only characters that the tokenizer would index anyway
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				return r
+			}
+			return ' '
+		}, word)
+		word = strings.Join(strings.Fields(word), " ")
+		if word == "" {
+			continue
+		}
+		if prefix {
+			terms = append(terms, quote(word)+"*")
+		} else {
+			terms = append(terms, quote(word))
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// SearchLibrary implements BookHandler.
+func (b *bookHandler) SearchLibrary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		match := ftsQuery(r.URL.Query().Get("q"))
+		if match == "" {
+			WriteJSONError(w, "Query parameter q is required", http.StatusBadRequest)
+			return
+		}
+		limit := int64(defaultSearchLimit)
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 || n > maxSearchLimit {
+				WriteJSONError(w, "limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		rows, err := b.store.SearchLibrary(ctx, db.SearchLibraryParams{
+			UserID: userID,
+			Match:  match,
+			Limit:  limit,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results := []SearchResult{}
+		for _, row := range rows {
+			results = append(results, SearchResult{
+				BookID:         int(row.BookID),
+				Title:          row.Title,
+				Author:         row.Author,
+				ImageURL:       row.ImageUrl,
+				Rank:           row.Rank,
+				TitleHighlight: row.TitleHighlight,
+				Snippet:        row.Snippet,
+			})
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Search completed successfully",
+			Data:    results,
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"booktrackr/db"
+)
+
+// maxSeriesName keeps series names to something that fits a heading
+const maxSeriesName = 200
+
+type SeriesHandler interface {
+	GetSeries() http.HandlerFunc
+	ContinueSeries() http.HandlerFunc
+	SetBookSeries() http.HandlerFunc
+	RemoveBookSeries() http.HandlerFunc
+}
+
+type seriesHandler struct {
+	store *db.Queries
+}
+
+func NewSeriesHandler(store *db.Queries) SeriesHandler {
+	return &seriesHandler{store: store}
+}
+
+// SeriesEntry is a book of a series and where the user is with it. Read is whether they ever
+// finished it, Status is their latest read, empty when they haven't started it.
+type SeriesEntry struct {
+	BookID    int      `json:"book_id"`
+	Title     string   `json:"title"`
+	Author    string   `json:"author"`
+	ImageURL  string   `json:"image_url"`
+	Position  *float64 `json:"position"`
+	InLibrary bool     `json:"in_library"`
+	Read      bool     `json:"read"`
+	Status    string   `json:"status,omitempty"`
+}
+
+// Series is a series in reading order. Next is the entry to read after the furthest one the user
+// has read, skipping the ones they gave up on.
+type Series struct {
+	ID      int           `json:"id"`
+	Name    string        `json:"name"`
+	Read    int           `json:"read"`
+	Total   int           `json:"total"`
+	Next    *SeriesEntry  `json:"next"`
+	Entries []SeriesEntry `json:"entries,omitempty"`
+}
+
+// BookSeries is a series a book belongs to. Manual is whether the user put it there themselves,
+// only those can be removed.
+type BookSeries struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Position *float64 `json:"position"`
+	Manual   bool     `json:"manual"`
+}
+
+func nullablePosition(position sql.NullFloat64) *float64 {
+	if !position.Valid {
+		return nil
+	}
+	return &position.Float64
+}
+
+// nextEntry is the first entry from up to to that is neither read nor given up on
+func (s *Series) nextEntry(from, to int) *SeriesEntry {
+	for i := from; i < to; i++ {
+		if entry := s.Entries[i]; !entry.Read && entry.Status != ReadStatusAbandoned {
+			return &s.Entries[i]
+		}
+	}
+	return nil
+}
+
+// toSeries groups entries, which come in reading order, by series. lastFinished is when the user
+// last finished an entry of each.
+func toSeries(rows []db.ListSeriesEntriesRow) ([]*Series, map[int]string) {
+	result := []*Series{}
+	lastFinished := map[int]string{}
+	var current *Series
+	for _, row := range rows {
+		if current == nil || current.ID != int(row.SeriesID) {
+			current = &Series{ID: int(row.SeriesID), Name: row.SeriesName, Entries: []SeriesEntry{}}
+			result = append(result, current)
+		}
+		entry := SeriesEntry{
+			BookID:    int(row.BookID),
+			Title:     row.Title,
+			Author:    row.Author,
+			ImageURL:  row.ImageUrl,
+			Position:  nullablePosition(row.Position),
+			InLibrary: row.InLibrary,
+			Read:      row.Finished,
+			Status:    row.ReadStatus,
+		}
+		current.Entries = append(current.Entries, entry)
+		if row.LastFinished > lastFinished[current.ID] {
+			lastFinished[current.ID] = row.LastFinished
+		}
+	}
+	for _, series := range result {
+		series.Total = len(series.Entries)
+		furthest := -1
+		for i, entry := range series.Entries {
+			if entry.Read {
+				series.Read++
+				furthest = i
+			}
+		}
+		// the next book is after the furthest one read, a gap before it only once there is
+		// nothing left after
+		series.Next = series.nextEntry(furthest+1, len(series.Entries))
+		if series.Next == nil {
+			series.Next = series.nextEntry(0, furthest)
+		}
+	}
+	return result, lastFinished
+}
+
+// GetSeries implements SeriesHandler.
+func (h *seriesHandler) GetSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		seriesID, err := pathID(r, "id")
+		if err != nil || seriesID < 1 {
+			WriteJSONError(w, "Invalid series ID", http.StatusBadRequest)
+			return
+		}
+		rows, err := h.store.ListSeriesEntries(ctx, db.ListSeriesEntriesParams{UserID: userID, SeriesID: seriesID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// a series goes away with its last book
+		series, _ := toSeries(rows)
+		if len(series) == 0 {
+			WriteJSONError(w, "Series not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Series retrieved successfully", Data: series[0]})
+	}
+}
+
+// ContinueSeries implements SeriesHandler.
+// It lists the series the user has finished a book of and not yet the rest, with the book to read
+// next, the series they finished a book of most recently first.
+func (h *seriesHandler) ContinueSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		rows, err := h.store.ListSeriesEntries(ctx, db.ListSeriesEntriesParams{UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		all, lastFinished := toSeries(rows)
+		result := []*Series{}
+		for _, series := range all {
+			if series.Next == nil {
+				continue
+			}
+			series.Entries = nil
+			result = append(result, series)
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return lastFinished[result[i].ID] > lastFinished[result[j].ID]
+		})
+		WriteJSON(w, http.StatusOK, JSONResponse{Message: "Series to continue retrieved successfully", Data: result})
+	}
+}
+
+func (h *seriesHandler) writeBookSeries(ctx context.Context, w http.ResponseWriter, userID, bookID int64, message string) {
+	rows, err := h.store.ListBookSeries(ctx, db.ListBookSeriesParams{BookID: bookID, UserID: userID})
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := []BookSeries{}
+	for _, row := range rows {
+		result = append(result, BookSeries{ID: int(row.ID), Name: row.Name, Position: nullablePosition(row.Position), Manual: row.Manual})
+	}
+	WriteJSON(w, http.StatusOK, JSONResponse{Message: message, Data: result})
+}
+
+// SetBookSeries implements SeriesHandler.
+// The series is created when there is none by the name yet. A book already in it moves to the new
+// position, which can be left out when it isn't known. Only the user sees the change, it takes the
+// place of the catalog's entry for them.
+func (h *seriesHandler) SetBookSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Name     string   `json:"name"`
+			Position *float64 `json:"position"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		errs := map[string]string{}
+		if req.Name == "" {
+			errs["name"] = "is required"
+		} else if len(req.Name) > maxSeriesName {
+			errs["name"] = "is too long"
+		}
+		if req.Position != nil && *req.Position <= 0 {
+			errs["position"] = "must be greater than 0"
+		}
+		if len(errs) > 0 {
+			WriteValidationErrors(w, errs)
+			return
+		}
+		if _, err := h.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := setSeries(ctx, h.store, userID, bookID, req.Name, req.Position); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.writeBookSeries(ctx, w, userID, bookID, "Series updated successfully")
+	}
+}
+
+// setSeries puts a book in a series by name, at position when it is known. The entry is the
+// user's own, userID 0 puts it in the catalog for everyone.
+func setSeries(ctx context.Context, store *db.Queries, userID, bookID int64, name string, position *float64) error {
+	seriesID, err := store.UpsertSeries(ctx, name)
+	if err != nil {
+		return err
+	}
+	params := db.SetUserSeriesBookParams{SeriesID: seriesID, BookID: bookID, UserID: userID}
+	if position != nil {
+		params.Position = sql.NullFloat64{Float64: *position, Valid: true}
+	}
+	return store.SetUserSeriesBook(ctx, params)
+}
+
+// RemoveBookSeries implements SeriesHandler.
+// Users remove the series they set themselves, the catalog's stay for everyone.
+func (h *seriesHandler) RemoveBookSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		seriesID, err := pathID(r, "seriesID")
+		if err != nil {
+			WriteJSONError(w, "Invalid series ID", http.StatusBadRequest)
+			return
+		}
+		if _, err := h.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		removed, err := h.store.RemoveUserSeriesBook(ctx, db.RemoveUserSeriesBookParams{SeriesID: seriesID, BookID: bookID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if removed == 0 {
+			series, err := h.store.ListBookSeries(ctx, db.ListBookSeriesParams{BookID: bookID, UserID: userID})
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, row := range series {
+				if row.ID == seriesID {
+					WriteJSONError(w, "Only series you set yourself can be removed", http.StatusForbidden)
+					return
+				}
+			}
+			WriteJSONError(w, "Book is not in this series", http.StatusNotFound)
+			return
+		}
+		h.writeBookSeries(ctx, w, userID, bookID, "Series removed successfully")
+	}
+}
@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+)
+
+// the sessions reading speed counts, a shorter one is a mis-tap and a longer one a timer left running
+const (
+	sessionSpeedMinimum = time.Minute
+	sessionSpeedLimit   = 12 * time.Hour
+)
+
+type SessionHandler interface {
+	StartSession() http.HandlerFunc
+	StopSession() http.HandlerFunc
+	ListSessions() http.HandlerFunc
+	Streaks() http.HandlerFunc
+	ReadingSpeed() http.HandlerFunc
+	GetTimezone() http.HandlerFunc
+	UpdateTimezone() http.HandlerFunc
+}
+
+type sessionHandler struct {
+	store *db.Queries
+}
+
+func NewSessionHandler(store *db.Queries) SessionHandler {
+	return &sessionHandler{store: store}
+}
+
+// ReadingSession is a timed sitting with a book. Pages is how far the reader got, when the pages
+// at both ends are known. A running session's minutes count up to now.
+type ReadingSession struct {
+	ID        int     `json:"id"`
+	BookID    int     `json:"book_id"`
+	ReadID    int     `json:"read_id,omitempty"`
+	Running   bool    `json:"running"`
+	StartedAt string  `json:"started_at"`
+	EndedAt   string  `json:"ended_at,omitempty"`
+	Minutes   float64 `json:"minutes"`
+	StartPage *int64  `json:"start_page,omitempty"`
+	EndPage   *int64  `json:"end_page,omitempty"`
+	Pages     *int64  `json:"pages,omitempty"`
+}
+
+type Streaks struct {
+	Timezone  string `json:"timezone"`
+	Current   int    `json:"current"`
+	Longest   int    `json:"longest"`
+	ReadToday bool   `json:"read_today"`
+	LastRead  string `json:"last_read,omitempty"`
+}
+
+type Speed struct {
+	PagesPerHour float64 `json:"pages_per_hour"`
+	Sessions     int     `json:"sessions"`
+	Pages        int64   `json:"pages"`
+	Minutes      float64 `json:"minutes"`
+}
+
+type GenreSpeed struct {
+	Genre string `json:"genre"`
+	Speed
+}
+
+// ReadingSpeed is the user's pace over all timed sessions and per genre, a book's tags are its genres
+type ReadingSpeed struct {
+	Speed
+	Genres []GenreSpeed `json:"genres"`
+}
+
+func toReadingSession(session db.ReadingSession, now time.Time) ReadingSession {
+	result := ReadingSession{
+		ID:        int(session.ID),
+		BookID:    int(session.BookID),
+		ReadID:    int(session.ReadID.Int64),
+		Running:   !session.EndedAt.Valid,
+		StartedAt: session.StartedAt.String(),
+	}
+	end := now
+	if session.EndedAt.Valid {
+		end = session.EndedAt.Time
+		result.EndedAt = session.EndedAt.Time.String()
+	}
+	result.Minutes = math.Round(end.Sub(session.StartedAt).Minutes()*10) / 10
+	if session.StartPage.Valid {
+		result.StartPage = &session.StartPage.Int64
+	}
+	if session.EndPage.Valid {
+		result.EndPage = &session.EndPage.Int64
+	}
+	if session.StartPage.Valid && session.EndPage.Valid {
+		pages := session.EndPage.Int64 - session.StartPage.Int64
+		result.Pages = &pages
+	}
+	return result
+}
+
+// latestPage is where the reader is in the book going by page progress, if they ever gave it
+func (h *sessionHandler) latestPage(ctx context.Context, userID, bookID int64) (sql.NullInt64, error) {
+	page, err := h.store.GetLatestPage(ctx, db.GetLatestPageParams{UserID: userID, BookID: bookID})
+	if err == sql.ErrNoRows {
+		return sql.NullInt64{}, nil
+	}
+	return sql.NullInt64{Int64: page, Valid: err == nil}, err
+}
+
+// userLocation is the timezone the user's reading days are counted in
+func userLocation(ctx context.Context, store *db.Queries, userID int64) (*time.Location, error) {
+	name, err := store.GetTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// StartSession implements SessionHandler.
+// The session starts at the page in the body, or the last page progress was given at. Only one
+// session runs at a time, starting another while one runs is a 409 with the running one.
+func (h *sessionHandler) StartSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Page *int64 `json:"page"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Page != nil && *req.Page < 0 {
+			WriteValidationErrors(w, map[string]string{"page": "cannot be negative"})
+			return
+		}
+		if _, err := h.store.GetUserBook(ctx, db.GetUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+			if err == sql.ErrNoRows {
+				WriteJSONError(w, "Book not found in library", http.StatusNotFound)
+				return
+			}
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().UTC()
+		running, err := h.store.GetRunningSession(ctx, userID)
+		if err == nil {
+			WriteJSON(w, http.StatusConflict, JSONResponse{
+				Error: "A reading session is already running",
+				Data:  toReadingSession(running, now),
+			})
+			return
+		}
+		if err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		startPage := sql.NullInt64{}
+		if req.Page != nil {
+			startPage = sql.NullInt64{Int64: *req.Page, Valid: true}
+		} else if startPage, err = h.latestPage(ctx, userID, bookID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var readID sql.NullInt64
+		read, err := h.store.GetCurrentRead(ctx, db.GetCurrentReadParams{UserID: userID, BookID: bookID})
+		if err != nil && err != sql.ErrNoRows {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			readID = sql.NullInt64{Int64: read.ID, Valid: true}
+		}
+		session, err := h.store.StartReadingSession(ctx, db.StartReadingSessionParams{
+			UserID:    userID,
+			BookID:    bookID,
+			ReadID:    readID,
+			StartedAt: now,
+			StartPage: startPage,
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Reading session started",
+			Data:    toReadingSession(session, now),
+		})
+	}
+}
+
+// StopSession implements SessionHandler.
+// A page in the body is recorded as progress, total as with progress updates. Without one the
+// session ends at the last page progress was given at.
+func (h *sessionHandler) StopSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Page  *int64 `json:"page"`
+			Total int64  `json:"total"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		running, err := h.store.GetRunningSession(ctx, userID)
+		if err == sql.ErrNoRows || (err == nil && running.BookID != bookID) {
+			WriteJSONError(w, "No reading session is running for this book", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now().UTC()
+		var endPage sql.NullInt64
+		if req.Page != nil {
+			event, err := recordProgress(ctx, h.store, userID, bookID, ProgressUnitPages, *req.Page, req.Total, now)
+			var invalid progressError
+			if errors.As(err, &invalid) {
+				WriteJSONError(w, invalid.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := h.store.TouchUserBook(ctx, db.TouchUserBookParams{UserID: userID, BookID: bookID}); err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			endPage = sql.NullInt64{Int64: event.Value, Valid: true}
+		} else if endPage, err = h.latestPage(ctx, userID, bookID); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		session, err := h.store.StopReadingSession(ctx, db.StopReadingSessionParams{
+			EndedAt: sql.NullTime{Time: now, Valid: true},
+			EndPage: endPage,
+			ID:      running.ID,
+		})
+		if err == sql.ErrNoRows {
+			WriteJSONError(w, "No reading session is running for this book", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Reading session stopped",
+			Data:    toReadingSession(session, now),
+		})
+	}
+}
+
+// ListSessions implements SessionHandler.
+func (h *sessionHandler) ListSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		bookID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid book ID", http.StatusBadRequest)
+			return
+		}
+		rows, err := h.store.ListReadingSessions(r.Context(), db.ListReadingSessionsParams{UserID: userID, BookID: bookID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		now := time.Now().UTC()
+		sessions := []ReadingSession{}
+		for _, row := range rows {
+			sessions = append(sessions, toReadingSession(row, now))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Reading sessions retrieved successfully",
+			Data:    sessions,
+		})
+	}
+}
+
+// readingDay is the calendar date of t in loc, as midnight UTC so days can be counted across DST changes
+func readingDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// streaks counts runs of consecutive days with reading. The current streak holds through a day
+// the user hasn't read yet and ends once a whole day passes without reading.
+func streaks(times []time.Time, now time.Time, loc *time.Location) Streaks {
+	result := Streaks{Timezone: loc.String()}
+	days := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		days = append(days, readingDay(t, loc))
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	run := 0
+	var last time.Time
+	for _, day := range days {
+		switch {
+		case run > 0 && day.Equal(last):
+			continue
+		case run > 0 && day.Equal(last.AddDate(0, 0, 1)):
+			run++
+		default:
+			run = 1
+		}
+		last = day
+		result.Longest = max(result.Longest, run)
+	}
+	if run == 0 {
+		return result
+	}
+	today := readingDay(now, loc)
+	result.LastRead = last.Format(time.DateOnly)
+	result.ReadToday = last.Equal(today)
+	if result.ReadToday || last.Equal(today.AddDate(0, 0, -1)) {
+		result.Current = run
+	}
+	return result
+}
+
+// Streaks implements SessionHandler.
+// A day counts when the user finished a reading session or updated progress on it, in their timezone.
+func (h *sessionHandler) Streaks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		loc, err := userLocation(ctx, h.store, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		times, err := h.store.ListReadingTimes(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Streaks retrieved successfully",
+			Data:    streaks(times, time.Now(), loc),
+		})
+	}
+}
+
+func (s *Speed) add(pages int64, duration time.Duration) {
+	s.Sessions++
+	s.Pages += pages
+	s.Minutes += duration.Minutes()
+}
+
+func (s *Speed) finish() {
+	if s.Minutes > 0 {
+		s.PagesPerHour = math.Round(float64(s.Pages)/s.Minutes*60*10) / 10
+	}
+	s.Minutes = math.Round(s.Minutes*10) / 10
+}
+
+// ReadingSpeed implements SessionHandler.
+// Speed comes from finished sessions with the pages at both ends, genres are the books' tags.
+func (h *sessionHandler) ReadingSpeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		rows, err := h.store.ListSessionSpeeds(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var total Speed
+		genres := map[string]*GenreSpeed{}
+		for _, row := range rows {
+			duration := row.EndedAt.Time.Sub(row.StartedAt)
+			if duration < sessionSpeedMinimum || duration > sessionSpeedLimit {
+				continue
+			}
+			total.add(row.Pages, duration)
+			if row.Tags == "" {
+				continue
+			}
+			for _, tag := range strings.Split(row.Tags, "\x1f") {
+				key := strings.ToLower(tag)
+				if genres[key] == nil {
+					genres[key] = &GenreSpeed{Genre: tag}
+				}
+				genres[key].add(row.Pages, duration)
+			}
+		}
+		total.finish()
+		speed := ReadingSpeed{Speed: total, Genres: []GenreSpeed{}}
+		for _, genre := range genres {
+			genre.finish()
+			speed.Genres = append(speed.Genres, *genre)
+		}
+		sort.Slice(speed.Genres, func(i, j int) bool {
+			if speed.Genres[i].Sessions != speed.Genres[j].Sessions {
+				return speed.Genres[i].Sessions > speed.Genres[j].Sessions
+			}
+			return speed.Genres[i].Genre < speed.Genres[j].Genre
+		})
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Reading speed retrieved successfully",
+			Data:    speed,
+		})
+	}
+}
+
+// GetTimezone implements SessionHandler.
+func (h *sessionHandler) GetTimezone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		timezone, err := h.store.GetTimezone(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Timezone retrieved successfully",
+			Data:    map[string]string{"timezone": timezone},
+		})
+	}
+}
+
+// UpdateTimezone implements SessionHandler.
+// The timezone is an IANA name such as Europe/Berlin.
+func (h *sessionHandler) UpdateTimezone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		var req struct {
+			Timezone string `json:"timezone"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Timezone = strings.TrimSpace(req.Timezone)
+		// Local would be the server's timezone rather than the user's
+		if _, err := time.LoadLocation(req.Timezone); err != nil || req.Timezone == "" || req.Timezone == "Local" {
+			WriteValidationErrors(w, map[string]string{"timezone": "must be an IANA timezone such as Europe/Berlin"})
+			return
+		}
+		if err := h.store.UpdateTimezone(r.Context(), db.UpdateTimezoneParams{Timezone: req.Timezone, ID: userID}); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Timezone updated successfully",
+			Data:    map[string]string{"timezone": req.Timezone},
+		})
+	}
+}
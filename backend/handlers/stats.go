@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"booktrackr/db"
+)
+
+const (
+	statsTopAuthors = 10
+	statsReads      = 3
+	// statsRanges is how many date ranges are cached per user, a dashboard only asks for a few
+	statsRanges = 16
+)
+
+// the range statistics cover when from or to is left out
+var (
+	statsFrom = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	statsTo   = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+type StatsHandler interface {
+	Stats() http.HandlerFunc
+}
+
+type statsHandler struct {
+	store *db.Queries
+	cache *statsCache
+}
+
+func NewStatsHandler(store *db.Queries) StatsHandler {
+	return &statsHandler{store: store, cache: &statsCache{users: map[int64]cachedStats{}}}
+}
+
+type MonthStats struct {
+	Month string `json:"month"`
+	Books int64  `json:"books"`
+	Pages int64  `json:"pages"`
+}
+
+type RatingStats struct {
+	Rating int   `json:"rating"`
+	Books  int64 `json:"books"`
+}
+
+type AuthorStats struct {
+	Author string `json:"author"`
+	Books  int64  `json:"books"`
+	Pages  int64  `json:"pages"`
+}
+
+// ReadLength is a finished book and how many days it took
+type ReadLength struct {
+	BookID     int     `json:"book_id"`
+	Title      string  `json:"title"`
+	Author     string  `json:"author"`
+	StartDate  string  `json:"start_date"`
+	FinishDate string  `json:"finish_date"`
+	Days       float64 `json:"days"`
+}
+
+type WeekdayStats struct {
+	Weekday string `json:"weekday"`
+	Updates int64  `json:"updates"`
+}
+
+// Stats covers the books finished between From and To. Pages only count books whose page count
+// was given with progress. Weekdays come from progress updates.
+type Stats struct {
+	From                string         `json:"from,omitempty"`
+	To                  string         `json:"to,omitempty"`
+	Books               int64          `json:"books"`
+	Pages               int64          `json:"pages"`
+	AverageDaysToFinish float64        `json:"average_days_to_finish"`
+	Months              []MonthStats   `json:"months"`
+	Ratings             []RatingStats  `json:"ratings"`
+	TopAuthors          []AuthorStats  `json:"top_authors"`
+	LongestReads        []ReadLength   `json:"longest_reads"`
+	ShortestReads       []ReadLength   `json:"shortest_reads"`
+	Weekdays            []WeekdayStats `json:"weekdays"`
+	MostReadWeekday     string         `json:"most_read_weekday,omitempty"`
+}
+
+// statsCache keeps computed statistics per user for as long as their stats_version stays the same
+type statsCache struct {
+	mu    sync.Mutex
+	users map[int64]cachedStats
+}
+
+type cachedStats struct {
+	version int64
+	ranges  map[[2]time.Time]Stats
+}
+
+func (c *statsCache) get(userID, version int64, from, to time.Time) (Stats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.users[userID]
+	if !ok || cached.version != version {
+		return Stats{}, false
+	}
+	stats, ok := cached.ranges[[2]time.Time{from, to}]
+	return stats, ok
+}
+
+func (c *statsCache) put(userID, version int64, from, to time.Time, stats Stats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.users[userID]
+	if !ok || cached.version != version || len(cached.ranges) >= statsRanges {
+		cached = cachedStats{version: version, ranges: map[[2]time.Time]Stats{}}
+		c.users[userID] = cached
+	}
+	cached.ranges[[2]time.Time{from, to}] = stats
+}
+
+// parseStatsDate reads from or to, a date on its own for to takes in the whole day
+func parseStatsDate(value string, end bool) (time.Time, error) {
+	t, err := parseDate(value)
+	if err != nil {
+		return t, err
+	}
+	if end && len(value) == len(time.DateOnly) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.UTC(), nil
+}
+
+func toReadLength(bookID int64, title, author string, startDate, finishDate time.Time, days float64) ReadLength {
+	return ReadLength{
+		BookID:     int(bookID),
+		Title:      title,
+		Author:     author,
+		StartDate:  startDate.String(),
+		FinishDate: finishDate.String(),
+		Days:       math.Round(days*10) / 10,
+	}
+}
+
+// compute runs the aggregates over the user's library
+func (h *statsHandler) compute(ctx context.Context, userID int64, from, to time.Time) (Stats, error) {
+	fromTime := from.Format(time.DateTime)
+	toTime := to.Format(time.DateTime)
+	stats := Stats{
+		Months:        []MonthStats{},
+		Ratings:       []RatingStats{},
+		TopAuthors:    []AuthorStats{},
+		LongestReads:  []ReadLength{},
+		ShortestReads: []ReadLength{},
+		Weekdays:      []WeekdayStats{},
+	}
+
+	summary, err := h.store.GetStatsSummary(ctx, db.GetStatsSummaryParams{UserID: userID, FromTime: fromTime, ToTime: toTime})
+	if err != nil {
+		return stats, err
+	}
+	stats.Books = summary.Books
+	stats.Pages = summary.Pages
+	stats.AverageDaysToFinish = math.Round(summary.AverageDays*10) / 10
+
+	months, err := h.store.ListStatsByMonth(ctx, db.ListStatsByMonthParams{UserID: userID, FromTime: fromTime, ToTime: toTime})
+	if err != nil {
+		return stats, err
+	}
+	for _, month := range months {
+		stats.Months = append(stats.Months, MonthStats{Month: month.Month, Books: month.Books, Pages: month.Pages})
+	}
+
+	// every rating is in the histogram, the ones no book got with 0
+	ratings, err := h.store.ListStatsRatings(ctx, db.ListStatsRatingsParams{UserID: userID, FromTime: fromTime, ToTime: toTime})
+	if err != nil {
+		return stats, err
+	}
+	counts := map[int64]int64{}
+	for _, rating := range ratings {
+		counts[rating.Rating.Int64] = rating.Books
+	}
+	for rating := int64(1); rating <= 5; rating++ {
+		stats.Ratings = append(stats.Ratings, RatingStats{Rating: int(rating), Books: counts[rating]})
+	}
+
+	authors, err := h.store.ListStatsTopAuthors(ctx, db.ListStatsTopAuthorsParams{UserID: userID, FromTime: fromTime, ToTime: toTime, Limit: statsTopAuthors})
+	if err != nil {
+		return stats, err
+	}
+	for _, author := range authors {
+		stats.TopAuthors = append(stats.TopAuthors, AuthorStats{Author: author.Author, Books: author.Books, Pages: author.Pages})
+	}
+
+	longest, err := h.store.ListStatsLongestReads(ctx, db.ListStatsLongestReadsParams{UserID: userID, FromTime: fromTime, ToTime: toTime, Limit: statsReads})
+	if err != nil {
+		return stats, err
+	}
+	for _, read := range longest {
+		stats.LongestReads = append(stats.LongestReads, toReadLength(read.BookID, read.Title, read.Author, read.StartDate.Time, read.FinishDate.Time, read.Days))
+	}
+	shortest, err := h.store.ListStatsShortestReads(ctx, db.ListStatsShortestReadsParams{UserID: userID, FromTime: fromTime, ToTime: toTime, Limit: statsReads})
+	if err != nil {
+		return stats, err
+	}
+	for _, read := range shortest {
+		stats.ShortestReads = append(stats.ShortestReads, toReadLength(read.BookID, read.Title, read.Author, read.StartDate.Time, read.FinishDate.Time, read.Days))
+	}
+
+	weekdays, err := h.store.ListStatsWeekdays(ctx, db.ListStatsWeekdaysParams{UserID: userID, FromTime: fromTime, ToTime: toTime})
+	if err != nil {
+		return stats, err
+	}
+	updates := map[int64]int64{}
+	for _, weekday := range weekdays {
+		updates[weekday.Weekday] = weekday.Updates
+	}
+	var most int64
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		count := updates[int64(weekday)]
+		stats.Weekdays = append(stats.Weekdays, WeekdayStats{Weekday: weekday.String(), Updates: count})
+		if count > most {
+			most = count
+			stats.MostReadWeekday = weekday.String()
+		}
+	}
+	return stats, nil
+}
+
+// Stats implements StatsHandler.
+// ?from= and ?to= are RFC 3339 timestamps or dates, to a date includes that day. Without them
+// the statistics cover the whole library. Results are cached until the user's reading changes.
+func (h *statsHandler) Stats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		query := r.URL.Query()
+		from, to := statsFrom, statsTo
+		fields := map[string]string{}
+		if value := query.Get("from"); value != "" {
+			t, err := parseStatsDate(value, false)
+			if err != nil {
+				fields["from"] = "must be an RFC 3339 timestamp or a date"
+			}
+			from = t
+		}
+		if value := query.Get("to"); value != "" {
+			t, err := parseStatsDate(value, true)
+			if err != nil {
+				fields["to"] = "must be an RFC 3339 timestamp or a date"
+			}
+			to = t
+		}
+		if len(fields) == 0 && !from.Before(to) {
+			fields["to"] = "must be after from"
+		}
+		if len(fields) > 0 {
+			WriteValidationErrors(w, fields)
+			return
+		}
+
+		version, err := h.store.GetStatsVersion(ctx, userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		stats, ok := h.cache.get(userID, version, from, to)
+		if !ok {
+			stats, err = h.compute(ctx, userID, from, to)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if query.Get("from") != "" {
+				stats.From = from.String()
+			}
+			if query.Get("to") != "" {
+				stats.To = to.String()
+			}
+			h.cache.put(userID, version, from, to, stats)
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Statistics retrieved successfully",
+			Data:    stats,
+		})
+	}
+}
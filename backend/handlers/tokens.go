@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"booktrackr/auth"
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+type TokenHandler interface {
+	CreateToken() http.HandlerFunc
+	ListTokens() http.HandlerFunc
+	DeleteToken() http.HandlerFunc
+}
+
+type tokenHandler struct {
+	store *db.Queries
+}
+
+func NewTokenHandler(store *db.Queries) TokenHandler {
+	return &tokenHandler{store: store}
+}
+
+// AccessToken describes a personal access token, Token is only filled in when it is created
+type AccessToken struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Token      string `json:"token,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+}
+
+func toAccessToken(token db.AccessToken) AccessToken {
+	result := AccessToken{
+		ID:        int(token.ID),
+		Name:      token.Name,
+		CreatedAt: token.CreatedAt.String(),
+	}
+	if token.LastUsedAt.Valid {
+		result.LastUsedAt = token.LastUsedAt.Time.String()
+	}
+	return result
+}
+
+// CreateToken implements TokenHandler.
+func (h *tokenHandler) CreateToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Name) > 100 {
+			WriteValidationErrors(w, map[string]string{"name": "name is required and at most 100 characters"})
+			return
+		}
+
+		token, err := auth.GenerateAccessToken()
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		created, err := h.store.CreateAccessToken(ctx, db.CreateAccessTokenParams{
+			UserID:    userID,
+			Name:      req.Name,
+			TokenHash: auth.HashAccessToken(token),
+		})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info("Created access token %d for user %d", created.ID, userID)
+
+		result := toAccessToken(created)
+		result.Token = token
+		WriteJSON(w, http.StatusCreated, JSONResponse{
+			Message: "Access token created, it will not be shown again",
+			Data:    result,
+		})
+	}
+}
+
+// ListTokens implements TokenHandler.
+func (h *tokenHandler) ListTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		tokens, err := h.store.ListAccessTokens(r.Context(), userID)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result := []AccessToken{}
+		for _, token := range tokens {
+			result = append(result, toAccessToken(token))
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Access tokens retrieved successfully",
+			Data:    result,
+		})
+	}
+}
+
+// DeleteToken implements TokenHandler.
+func (h *tokenHandler) DeleteToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		tokenID, err := pathID(r, "id")
+		if err != nil {
+			WriteJSONError(w, "Invalid token ID", http.StatusBadRequest)
+			return
+		}
+		deleted, err := h.store.DeleteAccessToken(r.Context(), db.DeleteAccessTokenParams{ID: tokenID, UserID: userID})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			WriteJSONError(w, "Access token not found", http.StatusNotFound)
+			return
+		}
+		WriteJSON(w, http.StatusOK, JSONResponse{
+			Message: "Access token revoked",
+		})
+	}
+}
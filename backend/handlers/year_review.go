@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"booktrackr/config"
+	"booktrackr/db"
+	log "booktrackr/logging"
+	"booktrackr/pkg/covers"
+	"booktrackr/pkg/yearreview"
+)
+
+// coverFetches is how many covers a report downloads at once
+const coverFetches = 4
+
+type YearReviewHandler interface {
+	GetYearReview() http.HandlerFunc
+	UpdateYearReview() http.HandlerFunc
+	Report() http.HandlerFunc
+}
+
+type yearReviewHandler struct {
+	store  *db.Queries
+	covers *covers.Cache
+}
+
+func NewYearReviewHandler(store *db.Queries) YearReviewHandler {
+	return &yearReviewHandler{store: store, covers: covers.NewCache(config.COVER_CACHE_DIR)}
+}
+
+// YearReview is the summary of a report and where it is served
+type YearReview struct {
+	Year    int               `json:"year"`
+	Public  bool              `json:"public"`
+	Books   int               `json:"books"`
+	Pages   int64             `json:"pages"`
+	Authors int               `json:"authors"`
+	URLs    map[string]string `json:"urls"`
+}
+
+func yearReviewURL(r *http.Request, username string, year int, format string) string {
+	return fmt.Sprintf("%s/users/%s/year-in-review/%d.%s", requestOrigin(r), url.PathEscape(username), year, format)
+}
+
+// report gathers the books of the year, with covers only when they are drawn. Only the owner's
+// report has the books they didn't make public.
+func (h *yearReviewHandler) report(ctx context.Context, r *http.Request, userID int64, username string, year int, owner, withCovers bool) (yearreview.Report, error) {
+	rows, err := h.store.ListYearInReviewBooks(ctx, db.ListYearInReviewBooksParams{UserID: userID, Year: int64(year), Owner: owner})
+	if err != nil {
+		return yearreview.Report{}, err
+	}
+	report := yearreview.Report{
+		Username: username,
+		Year:     year,
+		Books:    make([]yearreview.Book, len(rows)),
+		Links: yearreview.Links{
+			Page: yearReviewURL(r, username, year, "html"),
+			SVG:  yearReviewURL(r, username, year, "svg"),
+			PNG:  yearReviewURL(r, username, year, "png"),
+		},
+	}
+	for i, row := range rows {
+		report.Books[i] = yearreview.Book{
+			Title:    row.Title,
+			Author:   row.Author,
+			Rating:   row.Rating.Int64,
+			Pages:    row.Pages,
+			Finished: row.FinishDate.Time,
+		}
+	}
+	if !withCovers {
+		return report, nil
+	}
+
+	// a cover that can't be fetched is drawn as a placeholder rather than failing the report
+	var wg sync.WaitGroup
+	fetches := make(chan struct{}, coverFetches)
+	for i, row := range rows {
+		if row.ImageUrl == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetches <- struct{}{}
+			defer func() { <-fetches }()
+			cover, err := h.covers.Get(ctx, row.ImageUrl)
+			if err != nil {
+				log.Info("year in review: no cover for book %d: %v", row.BookID, err)
+				return
+			}
+			report.Books[i].Cover = cover
+		}()
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// GetYearReview implements YearReviewHandler.
+func (h *yearReviewHandler) GetYearReview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		year, ok := parseYear(r.PathValue("year"))
+		if !ok {
+			WriteJSONError(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		h.writeYearReview(ctx, w, r, userID, year, "Year in review retrieved successfully")
+	}
+}
+
+// UpdateYearReview implements YearReviewHandler.
+// Setting public shares the report of the year with anyone who has its URL.
+func (h *yearReviewHandler) UpdateYearReview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := GetUserID(r.Context())
+		ctx := r.Context()
+		year, ok := parseYear(r.PathValue("year"))
+		if !ok {
+			WriteJSONError(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Public bool `json:"public"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err := h.store.SetYearReviewPublic(ctx, db.SetYearReviewPublicParams{UserID: userID, Year: int64(year), Public: req.Public})
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.writeYearReview(ctx, w, r, userID, year, "Year in review updated successfully")
+	}
+}
+
+func (h *yearReviewHandler) writeYearReview(ctx context.Context, w http.ResponseWriter, r *http.Request, userID int64, year int, message string) {
+	user, err := h.store.GetUserByID(ctx, userID)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	settings, err := h.store.GetYearReviewSettings(ctx, db.GetYearReviewSettingsParams{Year: int64(year), Username: user.Username})
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report, err := h.report(ctx, r, userID, user.Username, year, true, false)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	review := YearReview{
+		Year:    year,
+		Public:  settings.Public,
+		Books:   len(report.Books),
+		Pages:   report.Pages(),
+		Authors: report.Authors(),
+		URLs:    map[string]string{},
+	}
+	for _, format := range yearreview.Names() {
+		review.URLs[format] = yearReviewURL(r, user.Username, year, format)
+	}
+	WriteJSON(w, http.StatusOK, JSONResponse{Message: message, Data: review})
+}
+
+// Report implements YearReviewHandler.
+// The report is served at /users/{username}/year-in-review/{year}.{svg,png,html}. The owner can
+// always see it, anyone else only once the year is public, until then it is a 404.
+func (h *yearReviewHandler) Report() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name, extension, _ := strings.Cut(r.PathValue("file"), ".")
+		format, ok := yearreview.Lookup(extension)
+		if !ok {
+			WriteJSONError(w, "Year in review not found", http.StatusNotFound)
+			return
+		}
+		year, ok := parseYear(name)
+		if name == "" || !ok {
+			WriteJSONError(w, "Year in review not found", http.StatusNotFound)
+			return
+		}
+		settings, err := h.store.GetYearReviewSettings(ctx, db.GetYearReviewSettingsParams{Year: int64(year), Username: r.PathValue("username")})
+		if err == sql.ErrNoRows || (err == nil && !settings.Public && GetViewerID(ctx) != settings.ID) {
+			WriteJSONError(w, "Year in review not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		owner := GetViewerID(ctx) == settings.ID
+		report, err := h.report(ctx, r, settings.ID, settings.Username, year, owner, true)
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var body bytes.Buffer
+		if err := format.Write(&body, report); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256(body.Bytes())
+		etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+
+		w.Header().Set("ETag", etag)
+		// the owner's report has their other books, it must not end up in a shared cache
+		if settings.Public && !owner {
+			w.Header().Set("Cache-Control", "public, max-age=300")
+		} else {
+			w.Header().Set("Cache-Control", "private, no-cache")
+		}
+		if header := r.Header.Get("If-None-Match"); header != "" && matchesIfMatch(header, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", format.ContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
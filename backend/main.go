@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	_ "time/tzdata" // users pick their timezone by name, the server may not have a zoneinfo database
 
 	"booktrackr/auth"
 	"booktrackr/db"
 	"booktrackr/handlers"
+	"booktrackr/migrations"
+	"booktrackr/pkg/recommend"
 
 	"github.com/dghubble/gologin/v2"
 	"github.com/dghubble/gologin/v2/google"
@@ -35,8 +41,33 @@ func main() {
 		log.Fatalf("failed to create schema: %v", err)
 	}
 
+	if err := migrations.Apply(conn); err != nil {
+		log.Fatalf("failed to migrate schema: %v", err)
+	}
+
 	store := db.New(conn)
-	bh := handlers.NewBookHandler(store)
+
+	// background work runs until the server is stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go recommend.NewRefresher(conn, store).Run(ctx)
+	bh := handlers.NewBookHandler(conn, store)
+	ih := handlers.NewImportHandler(conn, store)
+	eh := handlers.NewExportHandler(store)
+	th := handlers.NewTokenHandler(store)
+	oh := handlers.NewOPDSHandler(store)
+	fh := handlers.NewFeedHandler(store)
+	ph := handlers.NewProfileHandler(store)
+	flh := handlers.NewFollowHandler(store)
+	ch := handlers.NewClubHandler(conn, store)
+	rh := handlers.NewReviewHandler(store)
+	nh := handlers.NewNotificationHandler(store)
+	gh := handlers.NewGoalHandler(store)
+	sh := handlers.NewStatsHandler(store)
+	yh := handlers.NewYearReviewHandler(store)
+	ssh := handlers.NewSessionHandler(store)
+	rch := handlers.NewRecommendationHandler(store)
+	srh := handlers.NewSeriesHandler(store)
 
 	mux := http.NewServeMux()
 
@@ -63,13 +94,128 @@ func main() {
 	mux.HandleFunc("GET /user/books", handlers.AuthMiddleware(bh.ListUserBooks()))
 	mux.HandleFunc("GET /user/books/{id}", handlers.AuthMiddleware(bh.GetBookByUserID()))
 	mux.HandleFunc("PUT /user/books/{id}", handlers.AuthMiddleware(bh.UpdateUserBook()))
+	mux.HandleFunc("PATCH /user/books/{id}", handlers.AuthMiddleware(bh.PatchUserBook()))
+	mux.HandleFunc("GET /user/books/{id}/reads", handlers.AuthMiddleware(bh.ListReads()))
+	mux.HandleFunc("POST /user/books/{id}/reads", handlers.AuthMiddleware(bh.StartRead()))
+	mux.HandleFunc("PUT /user/books/{id}/reads/{readID}", handlers.AuthMiddleware(bh.UpdateRead()))
+	mux.HandleFunc("GET /user/books/{id}/progress", handlers.AuthMiddleware(bh.ListProgress()))
+	mux.HandleFunc("POST /user/books/{id}/progress", handlers.AuthMiddleware(bh.RecordProgress()))
+	mux.HandleFunc("GET /user/books/{id}/sessions", handlers.AuthMiddleware(ssh.ListSessions()))
+	mux.HandleFunc("POST /user/books/{id}/sessions/start", handlers.AuthMiddleware(ssh.StartSession()))
+	mux.HandleFunc("POST /user/books/{id}/sessions/stop", handlers.AuthMiddleware(ssh.StopSession()))
+	mux.HandleFunc("PUT /user/books/{id}/series", handlers.AuthMiddleware(srh.SetBookSeries()))
+	mux.HandleFunc("DELETE /user/books/{id}/series/{seriesID}", handlers.AuthMiddleware(srh.RemoveBookSeries()))
+	mux.HandleFunc("GET /user/books/{id}/annotations", handlers.AuthMiddleware(bh.ListAnnotations()))
+	mux.HandleFunc("POST /user/books/{id}/annotations", handlers.AuthMiddleware(bh.CreateAnnotation()))
+	mux.HandleFunc("GET /user/books/{id}/annotations/{annotationID}", handlers.AuthMiddleware(bh.GetAnnotation()))
+	mux.HandleFunc("PUT /user/books/{id}/annotations/{annotationID}", handlers.AuthMiddleware(bh.UpdateAnnotation()))
+	mux.HandleFunc("DELETE /user/books/{id}/annotations/{annotationID}", handlers.AuthMiddleware(bh.DeleteAnnotation()))
+	mux.HandleFunc("GET /user/quotes", handlers.AuthMiddleware(bh.ListQuotes()))
+	mux.HandleFunc("GET /user/quotes/random", handlers.AuthMiddleware(bh.RandomQuote()))
+	mux.HandleFunc("GET /user/search", handlers.AuthMiddleware(bh.SearchLibrary()))
+	mux.HandleFunc("POST /user/import/{format}", handlers.AuthMiddleware(ih.Import()))
+	mux.HandleFunc("POST /user/import/kindle", handlers.AuthMiddleware(ih.ImportKindleClippings()))
+	mux.HandleFunc("POST /user/import/calibre", handlers.AuthMiddleware(ih.ImportCalibre()))
+	mux.HandleFunc("GET /user/import/jobs/{id}", handlers.AuthMiddleware(ih.GetImportJob()))
+	mux.HandleFunc("GET /user/export/library", handlers.AuthMiddleware(eh.ExportLibrary()))
+	mux.HandleFunc("POST /user/tokens", handlers.AuthMiddleware(th.CreateToken()))
+	mux.HandleFunc("GET /user/tokens", handlers.AuthMiddleware(th.ListTokens()))
+	mux.HandleFunc("DELETE /user/tokens/{id}", handlers.AuthMiddleware(th.DeleteToken()))
+	mux.HandleFunc("GET /user/settings/feed", handlers.AuthMiddleware(fh.GetFeedSettings()))
+	mux.HandleFunc("PUT /user/settings/feed", handlers.AuthMiddleware(fh.UpdateFeedSettings()))
+	mux.HandleFunc("GET /user/settings/timezone", handlers.AuthMiddleware(ssh.GetTimezone()))
+	mux.HandleFunc("PUT /user/settings/timezone", handlers.AuthMiddleware(ssh.UpdateTimezone()))
+	mux.HandleFunc("GET /user/profile", handlers.AuthMiddleware(ph.GetProfile()))
+	mux.HandleFunc("PUT /user/profile", handlers.AuthMiddleware(ph.UpdateProfile()))
+	mux.HandleFunc("GET /user/followers", handlers.AuthMiddleware(flh.ListFollowers()))
+	mux.HandleFunc("POST /user/followers/{username}/approve", handlers.AuthMiddleware(flh.ApproveFollower()))
+	mux.HandleFunc("DELETE /user/followers/{username}", handlers.AuthMiddleware(flh.RemoveFollower()))
+	mux.HandleFunc("GET /user/following", handlers.AuthMiddleware(flh.ListFollowing()))
+	mux.HandleFunc("GET /user/feed", handlers.AuthMiddleware(flh.Timeline()))
+	mux.HandleFunc("POST /users/{username}/follow", handlers.AuthMiddleware(flh.Follow()))
+	mux.HandleFunc("DELETE /users/{username}/follow", handlers.AuthMiddleware(flh.Unfollow()))
+	mux.HandleFunc("GET /user/streaks", handlers.AuthMiddleware(ssh.Streaks()))
+	mux.HandleFunc("GET /user/reading-speed", handlers.AuthMiddleware(ssh.ReadingSpeed()))
+	mux.HandleFunc("GET /user/stats", handlers.AuthMiddleware(sh.Stats()))
+	mux.HandleFunc("GET /user/recommendations", handlers.AuthMiddleware(rch.ListRecommendations()))
+	mux.HandleFunc("GET /user/series/continue", handlers.AuthMiddleware(srh.ContinueSeries()))
+	mux.HandleFunc("GET /user/series/{id}", handlers.AuthMiddleware(srh.GetSeries()))
+	mux.HandleFunc("GET /user/year-in-review/{year}", handlers.AuthMiddleware(yh.GetYearReview()))
+	mux.HandleFunc("PUT /user/year-in-review/{year}", handlers.AuthMiddleware(yh.UpdateYearReview()))
+	mux.HandleFunc("GET /user/goals", handlers.AuthMiddleware(gh.ListGoals()))
+	mux.HandleFunc("PUT /user/goals/{year}/{unit}", handlers.AuthMiddleware(gh.SetGoal()))
+	mux.HandleFunc("DELETE /user/goals/{year}/{unit}", handlers.AuthMiddleware(gh.DeleteGoal()))
+	mux.HandleFunc("GET /user/challenges", handlers.AuthMiddleware(gh.ListChallenges()))
+	mux.HandleFunc("POST /user/challenges", handlers.AuthMiddleware(gh.CreateChallenge()))
+	mux.HandleFunc("GET /user/challenges/{id}", handlers.AuthMiddleware(gh.GetChallenge()))
+	mux.HandleFunc("PUT /user/challenges/{id}", handlers.AuthMiddleware(gh.UpdateChallenge()))
+	mux.HandleFunc("DELETE /user/challenges/{id}", handlers.AuthMiddleware(gh.DeleteChallenge()))
+	mux.HandleFunc("GET /user/notifications", handlers.AuthMiddleware(nh.ListNotifications()))
+	mux.HandleFunc("POST /user/notifications/read", handlers.AuthMiddleware(nh.MarkNotificationsRead()))
+	mux.HandleFunc("POST /users/{username}/books/{id}/review/comments", handlers.AuthMiddleware(rh.CreateComment()))
+	mux.HandleFunc("PUT /users/{username}/books/{id}/review/comments/{commentID}", handlers.AuthMiddleware(rh.UpdateComment()))
+	mux.HandleFunc("DELETE /users/{username}/books/{id}/review/comments/{commentID}", handlers.AuthMiddleware(rh.DeleteComment()))
+	mux.HandleFunc("POST /users/{username}/books/{id}/review/comments/{commentID}/report", handlers.AuthMiddleware(rh.ReportComment()))
+	mux.HandleFunc("PUT /users/{username}/books/{id}/review/reactions/{reaction}", handlers.AuthMiddleware(rh.AddReaction()))
+	mux.HandleFunc("DELETE /users/{username}/books/{id}/review/reactions/{reaction}", handlers.AuthMiddleware(rh.RemoveReaction()))
+	mux.HandleFunc("POST /clubs", handlers.AuthMiddleware(ch.CreateClub()))
+	mux.HandleFunc("GET /clubs", handlers.AuthMiddleware(ch.ListClubs()))
+	mux.HandleFunc("GET /clubs/{id}", handlers.AuthMiddleware(ch.GetClub()))
+	mux.HandleFunc("PUT /clubs/{id}", handlers.AuthMiddleware(ch.UpdateClub()))
+	mux.HandleFunc("DELETE /clubs/{id}", handlers.AuthMiddleware(ch.DeleteClub()))
+	mux.HandleFunc("PUT /clubs/{id}/book", handlers.AuthMiddleware(ch.SetBook()))
+	mux.HandleFunc("GET /clubs/{id}/members", handlers.AuthMiddleware(ch.ListMembers()))
+	mux.HandleFunc("POST /clubs/{id}/members", handlers.AuthMiddleware(ch.AddMember()))
+	mux.HandleFunc("DELETE /clubs/{id}/members/{username}", handlers.AuthMiddleware(ch.RemoveMember()))
+	mux.HandleFunc("GET /user/club-invites", handlers.AuthMiddleware(ch.ListInvites()))
+	mux.HandleFunc("POST /user/club-invites/{id}/accept", handlers.AuthMiddleware(ch.AcceptInvite()))
+	mux.HandleFunc("DELETE /user/club-invites/{id}", handlers.AuthMiddleware(ch.DeclineInvite()))
+	mux.HandleFunc("POST /clubs/{id}/sections", handlers.AuthMiddleware(ch.CreateSection()))
+	mux.HandleFunc("PUT /clubs/{id}/sections/{sectionID}", handlers.AuthMiddleware(ch.UpdateSection()))
+	mux.HandleFunc("DELETE /clubs/{id}/sections/{sectionID}", handlers.AuthMiddleware(ch.DeleteSection()))
+	mux.HandleFunc("GET /clubs/{id}/sections/{sectionID}/posts", handlers.AuthMiddleware(ch.ListPosts()))
+	mux.HandleFunc("POST /clubs/{id}/sections/{sectionID}/posts", handlers.AuthMiddleware(ch.CreatePost()))
+	mux.HandleFunc("DELETE /clubs/{id}/sections/{sectionID}/posts/{postID}", handlers.AuthMiddleware(ch.DeletePost()))
+
+	// profiles are public, a signed in viewer may be shown more depending on the owner's settings
+	mux.HandleFunc("GET /users/{username}", handlers.OptionalAuthMiddleware(ph.GetPublicProfile()))
+	mux.HandleFunc("GET /users/{username}/books", handlers.OptionalAuthMiddleware(ph.ListPublicBooks()))
+	mux.HandleFunc("GET /users/{username}/books/{id}/review", handlers.OptionalAuthMiddleware(rh.GetReview()))
+	mux.HandleFunc("GET /users/{username}/books/{id}/review/comments", handlers.OptionalAuthMiddleware(rh.ListComments()))
+	// year in review reports, {file} is the year and a format like 2025.png, shared once the user makes the year public
+	mux.HandleFunc("GET /users/{username}/year-in-review/{file}", handlers.OptionalAuthMiddleware(yh.Report()))
+	// moderation, admins are made by setting users.is_admin in the database
+	mux.HandleFunc("GET /admin/comments/reported", handlers.AuthMiddleware(handlers.AdminMiddleware(store, rh.ListReportedComments())))
+	mux.HandleFunc("POST /admin/comments/{commentID}/hide", handlers.AuthMiddleware(handlers.AdminMiddleware(store, rh.HideComment())))
+	mux.HandleFunc("POST /admin/comments/{commentID}/restore", handlers.AuthMiddleware(handlers.AdminMiddleware(store, rh.RestoreComment())))
+
+	// activity feeds are public, for feed readers, once a user with a public profile turns them on
+	mux.HandleFunc("GET /users/{username}/feed.atom", fh.UserFeed("atom"))
+	mux.HandleFunc("GET /users/{username}/feed.rss", fh.UserFeed("rss"))
+	mux.HandleFunc("GET /users/{username}/feed.json", fh.UserFeed("json"))
+
+	// the OPDS catalog is read by e-reader apps, which authenticate with a personal access token
+	mux.HandleFunc("GET /opds/{$}", handlers.TokenAuthMiddleware(store, oh.Root()))
+	mux.HandleFunc("GET /opds/shelves", handlers.TokenAuthMiddleware(store, oh.Shelves()))
+	mux.HandleFunc("GET /opds/shelves/{shelf}", handlers.TokenAuthMiddleware(store, oh.Shelf()))
+	mux.HandleFunc("GET /opds/status/{status}", handlers.TokenAuthMiddleware(store, oh.Status()))
+	mux.HandleFunc("GET /opds/all", handlers.TokenAuthMiddleware(store, oh.All()))
+	mux.HandleFunc("GET /opds/search", handlers.TokenAuthMiddleware(store, oh.Search()))
+	mux.HandleFunc("GET /opds/opensearch.xml", handlers.TokenAuthMiddleware(store, oh.OpenSearch()))
 
 	fmt.Println("Server running at http://localhost:8080")
 	handler := handlers.WithCORS(mux)
 
 	// frontend based
 	mux.HandleFunc("/", spaHandler("../frontend/dist"))
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	server := &http.Server{Addr: ":8080", Handler: handler}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
 }
 
 func spaHandler(distPath string) http.HandlerFunc {
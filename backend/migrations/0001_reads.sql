@@ -0,0 +1,26 @@
+-- every reading of a book gets its own row, user_books stays the ownership record
+CREATE TABLE IF NOT EXISTS reads (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    start_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finish_date TIMESTAMP,
+    status TEXT NOT NULL DEFAULT 'reading',
+    rating INTEGER,
+    review TEXT,
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reads_user_book ON reads(user_id, book_id);
+
+-- carry over the single read each library entry had before
+INSERT INTO reads (user_id, book_id, start_date, finish_date, status, rating, review)
+SELECT
+    user_id,
+    book_id,
+    start_date,
+    finish_date,
+    CASE WHEN finish_date IS NOT NULL THEN 'finished' ELSE 'reading' END,
+    rating,
+    review
+FROM user_books;
@@ -0,0 +1,29 @@
+-- progress is kept as a history of updates, the latest one is the current progress
+CREATE TABLE IF NOT EXISTS progress_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    read_id INTEGER,
+    unit TEXT NOT NULL,
+    value INTEGER NOT NULL,
+    total INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id),
+    FOREIGN KEY (read_id) REFERENCES reads(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_progress_events_user_book ON progress_events(user_id, book_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_progress_events_read ON progress_events(read_id, created_at);
+
+-- the old progress column was a percentage
+INSERT INTO progress_events (user_id, book_id, read_id, unit, value, total, created_at)
+SELECT
+    ub.user_id,
+    ub.book_id,
+    (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id),
+    'percent',
+    ub.progress,
+    100,
+    COALESCE(ub.start_date, CURRENT_TIMESTAMP)
+FROM user_books ub
+WHERE ub.progress > 0;
@@ -0,0 +1,5 @@
+-- version backs the ETag on a library entry, every write bumps it
+ALTER TABLE user_books ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_books ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE user_books SET updated_at = CURRENT_TIMESTAMP;
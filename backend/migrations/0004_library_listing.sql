@@ -0,0 +1,11 @@
+-- start_date moves with re-reads, so when a book joined the library is kept separately
+ALTER TABLE user_books ADD COLUMN added_at TIMESTAMP;
+
+UPDATE user_books SET added_at = COALESCE(start_date, updated_at, CURRENT_TIMESTAMP);
+
+-- indexes behind the sort orders and filters of the library listing
+CREATE INDEX IF NOT EXISTS idx_user_books_added ON user_books(user_id, added_at, book_id);
+CREATE INDEX IF NOT EXISTS idx_user_books_finished ON user_books(user_id, finish_date, book_id);
+CREATE INDEX IF NOT EXISTS idx_user_books_rating ON user_books(user_id, rating, book_id);
+CREATE INDEX IF NOT EXISTS idx_books_title ON books(title COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_books_author ON books(author COLLATE NOCASE);
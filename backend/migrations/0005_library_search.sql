@@ -0,0 +1,62 @@
+-- full-text index over each library entry: the book's metadata plus that user's own text.
+-- rowid is user_id * 2^32 + book_id so a user's rows are one contiguous rowid range.
+-- needs the sqlite_fts5 build tag, see the Makefile
+CREATE VIRTUAL TABLE IF NOT EXISTS library_search USING fts5(
+    title,
+    author,
+    description,
+    review,
+    notes,
+    user_id UNINDEXED,
+    book_id UNINDEXED,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO library_search (rowid, title, author, description, review, notes, user_id, book_id)
+SELECT
+    ub.user_id * 4294967296 + ub.book_id,
+    b.title,
+    b.author,
+    b.description,
+    COALESCE((SELECT group_concat(r.review, ' ') FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id), ub.review, ''),
+    '',
+    ub.user_id,
+    ub.book_id
+FROM user_books ub
+JOIN books b ON b.id = ub.book_id;
+
+CREATE TRIGGER IF NOT EXISTS library_search_user_books_insert AFTER INSERT ON user_books BEGIN
+    INSERT INTO library_search (rowid, title, author, description, review, notes, user_id, book_id)
+    SELECT NEW.user_id * 4294967296 + NEW.book_id, b.title, b.author, b.description, COALESCE(NEW.review, ''), '', NEW.user_id, NEW.book_id
+    FROM books b
+    WHERE b.id = NEW.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_user_books_delete AFTER DELETE ON user_books BEGIN
+    DELETE FROM library_search WHERE rowid = OLD.user_id * 4294967296 + OLD.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_books_update AFTER UPDATE OF title, author, description ON books BEGIN
+    UPDATE library_search
+    SET title = NEW.title, author = NEW.author, description = NEW.description
+    WHERE rowid IN (SELECT ub.user_id * 4294967296 + ub.book_id FROM user_books ub WHERE ub.book_id = NEW.id);
+END;
+
+-- reviews live on each read, the index holds all of them
+CREATE TRIGGER IF NOT EXISTS library_search_reads_insert AFTER INSERT ON reads BEGIN
+    UPDATE library_search
+    SET review = COALESCE((SELECT group_concat(r.review, ' ') FROM reads r WHERE r.user_id = NEW.user_id AND r.book_id = NEW.book_id), '')
+    WHERE rowid = NEW.user_id * 4294967296 + NEW.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_reads_update AFTER UPDATE OF review ON reads BEGIN
+    UPDATE library_search
+    SET review = COALESCE((SELECT group_concat(r.review, ' ') FROM reads r WHERE r.user_id = NEW.user_id AND r.book_id = NEW.book_id), '')
+    WHERE rowid = NEW.user_id * 4294967296 + NEW.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_reads_delete AFTER DELETE ON reads BEGIN
+    UPDATE library_search
+    SET review = COALESCE((SELECT group_concat(r.review, ' ') FROM reads r WHERE r.user_id = OLD.user_id AND r.book_id = OLD.book_id), '')
+    WHERE rowid = OLD.user_id * 4294967296 + OLD.book_id;
+END;
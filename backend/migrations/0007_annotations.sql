@@ -0,0 +1,18 @@
+-- highlights, notes and bookmarks kept against a library entry
+CREATE TABLE IF NOT EXISTS annotations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    text TEXT NOT NULL DEFAULT '',
+    page INTEGER,
+    location TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- imported annotations remember where they came from so a re-import adds nothing twice
+    source TEXT NOT NULL DEFAULT '',
+    source_key TEXT,
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_user_book ON annotations(user_id, book_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_annotations_source_key ON annotations(user_id, book_id, source_key);
@@ -0,0 +1,35 @@
+-- annotations can be written by hand as well as imported: quotes, highlights and notes
+ALTER TABLE annotations ADD COLUMN chapter TEXT;
+ALTER TABLE annotations ADD COLUMN private BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE annotations ADD COLUMN updated_at TIMESTAMP;
+
+-- notes imported from a Kindle are the reader's own thoughts, keep them private
+UPDATE annotations SET private = TRUE WHERE type = 'note';
+
+CREATE INDEX IF NOT EXISTS idx_annotations_user_type ON annotations(user_id, type, id);
+
+-- annotation text is searchable in the library index's notes column
+UPDATE library_search
+SET notes = COALESCE((
+    SELECT group_concat(a.text, ' ')
+    FROM annotations a
+    WHERE a.user_id = library_search.user_id AND a.book_id = library_search.book_id
+), '');
+
+CREATE TRIGGER IF NOT EXISTS library_search_annotations_insert AFTER INSERT ON annotations BEGIN
+    UPDATE library_search
+    SET notes = COALESCE((SELECT group_concat(a.text, ' ') FROM annotations a WHERE a.user_id = NEW.user_id AND a.book_id = NEW.book_id), '')
+    WHERE rowid = NEW.user_id * 4294967296 + NEW.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_annotations_update AFTER UPDATE OF text ON annotations BEGIN
+    UPDATE library_search
+    SET notes = COALESCE((SELECT group_concat(a.text, ' ') FROM annotations a WHERE a.user_id = NEW.user_id AND a.book_id = NEW.book_id), '')
+    WHERE rowid = NEW.user_id * 4294967296 + NEW.book_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS library_search_annotations_delete AFTER DELETE ON annotations BEGIN
+    UPDATE library_search
+    SET notes = COALESCE((SELECT group_concat(a.text, ' ') FROM annotations a WHERE a.user_id = OLD.user_id AND a.book_id = OLD.book_id), '')
+    WHERE rowid = OLD.user_id * 4294967296 + OLD.book_id;
+END;
@@ -0,0 +1,50 @@
+-- other ids a book is known by on other services, like goodreads or google
+CREATE TABLE IF NOT EXISTS book_identifiers (
+    book_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (type, value),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_identifiers_book ON book_identifiers(book_id);
+
+-- position is fractional, a novella between books 2 and 3 is 2.5
+CREATE TABLE IF NOT EXISTS series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS series_books (
+    series_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    position REAL,
+    PRIMARY KEY (series_id, book_id),
+    FOREIGN KEY (series_id) REFERENCES series(id),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_series_books_book ON series_books(book_id);
+
+-- a user's tags on library entries, source says which sync owns a tag so re-syncing leaves the user's own alone
+CREATE TABLE IF NOT EXISTS user_book_tags (
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (user_id, book_id, tag),
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_book_tags_tag ON user_book_tags(user_id, tag);
+
+-- library entries synced from another library, keyed by that library's own id
+CREATE TABLE IF NOT EXISTS external_books (
+    user_id INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    book_id INTEGER NOT NULL,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, source, external_id),
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id)
+);
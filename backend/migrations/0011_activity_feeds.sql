@@ -0,0 +1,8 @@
+-- a user's reading activity is published as a feed only once they turn it on
+ALTER TABLE users ADD COLUMN feed_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+-- reviews are only in the feed when the user chose to make them public
+ALTER TABLE users ADD COLUMN feed_reviews BOOLEAN NOT NULL DEFAULT FALSE;
+-- changing the settings changes the feed, this keeps Last-Modified honest
+ALTER TABLE users ADD COLUMN feed_updated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_user_books_updated ON user_books(user_id, updated_at);
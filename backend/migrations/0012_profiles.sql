@@ -0,0 +1,7 @@
+-- who a profile is shown to: only its owner, their followers, or anyone
+ALTER TABLE users ADD COLUMN profile_visibility TEXT NOT NULL DEFAULT 'private';
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+-- an entry is shown to whoever can see the profile, unless it is narrowed down here
+ALTER TABLE user_books ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
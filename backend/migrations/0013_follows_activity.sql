@@ -0,0 +1,84 @@
+-- following a public profile is accepted straight away, other profiles approve each follower
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER NOT NULL,
+    followee_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users(id),
+    FOREIGN KEY (followee_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id, status);
+
+-- what people did with their library, the timeline of the people they follow is built from it.
+-- Times are normalised with datetime() so events from every source sort together,
+-- and events that happened in the past (imports, backdated finishes) are recorded at that time.
+CREATE TABLE IF NOT EXISTS activity_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    -- the rating on finished, the percentage reached on progress
+    value INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id)
+);
+
+-- the timeline reads each followed user's latest events off this index and merges them
+CREATE INDEX IF NOT EXISTS idx_activity_events_user ON activity_events(user_id, created_at, id);
+
+CREATE TRIGGER IF NOT EXISTS activity_added AFTER INSERT ON user_books BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, created_at)
+    VALUES (NEW.user_id, NEW.book_id, 'added', datetime(COALESCE(NEW.added_at, CURRENT_TIMESTAMP)));
+END;
+
+CREATE TRIGGER IF NOT EXISTS activity_started AFTER INSERT ON reads
+WHEN NEW.status = 'reading' BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, created_at)
+    VALUES (NEW.user_id, NEW.book_id, 'started', datetime(COALESCE(NEW.start_date, CURRENT_TIMESTAMP)));
+END;
+
+-- undated finishes, like earlier reads of an imported book, are left out
+CREATE TRIGGER IF NOT EXISTS activity_finished_insert AFTER INSERT ON reads
+WHEN NEW.status = 'finished' AND NEW.finish_date IS NOT NULL BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, value, created_at)
+    VALUES (NEW.user_id, NEW.book_id, 'finished', NEW.rating, datetime(NEW.finish_date));
+END;
+
+CREATE TRIGGER IF NOT EXISTS activity_finished_update AFTER UPDATE OF status ON reads
+WHEN NEW.status = 'finished' AND OLD.status != 'finished' BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, value, created_at)
+    VALUES (NEW.user_id, NEW.book_id, 'finished', NEW.rating, datetime(COALESCE(NEW.finish_date, CURRENT_TIMESTAMP)));
+END;
+
+-- a review counts once, when one is first written
+CREATE TRIGGER IF NOT EXISTS activity_reviewed AFTER UPDATE OF review ON user_books
+WHEN COALESCE(NEW.review, '') != '' AND COALESCE(OLD.review, '') = '' BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, created_at)
+    VALUES (NEW.user_id, NEW.book_id, 'reviewed', CURRENT_TIMESTAMP);
+END;
+
+-- a progress update that passes 25, 50 or 75 percent of the read is a milestone,
+-- only the highest one passed is recorded when several are passed at once
+CREATE TRIGGER IF NOT EXISTS activity_progress AFTER INSERT ON progress_events
+WHEN NEW.read_id IS NOT NULL BEGIN
+    INSERT INTO activity_events (user_id, book_id, type, value, created_at)
+    SELECT NEW.user_id, NEW.book_id, 'progress', m.milestone, datetime(NEW.created_at)
+    FROM (SELECT 25 AS milestone UNION ALL SELECT 50 UNION ALL SELECT 75) m
+    WHERE (CASE WHEN NEW.unit = 'percent' THEN NEW.value WHEN NEW.total > 0 THEN NEW.value * 100 / NEW.total ELSE 0 END) >= m.milestone
+    AND COALESCE((
+        SELECT CASE WHEN pe.unit = 'percent' THEN pe.value WHEN pe.total > 0 THEN pe.value * 100 / pe.total ELSE 0 END
+        FROM progress_events pe
+        WHERE pe.read_id = NEW.read_id AND pe.id != NEW.id
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) < m.milestone
+    ORDER BY m.milestone DESC
+    LIMIT 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS activity_removed AFTER DELETE ON user_books BEGIN
+    DELETE FROM activity_events WHERE user_id = OLD.user_id AND book_id = OLD.book_id;
+END;
@@ -0,0 +1,69 @@
+-- a club reads one book at a time from the catalog, on a schedule of chapters or pages.
+-- schedule_total is the number of chapters or pages of the edition the club reads,
+-- it is what turns a reader's progress into a position in the schedule.
+CREATE TABLE IF NOT EXISTS clubs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    book_id INTEGER,
+    schedule_unit TEXT NOT NULL DEFAULT 'chapter',
+    schedule_total INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (book_id) REFERENCES books(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+-- roles are owner, moderator and member
+CREATE TABLE IF NOT EXISTS club_members (
+    club_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (club_id, user_id),
+    FOREIGN KEY (club_id) REFERENCES clubs(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_members_user ON club_members(user_id);
+
+-- sections keep the book they were scheduled for, so picking the next book starts a new schedule
+-- and the discussions of earlier books stay readable
+CREATE TABLE IF NOT EXISTS club_sections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    club_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    start_at INTEGER NOT NULL,
+    end_at INTEGER NOT NULL,
+    deadline TIMESTAMP,
+    FOREIGN KEY (club_id) REFERENCES clubs(id),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_sections_club ON club_sections(club_id, book_id, start_at);
+
+-- a post with a parent is a reply, deleted posts keep their place in the thread
+CREATE TABLE IF NOT EXISTS club_posts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    section_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (section_id) REFERENCES club_sections(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (parent_id) REFERENCES club_posts(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_posts_section ON club_posts(section_id, created_at);
+
+CREATE TRIGGER IF NOT EXISTS club_removed AFTER DELETE ON clubs BEGIN
+    DELETE FROM club_members WHERE club_id = OLD.id;
+    DELETE FROM club_sections WHERE club_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS club_section_removed AFTER DELETE ON club_sections BEGIN
+    DELETE FROM club_posts WHERE section_id = OLD.id;
+END;
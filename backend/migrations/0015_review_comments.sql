@@ -0,0 +1,70 @@
+-- admins moderate comments, there is no way to become one through the API
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT 0;
+
+-- a review is the review on a library entry, so comments and reactions are keyed by its owner and book.
+-- deleted_at is set when the author or reviewer deletes a comment, hidden_at when an admin hides it
+CREATE TABLE IF NOT EXISTS review_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reviewer_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    hidden_at TIMESTAMP,
+    hidden_by INTEGER,
+    FOREIGN KEY (reviewer_id, book_id) REFERENCES user_books(user_id, book_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (hidden_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_comments_review ON review_comments(reviewer_id, book_id, created_at, id);
+
+CREATE TABLE IF NOT EXISTS review_reactions (
+    reviewer_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    reaction TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (reviewer_id, book_id, user_id, reaction),
+    FOREIGN KEY (reviewer_id, book_id) REFERENCES user_books(user_id, book_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS comment_reports (
+    comment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES review_comments(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    actor_id INTEGER NOT NULL,
+    reviewer_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    comment_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (actor_id) REFERENCES users(id),
+    FOREIGN KEY (comment_id) REFERENCES review_comments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at, id);
+
+-- removing a book from the library takes its review, and everything said about it, with it
+CREATE TRIGGER IF NOT EXISTS review_removed AFTER DELETE ON user_books BEGIN
+    DELETE FROM notifications WHERE reviewer_id = OLD.user_id AND book_id = OLD.book_id;
+    DELETE FROM comment_reports WHERE comment_id IN (
+        SELECT id FROM review_comments WHERE reviewer_id = OLD.user_id AND book_id = OLD.book_id
+    );
+    DELETE FROM review_comments WHERE reviewer_id = OLD.user_id AND book_id = OLD.book_id;
+    DELETE FROM review_reactions WHERE reviewer_id = OLD.user_id AND book_id = OLD.book_id;
+END;
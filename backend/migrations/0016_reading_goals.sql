@@ -0,0 +1,24 @@
+-- a user can have a goal per year in each unit: books, pages or minutes
+CREATE TABLE IF NOT EXISTS reading_goals (
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    unit TEXT NOT NULL,
+    target INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, year, unit),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- rules is a JSON array of predicates over book metadata, a finished book counts when it matches all of them
+CREATE TABLE IF NOT EXISTS challenges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    target INTEGER NOT NULL,
+    rules TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenges_user ON challenges(user_id, year);
@@ -0,0 +1,49 @@
+-- stats_version goes up whenever anything reading statistics look at changes,
+-- cached statistics are good for as long as it stays the same
+ALTER TABLE users ADD COLUMN stats_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TRIGGER IF NOT EXISTS stats_user_books_insert AFTER INSERT ON user_books BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_user_books_update AFTER UPDATE OF start_date, finish_date, rating ON user_books BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_user_books_delete AFTER DELETE ON user_books BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = OLD.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_reads_insert AFTER INSERT ON reads BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_reads_update AFTER UPDATE OF status ON reads BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_reads_delete AFTER DELETE ON reads BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = OLD.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_progress_insert AFTER INSERT ON progress_events BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS stats_books_update AFTER UPDATE OF title, author ON books BEGIN
+    UPDATE users SET stats_version = stats_version + 1
+    WHERE id IN (SELECT user_id FROM user_books WHERE book_id = NEW.id);
+END;
+
+-- library entries whose current read is finished, with the page count last given with progress
+CREATE VIEW IF NOT EXISTS finished_books AS
+SELECT ub.user_id, ub.book_id, b.title, b.author, ub.start_date, ub.finish_date, ub.rating,
+    CAST(COALESCE((
+        SELECT pe.total FROM progress_events pe
+        WHERE pe.user_id = ub.user_id AND pe.book_id = ub.book_id AND pe.unit = 'pages' AND pe.total IS NOT NULL
+        ORDER BY pe.created_at DESC, pe.id DESC LIMIT 1
+    ), 0) AS INTEGER) AS pages
+FROM user_books ub
+JOIN books b ON b.id = ub.book_id
+WHERE ub.finish_date IS NOT NULL
+    AND (SELECT r.status FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id ORDER BY r.id DESC LIMIT 1) = 'finished';
@@ -0,0 +1,8 @@
+-- year in review reports are private until the user shares the year
+CREATE TABLE IF NOT EXISTS year_reviews (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    year INTEGER NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, year)
+);
@@ -0,0 +1,25 @@
+-- the timezone reading days are counted in for streaks, an IANA name like Europe/Berlin
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- a timed sitting with a book. Pages are where the reader was at the start and end, when known.
+CREATE TABLE IF NOT EXISTS reading_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    read_id INTEGER,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    start_page INTEGER,
+    end_page INTEGER,
+    FOREIGN KEY (user_id, book_id) REFERENCES user_books(user_id, book_id),
+    FOREIGN KEY (read_id) REFERENCES reads(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_sessions_book ON reading_sessions(user_id, book_id, started_at);
+
+-- a reader times one book at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reading_sessions_running ON reading_sessions(user_id) WHERE ended_at IS NULL;
+
+CREATE TRIGGER IF NOT EXISTS reading_sessions_removed AFTER DELETE ON user_books BEGIN
+    DELETE FROM reading_sessions WHERE user_id = OLD.user_id AND book_id = OLD.book_id;
+END;
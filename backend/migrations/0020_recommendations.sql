@@ -0,0 +1,14 @@
+-- recommendations are computed offline for every user and replaced as a whole on each run.
+-- reasons is a JSON array explaining the score, strongest first.
+CREATE TABLE IF NOT EXISTS recommendations (
+    user_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    reasons TEXT NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, book_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendations_score ON recommendations(user_id, score DESC);
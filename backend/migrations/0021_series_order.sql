@@ -0,0 +1,9 @@
+-- series are read in order, entries without a position go last
+CREATE INDEX IF NOT EXISTS idx_series_books_position ON series_books(series_id, position);
+
+-- a series left without books is gone
+CREATE TRIGGER IF NOT EXISTS series_books_cleanup AFTER DELETE ON series_books
+WHEN NOT EXISTS (SELECT 1 FROM series_books WHERE series_id = OLD.series_id)
+BEGIN
+    DELETE FROM series WHERE id = OLD.series_id;
+END;
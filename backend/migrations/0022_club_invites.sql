@@ -0,0 +1,19 @@
+-- moderators invite members, who only join a club, and show it their progress, once they accept.
+-- role is what the member joins as.
+CREATE TABLE IF NOT EXISTS club_invites (
+    club_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member',
+    invited_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (club_id, user_id),
+    FOREIGN KEY (club_id) REFERENCES clubs(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (invited_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_invites_user ON club_invites(user_id);
+
+CREATE TRIGGER IF NOT EXISTS club_invites_removed AFTER DELETE ON clubs BEGIN
+    DELETE FROM club_invites WHERE club_id = OLD.id;
+END;
@@ -0,0 +1,28 @@
+-- series entries put in by hand belong to the user who did, everyone else keeps seeing the catalog.
+-- user_id 0 is the catalog's, from providers, Calibre and imports. A user's own entry for a book
+-- overrides the catalog's for them. The key gains user_id, which sqlite can only do by rebuilding.
+CREATE TABLE series_books_by_user (
+    series_id INTEGER NOT NULL,
+    book_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    position REAL,
+    PRIMARY KEY (series_id, book_id, user_id),
+    FOREIGN KEY (series_id) REFERENCES series(id),
+    FOREIGN KEY (book_id) REFERENCES books(id)
+);
+
+INSERT INTO series_books_by_user (series_id, book_id, position)
+SELECT series_id, book_id, position FROM series_books;
+
+DROP TABLE series_books;
+ALTER TABLE series_books_by_user RENAME TO series_books;
+
+CREATE INDEX IF NOT EXISTS idx_series_books_book ON series_books(book_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_series_books_position ON series_books(series_id, position);
+
+-- a series left without books is gone
+CREATE TRIGGER IF NOT EXISTS series_books_cleanup AFTER DELETE ON series_books
+WHEN NOT EXISTS (SELECT 1 FROM series_books WHERE series_id = OLD.series_id)
+BEGIN
+    DELETE FROM series WHERE id = OLD.series_id;
+END;
@@ -0,0 +1,23 @@
+-- finished_books is every finished read rather than entries whose latest read is finished, so
+-- starting a re-read doesn't take the earlier one out of past statistics, goals and reviews.
+-- A book read twice is in it twice.
+DROP VIEW IF EXISTS finished_books;
+
+CREATE VIEW IF NOT EXISTS finished_books AS
+SELECT r.user_id, r.book_id, r.id AS read_id, b.title, b.author, r.start_date, r.finish_date, r.rating,
+    CAST(COALESCE((
+        SELECT pe.total FROM progress_events pe
+        WHERE pe.user_id = r.user_id AND pe.book_id = r.book_id AND pe.unit = 'pages' AND pe.total IS NOT NULL
+        ORDER BY pe.created_at DESC, pe.id DESC LIMIT 1
+    ), 0) AS INTEGER) AS pages
+FROM reads r
+JOIN user_books ub ON ub.user_id = r.user_id AND ub.book_id = r.book_id
+JOIN books b ON b.id = r.book_id
+WHERE r.status = 'finished' AND r.finish_date IS NOT NULL;
+
+-- statistics now look at the dates and rating of each read
+DROP TRIGGER IF EXISTS stats_reads_update;
+
+CREATE TRIGGER IF NOT EXISTS stats_reads_update AFTER UPDATE OF status, start_date, finish_date, rating ON reads BEGIN
+    UPDATE users SET stats_version = stats_version + 1 WHERE id = NEW.user_id;
+END;
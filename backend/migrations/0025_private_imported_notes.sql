@@ -0,0 +1,4 @@
+-- Kindle notes imported since 0008 went in public, they are private like the ones before unless
+-- the reader has edited them since
+UPDATE annotations SET private = TRUE
+WHERE type = 'note' AND source = 'kindle' AND updated_at IS NULL;
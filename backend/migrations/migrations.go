@@ -0,0 +1,87 @@
+package migrations
+
+// this package upgrades an existing books.db on top of schema.sql.
+// each NNNN_name.sql file runs once, tracked with sqlite's user_version pragma.
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "booktrackr/logging"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+type migration struct {
+	version int
+	name    string
+}
+
+// Apply runs every migration newer than the database's user_version, in order.
+// Each migration runs in its own transaction together with the version bump.
+func Apply(conn *sql.DB) error {
+	var current int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	pending, err := list()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if m.version <= current {
+			continue
+		}
+		contents, err := files.ReadFile(m.name)
+		if err != nil {
+			return err
+		}
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(contents)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+		// pragmas can't take bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Info("Applied migration %s", m.name)
+	}
+	return nil
+}
+
+func list() ([]migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is missing a version prefix", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: entry.Name()})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,185 @@
+package challenge
+
+// this package evaluates reading challenge rules, predicates over the metadata of finished books
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Book is what the rules of a challenge see of a finished book
+type Book struct {
+	Title     string
+	Author    string
+	Series    string
+	Tags      []string
+	Pages     int64 // 0 when the page count is not known
+	Minutes   int64 // 0 when the duration is not known
+	Rating    int64 // 0 when not rated
+	NewAuthor bool  // no book by the author was finished in an earlier year
+}
+
+// Rule is one predicate, for example {"field": "tag", "op": "has", "value": "non-fiction"}
+// or {"field": "new_author", "op": "is", "value": true}
+type Rule struct {
+	Field string          `json:"field"`
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kind int
+
+const (
+	kindText kind = iota
+	kindList
+	kindNumber
+	kindBool
+)
+
+var fields = map[string]kind{
+	"title":      kindText,
+	"author":     kindText,
+	"series":     kindText,
+	"tag":        kindList,
+	"pages":      kindNumber,
+	"minutes":    kindNumber,
+	"rating":     kindNumber,
+	"new_author": kindBool,
+}
+
+// the operators each kind of field takes
+var ops = map[kind][]string{
+	kindText:   {"equals", "contains", "in"},
+	kindList:   {"has", "in"},
+	kindNumber: {"equals", "gte", "lte"},
+	kindBool:   {"is"},
+}
+
+// Parse reads the JSON rules of a challenge and checks each of them
+func Parse(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("rules must be a list of rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("a challenge needs at least one rule")
+	}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// Validate checks that the field is known, that it takes the operator and that the value fits both
+func (r Rule) Validate() error {
+	k, ok := fields[r.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", r.Field)
+	}
+	if !slices.Contains(ops[k], r.Op) {
+		return fmt.Errorf("%s takes the operators %s", r.Field, strings.Join(ops[k], ", "))
+	}
+	var err error
+	switch {
+	case r.Op == "in":
+		var value []string
+		err = json.Unmarshal(r.Value, &value)
+		if err == nil && len(value) == 0 {
+			err = errors.New("empty list")
+		}
+	case k == kindText || k == kindList:
+		var value string
+		err = json.Unmarshal(r.Value, &value)
+	case k == kindNumber:
+		var value int64
+		err = json.Unmarshal(r.Value, &value)
+	case k == kindBool:
+		var value bool
+		err = json.Unmarshal(r.Value, &value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s needs a %s", r.Field, r.Op, valueDescription(k, r.Op))
+	}
+	return nil
+}
+
+func valueDescription(k kind, op string) string {
+	switch {
+	case op == "in":
+		return "list of strings"
+	case k == kindNumber:
+		return "whole number"
+	case k == kindBool:
+		return "true or false"
+	}
+	return "string"
+}
+
+// Match reports whether a book satisfies the rule, rules are expected to be valid.
+// Text is compared without regard to case.
+func (r Rule) Match(book Book) bool {
+	switch fields[r.Field] {
+	case kindText:
+		text := map[string]string{"title": book.Title, "author": book.Author, "series": book.Series}[r.Field]
+		return matchText(r, text)
+	case kindList:
+		for _, tag := range book.Tags {
+			if matchText(r, tag) {
+				return true
+			}
+		}
+		return false
+	case kindNumber:
+		number := map[string]int64{"pages": book.Pages, "minutes": book.Minutes, "rating": book.Rating}[r.Field]
+		var value int64
+		json.Unmarshal(r.Value, &value)
+		switch r.Op {
+		case "gte":
+			return number >= value
+		case "lte":
+			// an unknown page count or rating is not a short book or a low rating
+			return number > 0 && number <= value
+		}
+		return number == value
+	case kindBool:
+		var value bool
+		json.Unmarshal(r.Value, &value)
+		return book.NewAuthor == value
+	}
+	return false
+}
+
+func matchText(r Rule, text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if r.Op == "in" {
+		var values []string
+		json.Unmarshal(r.Value, &values)
+		for _, value := range values {
+			if text == strings.ToLower(strings.TrimSpace(value)) {
+				return true
+			}
+		}
+		return false
+	}
+	var value string
+	json.Unmarshal(r.Value, &value)
+	value = strings.ToLower(strings.TrimSpace(value))
+	if r.Op == "contains" {
+		return value != "" && strings.Contains(text, value)
+	}
+	return text == value
+}
+
+// MatchAll reports whether a book satisfies every rule
+func MatchAll(rules []Rule, book Book) bool {
+	for _, rule := range rules {
+		if !rule.Match(book) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package covers
+
+// this package fetches book cover images and keeps them on disk, so each cover is downloaded once
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxSize is the largest cover that is downloaded, covers are a few hundred KB at most
+	maxSize = 5 << 20
+	// maxDimension is the widest or tallest cover that is decoded, a small file can claim to be
+	// huge and decoding it would allocate for every pixel
+	maxDimension = 4000
+	// retryAfter is how long a cover that could not be fetched is left alone before trying again
+	retryAfter = time.Hour
+)
+
+var ErrNotAllowed = errors.New("cover URL is not allowed")
+
+// Cache downloads covers into a directory, named by a hash of their URL
+type Cache struct {
+	dir    string
+	client *http.Client
+
+	mu     sync.Mutex
+	failed map[string]time.Time
+}
+
+func NewCache(dir string) *Cache {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: publicOnly}
+	return &Cache{
+		dir: dir,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		failed: map[string]time.Time{},
+	}
+}
+
+// publicOnly stops cover URLs, which users enter, from reaching the server's own network
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+func (c *Cache) path(coverURL string) string {
+	sum := sha256.Sum256([]byte(coverURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the decoded cover, from disk when it was fetched before
+func (c *Cache) Get(ctx context.Context, coverURL string) (image.Image, error) {
+	data, err := os.ReadFile(c.path(coverURL))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = c.fetch(ctx, coverURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImage(data); err != nil {
+		return nil, fmt.Errorf("cover %s: %w", coverURL, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// checkImage makes sure data is an image small enough to decode, from its header alone
+func checkImage(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return fmt.Errorf("image is %dx%d, larger than %dx%d", config.Width, config.Height, maxDimension, maxDimension)
+	}
+	return nil
+}
+
+func (c *Cache) fetch(ctx context.Context, coverURL string) ([]byte, error) {
+	u, err := url.Parse(coverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrNotAllowed
+	}
+	c.mu.Lock()
+	failedAt, failed := c.failed[coverURL]
+	c.mu.Unlock()
+	if failed && time.Since(failedAt) < retryAfter {
+		return nil, fmt.Errorf("cover %s failed recently", coverURL)
+	}
+
+	data, err := c.download(ctx, coverURL)
+	if err != nil {
+		c.mu.Lock()
+		c.failed[coverURL] = time.Now()
+		c.mu.Unlock()
+		return nil, err
+	}
+	if err := c.store(coverURL, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (c *Cache) download(ctx context.Context, coverURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, coverURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cover %s: %s", coverURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("cover %s is larger than %d bytes", coverURL, maxSize)
+	}
+	// only images are kept, so a broken URL does not fill the cache with error pages
+	if err := checkImage(data); err != nil {
+		return nil, fmt.Errorf("cover %s: %w", coverURL, err)
+	}
+	return data, nil
+}
+
+// store writes through a temporary file so a report rendering at the same time never reads half a cover
+func (c *Cache) store(coverURL string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "cover-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(coverURL))
+}
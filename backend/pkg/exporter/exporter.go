@@ -0,0 +1,255 @@
+package exporter
+
+// this package writes a user's library out in formats other tools can read
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+)
+
+// Writer writes library entries one at a time, Close finishes the document
+type Writer interface {
+	Write(entry db.ExportLibraryRow) error
+	Close() error
+}
+
+// Format describes one export format
+type Format struct {
+	ContentType string
+	Extension   string
+	New         func(w io.Writer) Writer
+}
+
+var formats = map[string]Format{
+	"csv":       {ContentType: "text/csv; charset=utf-8", Extension: "csv", New: newCSVWriter},
+	"json":      {ContentType: "application/json", Extension: "json", New: newJSONWriter},
+	"goodreads": {ContentType: "text/csv; charset=utf-8", Extension: "csv", New: newGoodreadsWriter},
+}
+
+// Lookup finds an export format by name
+func Lookup(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+// Names lists the export formats in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatDate(t time.Time, valid bool, layout string) string {
+	if !valid {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// books without an ISBN are keyed by "source:id", that is not worth exporting
+func exportISBN(isbn string) string {
+	if strings.Contains(isbn, ":") {
+		return ""
+	}
+	return isbn
+}
+
+func formatRating(rating int64, valid bool) string {
+	if !valid {
+		return ""
+	}
+	return strconv.FormatInt(rating, 10)
+}
+
+// csvWriter is booktrackr's own flat export
+type csvWriter struct {
+	w *csv.Writer
+}
+
+var csvHeader = []string{
+	"book_id", "isbn", "title", "author", "description", "image_url", "status",
+	"added_at", "start_date", "finish_date", "rating", "review", "read_count",
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	return &csvWriter{w: writer}
+}
+
+func (c *csvWriter) Write(entry db.ExportLibraryRow) error {
+	return c.w.Write([]string{
+		strconv.FormatInt(entry.BookID, 10),
+		exportISBN(entry.Isbn),
+		entry.Title,
+		entry.Author,
+		entry.Description,
+		entry.ImageUrl,
+		entry.Status,
+		formatDate(entry.AddedAt.Time, entry.AddedAt.Valid, time.RFC3339),
+		formatDate(entry.StartDate.Time, entry.StartDate.Valid, time.RFC3339),
+		formatDate(entry.FinishDate.Time, entry.FinishDate.Valid, time.RFC3339),
+		formatRating(entry.Rating.Int64, entry.Rating.Valid),
+		entry.Review.String,
+		strconv.FormatInt(entry.ReadCount, 10),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter streams a JSON array, one entry per line
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+type jsonEntry struct {
+	BookID      int64  `json:"book_id"`
+	Isbn        string `json:"isbn"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	Status      string `json:"status"`
+	AddedAt     string `json:"added_at,omitempty"`
+	StartDate   string `json:"start_date,omitempty"`
+	FinishDate  string `json:"finish_date,omitempty"`
+	Rating      int64  `json:"rating,omitempty"`
+	Review      string `json:"review,omitempty"`
+	ReadCount   int64  `json:"read_count"`
+}
+
+func newJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) Write(entry db.ExportLibraryRow) error {
+	separator := ",\n"
+	if j.count == 0 {
+		separator = "[\n"
+	}
+	j.count++
+	encoded, err := json.Marshal(jsonEntry{
+		BookID:      entry.BookID,
+		Isbn:        exportISBN(entry.Isbn),
+		Title:       entry.Title,
+		Author:      entry.Author,
+		Description: entry.Description,
+		ImageURL:    entry.ImageUrl,
+		Status:      entry.Status,
+		AddedAt:     formatDate(entry.AddedAt.Time, entry.AddedAt.Valid, time.RFC3339),
+		StartDate:   formatDate(entry.StartDate.Time, entry.StartDate.Valid, time.RFC3339),
+		FinishDate:  formatDate(entry.FinishDate.Time, entry.FinishDate.Valid, time.RFC3339),
+		Rating:      entry.Rating.Int64,
+		Review:      entry.Review.String,
+		ReadCount:   entry.ReadCount,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "%s%s", separator, encoded)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// goodreadsWriter matches the columns of Goodreads' own export, so it can be
+// imported back into Goodreads or any tool that reads that format
+type goodreadsWriter struct {
+	w *csv.Writer
+}
+
+var goodreadsHeader = []string{
+	"Book Id", "Title", "Author", "Author l-f", "Additional Authors", "ISBN", "ISBN13",
+	"My Rating", "Average Rating", "Publisher", "Binding", "Number of Pages", "Year Published",
+	"Original Publication Year", "Date Read", "Date Added", "Bookshelves", "Bookshelves with positions",
+	"Exclusive Shelf", "My Review", "Spoiler", "Private Notes", "Read Count", "Owned Copies",
+}
+
+func newGoodreadsWriter(w io.Writer) Writer {
+	writer := csv.NewWriter(w)
+	writer.Write(goodreadsHeader)
+	return &goodreadsWriter{w: writer}
+}
+
+// goodreadsShelves maps read statuses to Goodreads' exclusive shelves
+var goodreadsShelves = map[string]string{
+	"finished":  "read",
+	"reading":   "currently-reading",
+	"abandoned": "did-not-finish",
+	"to-read":   "to-read",
+}
+
+func (g *goodreadsWriter) Write(entry db.ExportLibraryRow) error {
+	var isbn10, isbn13 string
+	switch isbn := exportISBN(entry.Isbn); len(isbn) {
+	case 10:
+		isbn10 = isbn
+	case 13:
+		isbn13 = isbn
+	}
+	shelf := goodreadsShelves[entry.Status]
+	bookshelves := ""
+	if shelf == "did-not-finish" {
+		bookshelves = shelf
+	}
+	rating := "0"
+	if entry.Rating.Valid {
+		rating = strconv.FormatInt(entry.Rating.Int64, 10)
+	}
+	return g.w.Write([]string{
+		strconv.FormatInt(entry.BookID, 10),
+		entry.Title,
+		entry.Author,
+		authorLastFirst(entry.Author),
+		"",
+		// Goodreads quotes ISBNs as formulas so spreadsheets keep leading zeros
+		`="` + isbn10 + `"`,
+		`="` + isbn13 + `"`,
+		rating,
+		"", "", "", "", "", "",
+		formatDate(entry.FinishDate.Time, entry.FinishDate.Valid, "2006/01/02"),
+		formatDate(entry.AddedAt.Time, entry.AddedAt.Valid, "2006/01/02"),
+		bookshelves,
+		"",
+		shelf,
+		entry.Review.String,
+		"", "",
+		strconv.FormatInt(entry.ReadCount, 10),
+		"0",
+	})
+}
+
+func (g *goodreadsWriter) Close() error {
+	g.w.Flush()
+	return g.w.Error()
+}
+
+// authorLastFirst turns "Frank Herbert" into "Herbert, Frank"
+func authorLastFirst(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	return parts[len(parts)-1] + ", " + strings.Join(parts[:len(parts)-1], " ")
+}
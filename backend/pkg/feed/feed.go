@@ -0,0 +1,253 @@
+package feed
+
+// this package writes a list of items as an Atom, RSS 2.0 or JSON Feed 1.1 document
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sort"
+	"time"
+)
+
+// Feed is the format-neutral feed, Items newest first
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string // the human readable page the feed is about
+	FeedURL     string // where the feed itself is served
+	Author      string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Content   string // plain text
+	ImageURL  string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Format describes one feed format
+type Format struct {
+	ContentType string
+	Write       func(w io.Writer, feed Feed) error
+}
+
+var formats = map[string]Format{
+	"atom": {ContentType: "application/atom+xml; charset=utf-8", Write: writeAtom},
+	"rss":  {ContentType: "application/rss+xml; charset=utf-8", Write: writeRSS},
+	"json": {ContentType: "application/feed+json; charset=utf-8", Write: writeJSON},
+}
+
+// Lookup finds a feed format by name
+func Lookup(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+// Names lists the feed formats in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+	Content   *atomText  `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   string      `xml:"author>name"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeXML(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
+
+func writeAtom(w io.Writer, feed Feed) error {
+	document := atomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  atomTime(feed.Updated),
+		Author:   feed.Author,
+		Links: []atomLink{
+			{Rel: "self", Href: feed.FeedURL, Type: "application/atom+xml"},
+			{Rel: "alternate", Href: feed.Link, Type: "text/html"},
+		},
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Published: atomTime(item.Published),
+			Updated:   atomTime(item.Updated),
+			Links:     []atomLink{{Rel: "alternate", Href: item.Link, Type: "text/html"}},
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "text", Text: item.Content}
+		}
+		document.Entries = append(document.Entries, entry)
+	}
+	return writeXML(w, document)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	XmlnsAtom string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+func rssTime(t time.Time) string {
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+func writeRSS(w io.Writer, feed Feed) error {
+	document := rssFeed{
+		Version:   "2.0",
+		XmlnsAtom: "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Description,
+			AtomLink:      atomLink{Rel: "self", Href: feed.FeedURL, Type: "application/rss+xml"},
+			LastBuildDate: rssTime(feed.Updated),
+		},
+	}
+	for _, item := range feed.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Content,
+			GUID:        rssGUID{ID: item.ID},
+			PubDate:     rssTime(item.Published),
+		}
+		if item.ImageURL != "" {
+			// the length is unknown, RSS readers accept 0
+			entry.Enclosure = &rssEnclosure{URL: item.ImageURL, Type: "image/jpeg"}
+		}
+		document.Channel.Items = append(document.Channel.Items, entry)
+	}
+	return writeXML(w, document)
+}
+
+type jsonItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title"`
+	ContentText   string `json:"content_text"`
+	Image         string `json:"image,omitempty"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Description string       `json:"description,omitempty"`
+	Authors     []jsonAuthor `json:"authors"`
+	Items       []jsonItem   `json:"items"`
+}
+
+func writeJSON(w io.Writer, feed Feed) error {
+	document := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Authors:     []jsonAuthor{{Name: feed.Author}},
+		Items:       []jsonItem{},
+	}
+	for _, item := range feed.Items {
+		document.Items = append(document.Items, jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   item.Content,
+			Image:         item.ImageURL,
+			DatePublished: atomTime(item.Published),
+			DateModified:  atomTime(item.Updated),
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,171 @@
+package forecast
+
+// this package estimates when a book will be finished from the reader's progress on it and their
+// usual pace, with a range that widens the less steady or the less known their reading is
+import (
+	"math"
+	"time"
+)
+
+const (
+	day = 24 * time.Hour
+	// Window is how far back progress counts as recent
+	Window = 14 * day
+	// recent progress is trusted fully once it covers steadyDays with steadyPoints updates,
+	// until then it is blended with the reader's usual pace
+	steadyDays   = 7
+	steadyPoints = 3
+	// the range around the estimate, as a fraction of the reading rate
+	minSpread        = 0.1
+	maxSpread        = 0.9
+	historicalSpread = 0.5
+	// estimates go no further out than maxDays, a slow enough pace would overflow a time.Duration
+	maxDays = 10 * 365
+)
+
+// how an estimate was made
+const (
+	BasisRecent     = "recent"
+	BasisHistorical = "historical"
+	BasisBlended    = "blended"
+)
+
+// Point is how far into the book the reader was at a time, in the book's unit
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+type Input struct {
+	History []Point   // oldest first
+	Total   float64   // the length of the book in the unit of History
+	Started time.Time // when the reader started, at 0, zero when not known
+	// HistoricalPerDay is the reader's usual pace in the same unit, 0 when not known
+	HistoricalPerDay float64
+	Now              time.Time
+}
+
+// Estimate is the likely finish and the range it falls in, Earliest at the faster end
+type Estimate struct {
+	Finish   time.Time
+	Earliest time.Time
+	Latest   time.Time
+	PerDay   float64
+	Basis    string
+}
+
+// position is where the reader was at t, the first point's value before it
+func position(history []Point, t time.Time) float64 {
+	value := history[0].Value
+	for _, point := range history {
+		if point.At.After(t) {
+			break
+		}
+		value = point.Value
+	}
+	return value
+}
+
+// recentRate is the pace over the window and a spread from how much it varied from day to day.
+// A pace read off a few days or from bursts of reading gets a wide spread.
+func recentRate(history []Point, now time.Time) (rate, spread, weight float64) {
+	start := now.Add(-Window)
+	if history[0].At.After(start) {
+		start = history[0].At
+	}
+	days := now.Sub(start).Hours() / 24
+	if days <= 0 {
+		return 0, maxSpread, 0
+	}
+	rate = (position(history, now) - position(history, start)) / math.Max(days, 1)
+
+	buckets := int(math.Ceil(days))
+	deltas := make([]float64, buckets)
+	var mean float64
+	for i := range deltas {
+		from := start.Add(time.Duration(i) * day)
+		to := from.Add(day)
+		if to.After(now) {
+			to = now
+		}
+		deltas[i] = position(history, to) - position(history, from)
+		mean += deltas[i] / float64(buckets)
+	}
+	var variance float64
+	if buckets > 1 {
+		for _, delta := range deltas {
+			variance += (delta - mean) * (delta - mean) / float64(buckets-1)
+		}
+	}
+	spread = maxSpread
+	if mean > 0 {
+		standardError := math.Sqrt(variance) / math.Sqrt(float64(buckets))
+		spread = math.Max(standardError/mean, 0.5/math.Sqrt(float64(buckets)))
+	}
+	spread = math.Min(math.Max(spread, minSpread), maxSpread)
+
+	// progress from before the window says where the reader was at its start, which counts too
+	points := 0
+	if history[0].At.Before(start) {
+		points++
+	}
+	for _, point := range history {
+		if !point.At.Before(start) {
+			points++
+		}
+	}
+	weight = math.Min(1, days/steadyDays) * math.Min(1, float64(points)/steadyPoints)
+	return rate, spread, weight
+}
+
+func after(now time.Time, remaining, perDay float64) time.Time {
+	days := math.Min(remaining/perDay, maxDays)
+	return now.Add(time.Duration(days * float64(day)))
+}
+
+// Predict estimates the finish. It reports false when there is nothing to go on, no progress and
+// no usual pace, or when reading has stalled and there is no usual pace to fall back on.
+func Predict(in Input) (Estimate, bool) {
+	history := in.History
+	if !in.Started.IsZero() && (len(history) == 0 || in.Started.Before(history[0].At)) {
+		history = append([]Point{{At: in.Started, Value: 0}}, history...)
+	}
+	if len(history) == 0 || in.Total <= 0 {
+		return Estimate{}, false
+	}
+	remaining := in.Total - position(history, in.Now)
+	if remaining <= 0 {
+		return Estimate{Finish: in.Now, Earliest: in.Now, Latest: in.Now, Basis: BasisRecent}, true
+	}
+
+	var rate, spread, weight float64
+	if len(history) >= 2 {
+		rate, spread, weight = recentRate(history, in.Now)
+	}
+	if in.HistoricalPerDay <= 0 {
+		if len(history) < 2 {
+			return Estimate{}, false
+		}
+		weight = 1
+	}
+	perDay := weight*rate + (1-weight)*in.HistoricalPerDay
+	spread = weight*spread + (1-weight)*historicalSpread
+	if perDay <= 0 {
+		return Estimate{}, false
+	}
+
+	basis := BasisBlended
+	switch weight {
+	case 1:
+		basis = BasisRecent
+	case 0:
+		basis = BasisHistorical
+	}
+	return Estimate{
+		Finish:   after(in.Now, remaining, perDay),
+		Earliest: after(in.Now, remaining, perDay*(1+spread)),
+		Latest:   after(in.Now, remaining, perDay*(1-spread)),
+		PerDay:   perDay,
+		Basis:    basis,
+	}, true
+}
@@ -0,0 +1,161 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, time.March, 15, 20, 0, 0, 0, time.UTC)
+
+func daysAgo(days float64) time.Time {
+	return now.Add(-time.Duration(days * float64(day)))
+}
+
+// steady is reading pagesPerDay every evening for the last days
+func steady(days int, pagesPerDay float64) []Point {
+	history := []Point{}
+	for i := days; i >= 0; i-- {
+		history = append(history, Point{At: daysAgo(float64(i)), Value: float64(days-i) * pagesPerDay})
+	}
+	return history
+}
+
+func daysUntil(t time.Time) float64 {
+	return t.Sub(now).Hours() / 24
+}
+
+func TestPredictSteadyReading(t *testing.T) {
+	estimate, ok := Predict(Input{History: steady(14, 20), Total: 400, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if estimate.Basis != BasisRecent {
+		t.Errorf("basis = %s, want %s", estimate.Basis, BasisRecent)
+	}
+	// 280 of 400 pages read at 20 a day leaves 6 days
+	if got := daysUntil(estimate.Finish); got < 5.9 || got > 6.1 {
+		t.Errorf("finish in %.1f days, want 6", got)
+	}
+	if !estimate.Earliest.Before(estimate.Finish) || !estimate.Latest.After(estimate.Finish) {
+		t.Errorf("range %s to %s does not contain %s", estimate.Earliest, estimate.Latest, estimate.Finish)
+	}
+	if width := daysUntil(estimate.Latest) - daysUntil(estimate.Earliest); width > 3 {
+		t.Errorf("steady reading has a range of %.1f days, want a narrow one", width)
+	}
+}
+
+func TestPredictBurstyReadingWidensRange(t *testing.T) {
+	// the same 280 pages, read in two long sittings
+	bursty := []Point{
+		{At: daysAgo(14), Value: 0},
+		{At: daysAgo(10), Value: 140},
+		{At: daysAgo(3), Value: 280},
+	}
+	steadyEstimate, _ := Predict(Input{History: steady(14, 20), Total: 400, Now: now})
+	estimate, ok := Predict(Input{History: bursty, Total: 400, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if got := daysUntil(estimate.Finish); got < 5.9 || got > 6.1 {
+		t.Errorf("finish in %.1f days, want 6 as the average pace is the same", got)
+	}
+	width := estimate.Latest.Sub(estimate.Earliest)
+	if steadyWidth := steadyEstimate.Latest.Sub(steadyEstimate.Earliest); width <= steadyWidth {
+		t.Errorf("bursty range %s is not wider than steady range %s", width, steadyWidth)
+	}
+}
+
+func TestPredictSparseHistoryLeansOnUsualPace(t *testing.T) {
+	// started two days ago with one update since, the usual pace is 50 pages a day
+	sparse := []Point{{At: daysAgo(1), Value: 30}}
+	estimate, ok := Predict(Input{History: sparse, Total: 330, Started: daysAgo(2), HistoricalPerDay: 50, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if estimate.Basis != BasisBlended {
+		t.Errorf("basis = %s, want %s", estimate.Basis, BasisBlended)
+	}
+	// 15 pages a day recently, 50 usually: the estimate falls between the two, nearer the usual
+	if got := daysUntil(estimate.Finish); got <= 300.0/50 || got >= 300.0/15 {
+		t.Errorf("finish in %.1f days, want between %.1f and %.1f", got, 300.0/50, 300.0/15)
+	}
+	if got := daysUntil(estimate.Finish); got >= (300.0/50+300.0/15)/2 {
+		t.Errorf("finish in %.1f days leans on two days of progress more than the usual pace", got)
+	}
+}
+
+func TestPredictVerySlowReading(t *testing.T) {
+	// one page in two weeks of a 2000 page book
+	slow := []Point{{At: daysAgo(14), Value: 0}, {At: now.Add(-time.Hour), Value: 1}}
+	estimate, ok := Predict(Input{History: slow, Total: 2000, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if !estimate.Finish.After(now) {
+		t.Errorf("finish %s is not after now", estimate.Finish)
+	}
+	if estimate.Earliest.After(estimate.Finish) || estimate.Latest.Before(estimate.Finish) {
+		t.Errorf("range %s to %s does not contain %s", estimate.Earliest, estimate.Latest, estimate.Finish)
+	}
+	if got := daysUntil(estimate.Latest); got > maxDays+1 {
+		t.Errorf("latest in %.0f days, want at most %d", got, maxDays)
+	}
+}
+
+func TestPredictSinglePoint(t *testing.T) {
+	single := []Point{{At: daysAgo(1), Value: 100}}
+	if _, ok := Predict(Input{History: single, Total: 300, Now: now}); ok {
+		t.Error("one update and no usual pace should give no estimate")
+	}
+	estimate, ok := Predict(Input{History: single, Total: 300, HistoricalPerDay: 40, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate from the usual pace")
+	}
+	if estimate.Basis != BasisHistorical {
+		t.Errorf("basis = %s, want %s", estimate.Basis, BasisHistorical)
+	}
+	if got := daysUntil(estimate.Finish); got < 4.9 || got > 5.1 {
+		t.Errorf("finish in %.1f days, want 5", got)
+	}
+}
+
+func TestPredictNoHistory(t *testing.T) {
+	if _, ok := Predict(Input{Total: 300, HistoricalPerDay: 40, Now: now}); ok {
+		t.Error("no progress and no start should give no estimate")
+	}
+	estimate, ok := Predict(Input{Total: 300, Started: daysAgo(1), HistoricalPerDay: 40, Now: now})
+	if !ok {
+		t.Fatal("a started book with a usual pace should get an estimate")
+	}
+	if estimate.Finish.Before(now) {
+		t.Errorf("finish %s is in the past", estimate.Finish)
+	}
+}
+
+func TestPredictStalledReading(t *testing.T) {
+	// nothing read in the last month
+	stalled := []Point{{At: daysAgo(40), Value: 0}, {At: daysAgo(30), Value: 150}}
+	if _, ok := Predict(Input{History: stalled, Total: 300, Now: now}); ok {
+		t.Error("stalled reading with no usual pace should give no estimate")
+	}
+	estimate, ok := Predict(Input{History: stalled, Total: 300, HistoricalPerDay: 30, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if estimate.Basis != BasisBlended {
+		t.Errorf("basis = %s, want %s", estimate.Basis, BasisBlended)
+	}
+	if got := daysUntil(estimate.Finish); got <= 150.0/30 {
+		t.Errorf("finish in %.1f days, want later than the %.1f days at the usual pace", got, 150.0/30)
+	}
+}
+
+func TestPredictFinished(t *testing.T) {
+	estimate, ok := Predict(Input{History: steady(3, 100), Total: 300, Now: now})
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if !estimate.Finish.Equal(now) {
+		t.Errorf("finish = %s, want now as all pages are read", estimate.Finish)
+	}
+}
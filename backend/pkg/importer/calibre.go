@@ -0,0 +1,283 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// CalibreBook is one book read from a Calibre library's metadata.db
+type CalibreBook struct {
+	// UUID is Calibre's stable id for the book, re-syncs find the entry by it
+	UUID        string
+	Title       string
+	Authors     []string
+	Description string
+	// ISBN is cleaned, other identifiers are kept as Calibre has them, keyed by type
+	ISBN        string
+	Identifiers map[string]string
+	Series      string
+	SeriesIndex float64
+	Tags        []string
+	// Rating is out of 5, Calibre stores half stars out of 10
+	Rating   int
+	Shelf    Shelf
+	Added    time.Time
+	DateRead time.Time
+}
+
+// CalibreOptions names the custom columns holding read status, empty means the usual names.
+// Calibre has no built in one, people add a yes/no or fixed-value column and call it whatever they like.
+type CalibreOptions struct {
+	// ReadColumn is a yes/no column, or a text/enumeration column with values like read, reading, dnf
+	ReadColumn string
+	// DateReadColumn is a date column
+	DateReadColumn string
+}
+
+// the column names most Calibre guides suggest
+var defaultCalibreOptions = CalibreOptions{ReadColumn: "read", DateReadColumn: "date_read"}
+
+// identifier types worth keeping, others like amazon or mobi-asin are left in Calibre
+var calibreIdentifierTypes = map[string]bool{"isbn": true, "goodreads": true, "google": true}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// ReadCalibreLibrary reads every book from the metadata.db at path. The file is opened read only.
+func ReadCalibreLibrary(path string, options CalibreOptions) ([]CalibreBook, error) {
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query(`SELECT id, uuid, title, CAST(timestamp AS TEXT), series_index FROM books ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("not a Calibre library: %w", err)
+	}
+	byID := map[int64]*CalibreBook{}
+	var order []int64
+	for rows.Next() {
+		var (
+			id          int64
+			uuid        sql.NullString
+			title       string
+			added       sql.NullString
+			seriesIndex sql.NullFloat64
+		)
+		if err := rows.Scan(&id, &uuid, &title, &added, &seriesIndex); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		book := &CalibreBook{
+			UUID:        uuid.String,
+			Title:       strings.TrimSpace(title),
+			Identifiers: map[string]string{},
+			SeriesIndex: seriesIndex.Float64,
+			Shelf:       ShelfToRead,
+			Added:       parseCalibreTime(added.String),
+		}
+		// libraries from very old versions can lack uuids, the id is the next best thing
+		if book.UUID == "" {
+			book.UUID = fmt.Sprintf("id:%d", id)
+		}
+		byID[id] = book
+		order = append(order, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	links := []struct {
+		query string
+		apply func(book *CalibreBook, value string)
+	}{
+		{`SELECT l.book, a.name FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.id`, func(book *CalibreBook, value string) {
+			book.Authors = append(book.Authors, value)
+		}},
+		{`SELECT l.book, s.name FROM books_series_link l JOIN series s ON s.id = l.series`, func(book *CalibreBook, value string) {
+			book.Series = value
+		}},
+		{`SELECT l.book, t.name FROM books_tags_link l JOIN tags t ON t.id = l.tag ORDER BY t.name`, func(book *CalibreBook, value string) {
+			book.Tags = append(book.Tags, value)
+		}},
+		{`SELECT l.book, CAST(r.rating AS TEXT) FROM books_ratings_link l JOIN ratings r ON r.id = l.rating`, func(book *CalibreBook, value string) {
+			var rating int
+			fmt.Sscan(value, &rating)
+			book.Rating = (rating + 1) / 2
+		}},
+		{`SELECT book, text FROM comments`, func(book *CalibreBook, value string) {
+			book.Description = calibreText(value)
+		}},
+		{`SELECT book, type || ':' || val FROM identifiers`, func(book *CalibreBook, value string) {
+			kind, id, _ := strings.Cut(value, ":")
+			kind = strings.ToLower(kind)
+			if kind == "isbn" {
+				book.ISBN = cleanISBN(id)
+			} else if calibreIdentifierTypes[kind] && id != "" {
+				book.Identifiers[kind] = id
+			}
+		}},
+	}
+	for _, link := range links {
+		if err := eachBookValue(conn, link.query, byID, link.apply); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := readCalibreStatus(conn, byID, options); err != nil {
+		return nil, err
+	}
+
+	books := make([]CalibreBook, 0, len(order))
+	for _, id := range order {
+		books = append(books, *byID[id])
+	}
+	return books, nil
+}
+
+func eachBookValue(conn *sql.DB, query string, byID map[int64]*CalibreBook, apply func(*CalibreBook, string)) error {
+	rows, err := conn.Query(query)
+	if err != nil {
+		return fmt.Errorf("not a Calibre library: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			bookID int64
+			value  sql.NullString
+		)
+		if err := rows.Scan(&bookID, &value); err != nil {
+			return err
+		}
+		if book, ok := byID[bookID]; ok && value.Valid {
+			apply(book, value.String)
+		}
+	}
+	return rows.Err()
+}
+
+type calibreColumn struct {
+	id       int64
+	datatype string
+	// normalized columns keep values in their own table and link them to books
+	normalized bool
+}
+
+// calibreColumnByLabel finds a custom column, labels are shown with a # in Calibre but stored without
+func calibreColumnByLabel(conn *sql.DB, label string) (calibreColumn, error) {
+	var column calibreColumn
+	err := conn.QueryRow(
+		`SELECT id, datatype, normalized FROM custom_columns WHERE label = ?`,
+		strings.ToLower(strings.TrimPrefix(label, "#")),
+	).Scan(&column.id, &column.datatype, &column.normalized)
+	return column, err
+}
+
+func readCalibreStatus(conn *sql.DB, byID map[int64]*CalibreBook, options CalibreOptions) error {
+	columns := []struct {
+		label    string
+		fallback string
+		apply    func(column calibreColumn) error
+	}{
+		{options.ReadColumn, defaultCalibreOptions.ReadColumn, func(column calibreColumn) error {
+			switch column.datatype {
+			case "bool", "text", "enumeration":
+			default:
+				return fmt.Errorf("read column must be yes/no, text or fixed value, it is %s", column.datatype)
+			}
+			return eachBookValue(conn, calibreColumnQuery(column), byID, func(book *CalibreBook, value string) {
+				book.Shelf = calibreShelf(value)
+			})
+		}},
+		{options.DateReadColumn, defaultCalibreOptions.DateReadColumn, func(column calibreColumn) error {
+			if column.datatype != "datetime" {
+				return fmt.Errorf("date read column must be a date, it is %s", column.datatype)
+			}
+			return eachBookValue(conn, calibreColumnQuery(column), byID, func(book *CalibreBook, value string) {
+				book.DateRead = parseCalibreTime(value)
+			})
+		}},
+	}
+	for _, c := range columns {
+		label, named := c.label, c.label != ""
+		if !named {
+			label = c.fallback
+		}
+		// a library without custom columns has no custom_columns table, which is fine unless one was asked for
+		column, err := calibreColumnByLabel(conn, label)
+		if err != nil {
+			if !named {
+				continue
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("the Calibre library has no #%s column", strings.TrimPrefix(label, "#"))
+			}
+			return err
+		}
+		if err := c.apply(column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func calibreColumnQuery(column calibreColumn) string {
+	if column.normalized {
+		return fmt.Sprintf(`SELECT l.book, CAST(v.value AS TEXT) FROM books_custom_column_%d_link l JOIN custom_column_%d v ON v.id = l.value`, column.id, column.id)
+	}
+	return fmt.Sprintf(`SELECT book, CAST(value AS TEXT) FROM custom_column_%d`, column.id)
+}
+
+// calibreShelf maps a read column's value, yes/no columns come through as 1 and 0
+func calibreShelf(value string) Shelf {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "read", "finished", "done":
+		return ShelfRead
+	case "reading", "currently reading", "currently-reading", "in progress":
+		return ShelfReading
+	case "dnf", "abandoned", "did not finish", "did-not-finish":
+		return ShelfAbandoned
+	}
+	return ShelfToRead
+}
+
+// parseCalibreTime reads Calibre's timestamps. Calibre writes 0101-01-01 for "no date".
+func parseCalibreTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02T15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+		time.DateOnly,
+	} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			if t.Year() < 1000 {
+				return time.Time{}
+			}
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// calibreText turns a Calibre comment, which is HTML, into plain text
+func calibreText(value string) string {
+	value = strings.NewReplacer("</p>", "\n\n", "<br>", "\n", "<br/>", "\n", "<br />", "\n").Replace(value)
+	value = html.UnescapeString(htmlTag.ReplaceAllString(value, ""))
+	var paragraphs []string
+	for _, paragraph := range strings.Split(value, "\n\n") {
+		if paragraph = strings.Join(strings.Fields(paragraph), " "); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+)
+
+const clippingSource = "kindle"
+
+// ClippingBook says what happened to one book's clippings
+type ClippingBook struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	// Status is created or matched for the book, error when nothing was saved
+	Status    string `json:"status"`
+	MatchedBy string `json:"matched_by,omitempty"`
+	BookID    int64  `json:"book_id,omitempty"`
+	// NewEntry is true when the book was added to the library for these clippings
+	NewEntry   bool   `json:"new_entry"`
+	Added      int    `json:"added"`
+	Duplicates int    `json:"duplicates"`
+	Message    string `json:"message,omitempty"`
+}
+
+// ClippingProblem is a clipping that could not be read
+type ClippingProblem struct {
+	Entry   int    `json:"entry"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+type ClippingsSummary struct {
+	BooksCreated int `json:"books_created"`
+	BooksMatched int `json:"books_matched"`
+	Added        int `json:"added"`
+	Duplicates   int `json:"duplicates"`
+	Errors       int `json:"errors"`
+}
+
+type ClippingsReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Summary  ClippingsSummary  `json:"summary"`
+	Books    []ClippingBook    `json:"books"`
+	Problems []ClippingProblem `json:"problems"`
+}
+
+// ImportClippings saves Kindle clippings as annotations, grouped by book.
+// Books are matched like any other import and added to the library when missing.
+// Each book is its own savepoint, and a dry run rolls everything back.
+func (p *Pipeline) ImportClippings(ctx context.Context, userID int64, clippings []Clipping, dryRun bool) (ClippingsReport, error) {
+	report := ClippingsReport{DryRun: dryRun, Books: []ClippingBook{}, Problems: []ClippingProblem{}}
+
+	type bookKey struct{ title, author string }
+	var order []bookKey
+	byBook := map[bookKey][]Clipping{}
+	for _, clipping := range clippings {
+		if clipping.Problem != "" {
+			report.Problems = append(report.Problems, ClippingProblem{Entry: clipping.Entry, Title: clipping.Title, Message: clipping.Problem})
+			report.Summary.Errors++
+			continue
+		}
+		key := bookKey{clipping.Title, clipping.Author}
+		if _, seen := byBook[key]; !seen {
+			order = append(order, key)
+		}
+		byBook[key] = append(byBook[key], clipping)
+	}
+
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+	qtx := p.store.WithTx(tx)
+
+	for _, key := range order {
+		result := ClippingBook{Title: key.title, Author: key.author}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_book"); err != nil {
+			return report, err
+		}
+		result, err = importBookClippings(ctx, qtx, userID, byBook[key], result)
+		if err != nil {
+			result = ClippingBook{Title: key.title, Author: key.author, Status: StatusError, Message: err.Error()}
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO import_book"); err != nil {
+				return report, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE import_book"); err != nil {
+			return report, err
+		}
+
+		switch result.Status {
+		case StatusCreated:
+			report.Summary.BooksCreated++
+		case StatusMatched:
+			report.Summary.BooksMatched++
+		case StatusError:
+			report.Summary.Errors++
+		}
+		report.Summary.Added += result.Added
+		report.Summary.Duplicates += result.Duplicates
+		report.Books = append(report.Books, result)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+func importBookClippings(ctx context.Context, q *db.Queries, userID int64, clippings []Clipping, result ClippingBook) (ClippingBook, error) {
+	// the first clipping is the best guess for when the book was started
+	var started time.Time
+	for _, clipping := range clippings {
+		if !clipping.AddedAt.IsZero() && (started.IsZero() || clipping.AddedAt.Before(started)) {
+			started = clipping.AddedAt
+		}
+	}
+	record := Record{
+		Source:      clippingSource,
+		Title:       result.Title,
+		Author:      result.Author,
+		Shelf:       ShelfReading,
+		DateAdded:   started,
+		DateStarted: started,
+	}
+
+	book, matchedBy, err := findBook(ctx, q, record)
+	if err != nil {
+		return result, err
+	}
+	if matchedBy == "" {
+		bookID, err := q.CreateBook(ctx, db.CreateBookParams{
+			Isbn:        catalogKey(record),
+			Title:       record.Title,
+			Description: "",
+			Author:      record.Author,
+			ImageUrl:    "",
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to create book: %w", err)
+		}
+		result.Status = StatusCreated
+		result.BookID = bookID
+	} else {
+		result.Status = StatusMatched
+		result.MatchedBy = matchedBy
+		result.BookID = book.ID
+	}
+
+	owned, err := q.UserHasBook(ctx, db.UserHasBookParams{UserID: userID, BookID: result.BookID})
+	if err != nil {
+		return result, err
+	}
+	if owned == 0 {
+		if err := addToLibrary(ctx, q, userID, result.BookID, record); err != nil {
+			return result, err
+		}
+		result.NewEntry = true
+	}
+
+	for _, clipping := range clippings {
+		createdAt := clipping.AddedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		// notes are the reader's own thoughts and stay private, like those imported before
+		added, err := q.ImportAnnotation(ctx, db.ImportAnnotationParams{
+			UserID:    userID,
+			BookID:    result.BookID,
+			Type:      string(clipping.Type),
+			Text:      clipping.Text,
+			Page:      sql.NullInt64{Int64: int64(clipping.Page), Valid: clipping.Page > 0},
+			Location:  sql.NullString{String: clipping.Location, Valid: clipping.Location != ""},
+			Private:   clipping.Type == ClippingNote,
+			CreatedAt: createdAt,
+			Source:    clippingSource,
+			SourceKey: sql.NullString{String: clippingKey(clipping), Valid: true},
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to save clipping %d: %w", clipping.Entry, err)
+		}
+		if added > 0 {
+			result.Added++
+		} else {
+			result.Duplicates++
+		}
+	}
+	return result, nil
+}
+
+// clippingKey identifies a clipping across exports. The time is left out so the
+// same passage highlighted again is still one annotation.
+func clippingKey(clipping Clipping) string {
+	text := strings.Join(strings.Fields(strings.ToLower(clipping.Text)), " ")
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%s|%s", clipping.Type, clipping.Page, clipping.Location, text)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type goodreadsImporter struct{}
+
+func init() {
+	Register(goodreadsImporter{})
+}
+
+func (goodreadsImporter) Name() string {
+	return "goodreads"
+}
+
+// Parse reads the CSV from Goodreads' "Export Library" page
+func (goodreadsImporter) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	return parseTable(reader, "goodreads", []string{"Title", "Author", "Exclusive Shelf"}, goodreadsRecord)
+}
+
+// parseTable reads a delimited export with a header row, handing each row to toRecord
+// with a getter that looks fields up by column name.
+func parseTable(reader *csv.Reader, source string, required []string, toRecord func(int, func(string) string) Record) ([]Record, error) {
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, column := range required {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("not a %s export, the %q column is missing", source, column)
+		}
+	}
+
+	var records []Record
+	row := 1
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row++
+		if err != nil {
+			records = append(records, Record{Source: source, Row: row, Problem: err.Error()})
+			continue
+		}
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+		records = append(records, toRecord(row, get))
+	}
+	return records, nil
+}
+
+func goodreadsRecord(row int, get func(string) string) Record {
+	record := Record{
+		Source:   "goodreads",
+		Row:      row,
+		Title:    get("Title"),
+		Author:   get("Author"),
+		SourceID: get("Book Id"),
+		Shelf:    goodreadsShelf(get("Exclusive Shelf")),
+		Review:   get("My Review"),
+	}
+	if record.Title == "" {
+		record.Problem = "title is empty"
+		return record
+	}
+
+	record.ISBN = cleanISBN(get("ISBN13"))
+	if record.ISBN == "" {
+		record.ISBN = cleanISBN(get("ISBN"))
+	}
+
+	// Goodreads writes 0 for "not rated"
+	if rating := get("My Rating"); rating != "" {
+		n, err := strconv.Atoi(rating)
+		if err != nil || n < 0 || n > 5 {
+			record.Problem = fmt.Sprintf("rating %q is not between 0 and 5", rating)
+			return record
+		}
+		record.Rating = n
+	}
+
+	var err error
+	if record.DateAdded, err = parseExportDate(get("Date Added")); err != nil {
+		record.Problem = fmt.Sprintf("date added: %v", err)
+		return record
+	}
+	if record.DateRead, err = parseExportDate(get("Date Read")); err != nil {
+		record.Problem = fmt.Sprintf("date read: %v", err)
+		return record
+	}
+
+	record.ReadCount, _ = strconv.Atoi(get("Read Count"))
+	if record.Shelf == ShelfRead && record.ReadCount < 1 {
+		record.ReadCount = 1
+	}
+	return record
+}
+
+// goodreadsShelf maps the exclusive shelf, custom shelves only carry meaning through their name
+func goodreadsShelf(shelf string) Shelf {
+	switch strings.ToLower(shelf) {
+	case "read":
+		return ShelfRead
+	case "currently-reading":
+		return ShelfReading
+	case "to-read", "":
+		return ShelfToRead
+	}
+	name := strings.ToLower(shelf)
+	for _, hint := range []string{"dnf", "did-not-finish", "abandon", "gave-up"} {
+		if strings.Contains(name, hint) {
+			return ShelfAbandoned
+		}
+	}
+	return ShelfToRead
+}
+
+// parseExportDate understands the date formats the supported exports use
+func parseExportDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006/01/02", "2006-01-02", "2006/01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date", value)
+}
@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"strings"
+)
+
+// cleanISBN strips spreadsheet quoting, hyphens and spaces from an ISBN.
+// Anything that isn't a plausible ISBN-10 or ISBN-13 comes back empty.
+func cleanISBN(value string) string {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "=")
+	value = strings.Trim(value, `"`)
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == 'X' || r == 'x':
+			digits.WriteRune('X')
+		}
+	}
+	isbn := digits.String()
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return ""
+	}
+	return isbn
+}
+
+// isbnVariants returns the ISBN-13 and ISBN-10 forms of isbn, so a book stored under
+// either form still matches. 979 prefixed ISBN-13s have no ISBN-10.
+func isbnVariants(isbn string) []string {
+	switch len(isbn) {
+	case 10:
+		return []string{isbn10To13(isbn), isbn}
+	case 13:
+		if strings.HasPrefix(isbn, "978") {
+			return []string{isbn, isbn13To10(isbn)}
+		}
+		return []string{isbn}
+	}
+	return nil
+}
+
+func isbn10To13(isbn string) string {
+	body := "978" + isbn[:9]
+	sum := 0
+	for i, r := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	check := (10 - sum%10) % 10
+	return body + string(rune('0'+check))
+}
+
+func isbn13To10(isbn string) string {
+	body := isbn[3:12]
+	sum := 0
+	for i, r := range body {
+		sum += int(r-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X"
+	}
+	return body + string(rune('0'+check))
+}
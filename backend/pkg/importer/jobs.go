@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// Import job statuses
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// batchPause is how long a job waits between batches. SQLite's busy handler only retries every
+// 100ms or so, without a gap other writers would keep missing the moment the lock is free.
+const batchPause = 50 * time.Millisecond
+
+// Jobs runs imports in the background and keeps their state in import_jobs
+type Jobs struct {
+	pipeline *Pipeline
+	store    *db.Queries
+}
+
+func NewJobs(pipeline *Pipeline, store *db.Queries) *Jobs {
+	return &Jobs{
+		pipeline: pipeline,
+		store:    store,
+	}
+}
+
+// Recover marks jobs cut off by a restart as failed, they would otherwise poll as running forever
+func (j *Jobs) Recover(ctx context.Context) error {
+	return j.store.FailInterruptedImportJobs(ctx)
+}
+
+// Start parses data up front, so a file in the wrong format fails the request
+// rather than the job, and then imports it in a goroutine.
+func (j *Jobs) Start(ctx context.Context, userID int64, importer Importer, data []byte, dryRun bool) (int64, error) {
+	records, err := importer.Parse(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	jobID, err := j.store.CreateImportJob(ctx, db.CreateImportJobParams{
+		UserID:    userID,
+		Format:    importer.Name(),
+		DryRun:    dryRun,
+		TotalRows: int64(len(records)),
+	})
+	if err != nil {
+		return 0, err
+	}
+	go j.run(jobID, userID, records, dryRun)
+	return jobID, nil
+}
+
+func (j *Jobs) run(jobID, userID int64, records []Record, dryRun bool) {
+	// the request that started the job is long gone
+	ctx := context.Background()
+	started := time.Now()
+	if err := j.store.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{ID: jobID}); err != nil {
+		log.Error("Failed to start import job %d: %v", jobID, err)
+	}
+
+	// the pipeline calls back between batches, when it isn't holding the write lock
+	report, err := j.pipeline.RunWithProgress(ctx, userID, records, dryRun, func(done int) {
+		if err := j.store.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{
+			ProcessedRows: int64(done),
+			ID:            jobID,
+		}); err != nil {
+			log.Error("Failed to update import job %d: %v", jobID, err)
+		}
+		time.Sleep(batchPause)
+	})
+
+	finish := db.FinishImportJobParams{Status: JobDone, ID: jobID}
+	if err != nil {
+		finish.Status = JobFailed
+		finish.Error = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		encoded, _ := json.Marshal(report)
+		finish.Report = sql.NullString{String: string(encoded), Valid: true}
+		j.store.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{
+			ProcessedRows: int64(len(records)),
+			ID:            jobID,
+		})
+	}
+	if err := j.store.FinishImportJob(ctx, finish); err != nil {
+		log.Error("Failed to finish import job %d: %v", jobID, err)
+		return
+	}
+	log.Info("Import job %d finished as %s in %s", jobID, finish.Status, time.Since(started))
+}
@@ -0,0 +1,278 @@
+package importer
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kindles append every highlight, note and bookmark to "My Clippings.txt":
+//
+//	Dune (Herbert, Frank)
+//	- Your Highlight on page 12 | Location 170-172 | Added on Sunday, March 4, 2018 10:21:53 PM
+//
+//	I must not fear.
+//	==========
+//
+// The header line is translated into the device language, so it is read by keyword.
+
+// ClippingType is what kind of clipping an entry is
+type ClippingType string
+
+const (
+	ClippingHighlight ClippingType = "highlight"
+	ClippingNote      ClippingType = "note"
+	ClippingBookmark  ClippingType = "bookmark"
+)
+
+// Clipping is one entry from a Kindle clippings file
+type Clipping struct {
+	// Entry is the clipping's position in the file, used in the report
+	Entry  int
+	Title  string
+	Author string
+	Type   ClippingType
+	// Page is 0 when the book has no real page numbers
+	Page int
+	// Location is a Kindle location or range, like 170-172
+	Location string
+	AddedAt  time.Time
+	Text     string
+	// Problem is set when the entry could not be understood
+	Problem string
+}
+
+const clippingSeparator = "=========="
+
+// the first words found decide the type, so bookmarks are checked before notes
+var clippingTypeWords = []struct {
+	clippingType ClippingType
+	words        []string
+}{
+	{ClippingBookmark, []string{"bookmark", "lesezeichen", "signet", "marcador", "segnalibro", "bladwijzer"}},
+	{ClippingHighlight, []string{"highlight", "markierung", "surlignement", "subrayado", "evidenziazione", "destaque", "markering"}},
+	{ClippingNote, []string{"note", "notiz", "nota", "notitie"}},
+}
+
+var (
+	clippingDateWords     = []string{"added on", "hinzugefügt am", "ajouté le", "añadido el", "aggiunto in data", "adicionado em", "adicionado:", "toegevoegd op"}
+	clippingLocationWords = []string{"location", "loc.", "position", "emplacement", "posición", "posizione", "posição", "locatie"}
+	clippingPageWords     = []string{"page", "seite", "página", "pagina"}
+)
+
+var clippingMonths = map[string]time.Month{
+	"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+	"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+	// German
+	"januar": time.January, "februar": time.February, "märz": time.March, "mai": time.May,
+	"juni": time.June, "juli": time.July, "oktober": time.October, "dezember": time.December,
+	// French
+	"janvier": time.January, "février": time.February, "mars": time.March, "avril": time.April,
+	"juin": time.June, "juillet": time.July, "août": time.August, "septembre": time.September,
+	"octobre": time.October, "novembre": time.November, "décembre": time.December,
+	// Spanish
+	"enero": time.January, "febrero": time.February, "marzo": time.March, "abril": time.April,
+	"mayo": time.May, "junio": time.June, "julio": time.July, "agosto": time.August,
+	"septiembre": time.September, "setiembre": time.September, "octubre": time.October,
+	"noviembre": time.November, "diciembre": time.December,
+	// Italian
+	"gennaio": time.January, "febbraio": time.February, "aprile": time.April, "maggio": time.May,
+	"giugno": time.June, "luglio": time.July, "settembre": time.September, "ottobre": time.October,
+	"dicembre": time.December,
+	// Portuguese
+	"janeiro": time.January, "fevereiro": time.February, "março": time.March, "maio": time.May,
+	"junho": time.June, "julho": time.July, "setembro": time.September, "outubro": time.October,
+	"dezembro": time.December,
+	// Dutch
+	"januari": time.January, "februari": time.February, "maart": time.March, "mei": time.May,
+	"augustus": time.August,
+}
+
+var clippingNumber = regexp.MustCompile(`(\d+)(?:\s*-\s*(\d+))?`)
+
+// ParseKindleClippings reads a "My Clippings.txt" file
+func ParseKindleClippings(r io.Reader) ([]Clipping, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var clippings []Clipping
+	for i, chunk := range strings.Split(string(data), clippingSeparator) {
+		// every entry starts with a byte order mark on some devices, not just the file
+		chunk = strings.Trim(strings.ReplaceAll(chunk, "\ufeff", ""), "\n")
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		clipping := parseClipping(strings.Split(chunk, "\n"))
+		clipping.Entry = i + 1
+		clippings = append(clippings, clipping)
+	}
+	return clippings, nil
+}
+
+func parseClipping(lines []string) Clipping {
+	var clipping Clipping
+	clipping.Title, clipping.Author = splitClippingTitle(strings.TrimSpace(lines[0]))
+	if clipping.Title == "" {
+		clipping.Problem = "missing title"
+		return clipping
+	}
+	if len(lines) < 2 {
+		clipping.Problem = "missing clipping details"
+		return clipping
+	}
+	if len(lines) > 2 {
+		clipping.Text = strings.TrimSpace(strings.Join(lines[2:], "\n"))
+	}
+
+	header := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[1]), "-")))
+	segments := strings.Split(header, "|")
+	for _, kind := range clippingTypeWords {
+		if containsAny(segments[0], kind.words) {
+			clipping.Type = kind.clippingType
+			break
+		}
+	}
+	if clipping.Type == "" {
+		clipping.Problem = "unrecognised clipping type"
+		return clipping
+	}
+
+	for _, segment := range segments {
+		switch {
+		case containsAny(segment, clippingDateWords):
+			clipping.AddedAt = parseClippingDate(segment)
+		case containsAny(segment, clippingLocationWords):
+			clipping.Location = parseClippingLocation(segment)
+		case containsAny(segment, clippingPageWords):
+			if match := clippingNumber.FindStringSubmatch(segment); match != nil {
+				clipping.Page, _ = strconv.Atoi(match[1])
+			}
+		}
+	}
+
+	if clipping.Type != ClippingBookmark && clipping.Text == "" {
+		clipping.Problem = "clipping has no text"
+	}
+	if strings.HasPrefix(clipping.Text, "<You have reached the clipping limit") {
+		clipping.Problem = "the publisher's clipping limit cut this highlight"
+	}
+	return clipping
+}
+
+// splitClippingTitle splits "Title (Author)" on the last balanced parentheses.
+// Kindle writes authors as "Last, First" and several as "A;B", the first is kept as "First Last".
+func splitClippingTitle(line string) (string, string) {
+	if !strings.HasSuffix(line, ")") {
+		return line, ""
+	}
+	depth := 0
+	for i := len(line) - 1; i >= 0; i-- {
+		switch line[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				title := strings.TrimSpace(line[:i])
+				author := line[i+1 : len(line)-1]
+				if title == "" {
+					return line, ""
+				}
+				return title, clippingAuthor(author)
+			}
+		}
+	}
+	return line, ""
+}
+
+func clippingAuthor(author string) string {
+	author = strings.TrimSpace(strings.Split(author, ";")[0])
+	if last, first, ok := strings.Cut(author, ","); ok && !strings.Contains(first, ",") {
+		return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+	}
+	return author
+}
+
+// parseClippingLocation expands the short form some devices use, 170-72 is 170-172
+func parseClippingLocation(segment string) string {
+	match := clippingNumber.FindStringSubmatch(segment)
+	if match == nil {
+		return ""
+	}
+	start, end := match[1], match[2]
+	if end == "" || end == start {
+		return start
+	}
+	if len(end) < len(start) {
+		end = start[:len(start)-len(end)] + end
+	}
+	return start + "-" + end
+}
+
+// parseClippingDate picks the date apart by token so word order does not matter,
+// "Sunday, March 4, 2018 10:21:53 PM" and "Sonntag, 4. März 2018 22:21:53" both work.
+// A date it cannot read comes back as the zero time.
+func parseClippingDate(segment string) time.Time {
+	var (
+		year, day, hour, minute, second int
+		month                           time.Month
+		pm, am                          bool
+	)
+	for _, token := range strings.Fields(strings.ReplaceAll(segment, ",", " ")) {
+		token = strings.ReplaceAll(token, ".", "")
+		switch {
+		case strings.Contains(token, ":"):
+			parts := strings.Split(token, ":")
+			if len(parts) < 2 {
+				continue
+			}
+			hour, _ = strconv.Atoi(parts[0])
+			minute, _ = strconv.Atoi(parts[1])
+			if len(parts) > 2 {
+				second, _ = strconv.Atoi(parts[2])
+			}
+		case token == "pm":
+			pm = true
+		case token == "am":
+			am = true
+		case clippingMonths[token] != 0:
+			month = clippingMonths[token]
+		default:
+			n, err := strconv.Atoi(token)
+			if err != nil {
+				continue
+			}
+			if len(token) == 4 {
+				year = n
+			} else if day == 0 && len(token) <= 2 {
+				day = n
+			}
+		}
+	}
+	if year == 0 || month == 0 || day == 0 {
+		return time.Time{}
+	}
+	if pm && hour < 12 {
+		hour += 12
+	}
+	if am && hour == 12 {
+		hour = 0
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+}
+
+func containsAny(s string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
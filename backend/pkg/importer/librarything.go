@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type libraryThingImporter struct{}
+
+func init() {
+	Register(libraryThingImporter{})
+}
+
+func (libraryThingImporter) Name() string {
+	return "librarything"
+}
+
+// Parse reads LibraryThing's tab-delimited or JSON export, whichever it is given
+func (libraryThingImporter) Parse(r io.Reader) ([]Record, error) {
+	buffered := bufio.NewReader(r)
+	for {
+		b, err := buffered.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export: %w", err)
+		}
+		if b[0] == ' ' || b[0] == '\n' || b[0] == '\r' || b[0] == '\t' {
+			buffered.ReadByte()
+			continue
+		}
+		if b[0] == '{' || b[0] == '[' {
+			return parseLibraryThingJSON(buffered)
+		}
+		break
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = '\t'
+	return parseTable(reader, "librarything", []string{"Title", "Primary Author"}, libraryThingRecord)
+}
+
+func libraryThingRecord(row int, get func(string) string) Record {
+	return libraryThingEntry{
+		ID:          get("Book Id"),
+		Title:       get("Title"),
+		Author:      get("Primary Author"),
+		ISBN:        get("ISBN"),
+		Rating:      get("Rating"),
+		Review:      get("Review"),
+		EntryDate:   get("Entry Date"),
+		DateStarted: get("Date Started"),
+		DateRead:    get("Date Read"),
+		Collections: strings.Split(get("Collections"), ","),
+	}.record(row)
+}
+
+// libraryThingEntry is the fields both export styles have in common
+type libraryThingEntry struct {
+	ID          string
+	Title       string
+	Author      string
+	ISBN        string
+	Rating      string
+	Review      string
+	EntryDate   string
+	DateStarted string
+	DateRead    string
+	Collections []string
+}
+
+func (e libraryThingEntry) record(row int) Record {
+	record := Record{
+		Source:   "librarything",
+		Row:      row,
+		Title:    e.Title,
+		Author:   lastFirstToName(e.Author),
+		SourceID: e.ID,
+		Review:   e.Review,
+		// the ISBN column looks like [0441013597]
+		ISBN: cleanISBN(strings.Trim(e.ISBN, "[]")),
+	}
+	if record.Title == "" {
+		record.Problem = "title is empty"
+		return record
+	}
+
+	// LibraryThing rates in half stars
+	if e.Rating != "" {
+		stars, err := strconv.ParseFloat(e.Rating, 64)
+		if err != nil || stars < 0 || stars > 5 {
+			record.Problem = fmt.Sprintf("rating %q is not between 0 and 5", e.Rating)
+			return record
+		}
+		record.Rating = int(stars + 0.5)
+	}
+
+	var err error
+	if record.DateAdded, err = parseExportDate(e.EntryDate); err != nil {
+		record.Problem = fmt.Sprintf("entry date: %v", err)
+		return record
+	}
+	if record.DateStarted, err = parseExportDate(e.DateStarted); err != nil {
+		record.Problem = fmt.Sprintf("date started: %v", err)
+		return record
+	}
+	if record.DateRead, err = parseExportDate(e.DateRead); err != nil {
+		record.Problem = fmt.Sprintf("date read: %v", err)
+		return record
+	}
+
+	// collections decide the shelf, LibraryThing has no single read status
+	collections := map[string]bool{}
+	for _, collection := range e.Collections {
+		collections[strings.ToLower(strings.TrimSpace(collection))] = true
+	}
+	switch {
+	case collections["currently reading"]:
+		record.Shelf = ShelfReading
+	case collections["to read"], collections["wishlist"]:
+		record.Shelf = ShelfToRead
+	case !record.DateRead.IsZero(), collections["read but unowned"], record.Rating > 0:
+		record.Shelf = ShelfRead
+		record.ReadCount = 1
+	default:
+		record.Shelf = ShelfToRead
+	}
+	return record
+}
+
+// lastFirstToName turns "Herbert, Frank" into "Frank Herbert"
+func lastFirstToName(name string) string {
+	last, first, ok := strings.Cut(name, ",")
+	if !ok {
+		return strings.TrimSpace(name)
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// the JSON export is an object keyed by book id, fields vary in shape between books
+type libraryThingJSONBook struct {
+	BooksID       string          `json:"books_id"`
+	Title         string          `json:"title"`
+	PrimaryAuthor string          `json:"primaryauthor"`
+	Rating        json.Number     `json:"rating"`
+	Review        string          `json:"review"`
+	EntryDate     string          `json:"entrydate"`
+	DateStarted   string          `json:"datestarted"`
+	DateFinished  string          `json:"datefinished"`
+	OriginalISBN  string          `json:"originalisbn"`
+	ISBN          json.RawMessage `json:"isbn"`
+	Collections   json.RawMessage `json:"collections"`
+}
+
+func parseLibraryThingJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var books map[string]libraryThingJSONBook
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var list []libraryThingJSONBook
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("not a librarything export: %w", err)
+		}
+		books = map[string]libraryThingJSONBook{}
+		for i, book := range list {
+			books[strconv.Itoa(i)] = book
+		}
+	} else if err := json.Unmarshal(data, &books); err != nil {
+		return nil, fmt.Errorf("not a librarything export: %w", err)
+	}
+
+	// map order is random, keep the report stable
+	keys := make([]string, 0, len(books))
+	for key := range books {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var records []Record
+	for i, key := range keys {
+		book := books[key]
+		id := book.BooksID
+		if id == "" {
+			id = key
+		}
+		isbn := book.OriginalISBN
+		if isbn == "" {
+			isbn = firstJSONString(book.ISBN)
+		}
+		records = append(records, libraryThingEntry{
+			ID:          id,
+			Title:       book.Title,
+			Author:      book.PrimaryAuthor,
+			ISBN:        isbn,
+			Rating:      book.Rating.String(),
+			Review:      book.Review,
+			EntryDate:   book.EntryDate,
+			DateStarted: book.DateStarted,
+			DateRead:    book.DateFinished,
+			Collections: jsonStrings(book.Collections),
+		}.record(i+1))
+	}
+	return records, nil
+}
+
+// jsonStrings reads a string, a list of strings or an object of strings
+func jsonStrings(raw json.RawMessage) []string {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return []string{one}
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var object map[string]string
+	if json.Unmarshal(raw, &object) == nil {
+		var values []string
+		for _, value := range object {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return values
+	}
+	return nil
+}
+
+func firstJSONString(raw json.RawMessage) string {
+	values := jsonStrings(raw)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,364 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+)
+
+// Row statuses in an import report
+const (
+	StatusCreated = "created"
+	StatusMatched = "matched"
+	StatusSkipped = "skipped"
+	// StatusUpdated is a book synced before, brought up to date
+	StatusUpdated = "updated"
+	// StatusUnchanged is a book synced before that was already up to date
+	StatusUnchanged = "unchanged"
+	StatusError     = "error"
+)
+
+// read statuses, the same values handlers uses for the reads table
+const (
+	readStatusReading   = "reading"
+	readStatusFinished  = "finished"
+	readStatusAbandoned = "abandoned"
+)
+
+// RowResult says what happened to one record
+type RowResult struct {
+	Row    int    `json:"row"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Status string `json:"status"`
+	// MatchedBy is isbn or title_author when an existing book was found
+	MatchedBy string `json:"matched_by,omitempty"`
+	BookID    int64  `json:"book_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type Summary struct {
+	Created   int `json:"created"`
+	Matched   int `json:"matched"`
+	Skipped   int `json:"skipped"`
+	Updated   int `json:"updated,omitempty"`
+	Unchanged int `json:"unchanged,omitempty"`
+	Errors    int `json:"errors"`
+}
+
+type Report struct {
+	DryRun  bool        `json:"dry_run"`
+	Summary Summary     `json:"summary"`
+	Rows    []RowResult `json:"rows"`
+}
+
+// Pipeline matches records against the catalog and adds them to a user's library
+type Pipeline struct {
+	conn  *sql.DB
+	store *db.Queries
+}
+
+func NewPipeline(conn *sql.DB, store *db.Queries) *Pipeline {
+	return &Pipeline{
+		conn:  conn,
+		store: store,
+	}
+}
+
+// importBatch is how many rows are imported per transaction. The write lock is let go between
+// batches, so other requests and the job's progress get written while a large import runs.
+const importBatch = 100
+
+// Run imports records for a user in batches, each its own transaction, and each row its own
+// savepoint, so a bad row is reported without undoing the others. A batch that fails leaves the
+// ones before it imported. A dry run does all the same work and rolls every batch back, so it
+// checks each batch against the library as it was before the import.
+func (p *Pipeline) Run(ctx context.Context, userID int64, records []Record, dryRun bool) (Report, error) {
+	return p.RunWithProgress(ctx, userID, records, dryRun, nil)
+}
+
+// RunWithProgress is Run calling progress, when not nil, with the number of rows done after each batch
+func (p *Pipeline) RunWithProgress(ctx context.Context, userID int64, records []Record, dryRun bool, progress func(done int)) (Report, error) {
+	report := Report{DryRun: dryRun, Rows: []RowResult{}}
+	for start := 0; start < len(records); start += importBatch {
+		batch := records[start:min(start+importBatch, len(records))]
+		if err := p.runBatch(ctx, userID, batch, dryRun, &report); err != nil {
+			return report, err
+		}
+		if progress != nil {
+			progress(len(report.Rows))
+		}
+	}
+	return report, nil
+}
+
+func (p *Pipeline) runBatch(ctx context.Context, userID int64, records []Record, dryRun bool, report *Report) error {
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := p.store.WithTx(tx)
+
+	for _, record := range records {
+		result := RowResult{Row: record.Row, Title: record.Title, Author: record.Author}
+		if record.Problem != "" {
+			result.Status = StatusError
+			result.Message = record.Problem
+		} else {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+				return err
+			}
+			result, err = p.importRecord(ctx, qtx, userID, record, result)
+			if err != nil {
+				result.Status = StatusError
+				result.Message = err.Error()
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO import_row"); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, "RELEASE import_row"); err != nil {
+				return err
+			}
+		}
+
+		switch result.Status {
+		case StatusCreated:
+			report.Summary.Created++
+		case StatusMatched:
+			report.Summary.Matched++
+		case StatusSkipped:
+			report.Summary.Skipped++
+		case StatusError:
+			report.Summary.Errors++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if dryRun {
+		return nil
+	}
+	return tx.Commit()
+}
+
+func (p *Pipeline) importRecord(ctx context.Context, q *db.Queries, userID int64, record Record, result RowResult) (RowResult, error) {
+	book, matchedBy, err := findBook(ctx, q, record)
+	if err != nil {
+		return result, err
+	}
+
+	if matchedBy == "" {
+		bookID, err := q.CreateBook(ctx, db.CreateBookParams{
+			Isbn:        catalogKey(record),
+			Title:       record.Title,
+			Description: "",
+			Author:      record.Author,
+			ImageUrl:    coverURL(record.ISBN),
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to create book: %w", err)
+		}
+		result.Status = StatusCreated
+		result.BookID = bookID
+	} else {
+		owned, err := q.UserHasBook(ctx, db.UserHasBookParams{UserID: userID, BookID: book.ID})
+		if err != nil {
+			return result, err
+		}
+		result.BookID = book.ID
+		result.MatchedBy = matchedBy
+		if owned > 0 {
+			result.Status = StatusSkipped
+			result.Message = "already in your library"
+			return result, nil
+		}
+		result.Status = StatusMatched
+	}
+
+	if err := addToLibrary(ctx, q, userID, result.BookID, record); err != nil {
+		return result, err
+	}
+	if err := addTitleSeries(ctx, q, result.BookID, record.Title); err != nil {
+		return result, fmt.Errorf("failed to add series: %w", err)
+	}
+	return result, nil
+}
+
+// addTitleSeries puts a book in the series named in its title, unless it's already there
+func addTitleSeries(ctx context.Context, q *db.Queries, bookID int64, title string) error {
+	name, position, ok := seriesFromTitle(title)
+	if !ok {
+		return nil
+	}
+	seriesID, err := q.UpsertSeries(ctx, name)
+	if err != nil {
+		return err
+	}
+	return q.AddSeriesBook(ctx, db.AddSeriesBookParams{
+		SeriesID: seriesID,
+		BookID:   bookID,
+		Position: sql.NullFloat64{Float64: position, Valid: true},
+	})
+}
+
+// findBook looks for the record's book by ISBN first and then by title and author
+func findBook(ctx context.Context, q *db.Queries, record Record) (db.Book, string, error) {
+	for _, isbn := range isbnVariants(record.ISBN) {
+		book, err := q.GetBookByIsbn(ctx, isbn)
+		if err == nil {
+			return book, "isbn", nil
+		}
+		if err != sql.ErrNoRows {
+			return db.Book{}, "", err
+		}
+	}
+
+	for _, title := range titleVariants(record.Title) {
+		book, err := q.FindBookByTitleAuthor(ctx, db.FindBookByTitleAuthorParams{
+			Title:  title,
+			Author: record.Author,
+		})
+		if err == nil {
+			return book, "title_author", nil
+		}
+		if err != sql.ErrNoRows {
+			return db.Book{}, "", err
+		}
+	}
+
+	// a book imported earlier without an ISBN is stored under its source id
+	if record.SourceID != "" {
+		book, err := q.GetBookByIsbn(ctx, catalogKey(record))
+		if err == nil {
+			return book, "source_id", nil
+		}
+		if err != sql.ErrNoRows {
+			return db.Book{}, "", err
+		}
+	}
+	return db.Book{}, "", nil
+}
+
+// addToLibrary creates the library entry and one read per time the book was read
+func addToLibrary(ctx context.Context, q *db.Queries, userID, bookID int64, record Record) error {
+	rating := sql.NullInt64{Int64: int64(record.Rating), Valid: record.Rating > 0}
+	review := sql.NullString{String: record.Review, Valid: record.Review != ""}
+	added := nullTime(record.DateAdded)
+	if !added.Valid {
+		added = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	entry := db.ImportUserBookParams{
+		UserID:  userID,
+		BookID:  bookID,
+		Rating:  rating,
+		Review:  review,
+		AddedAt: added,
+	}
+	started := nullTime(record.DateStarted)
+	if !started.Valid && record.Shelf != ShelfRead {
+		started = added
+	}
+	switch record.Shelf {
+	case ShelfRead:
+		entry.StartDate = started
+		entry.FinishDate = nullTime(record.DateRead)
+	case ShelfReading, ShelfAbandoned:
+		entry.StartDate = started
+	}
+	if err := q.ImportUserBook(ctx, entry); err != nil {
+		return fmt.Errorf("failed to add to library: %w", err)
+	}
+
+	var reads []db.ImportReadParams
+	switch record.Shelf {
+	case ShelfRead:
+		// only the latest read has a known date, earlier ones are recorded undated
+		for i := 1; i < record.ReadCount; i++ {
+			reads = append(reads, db.ImportReadParams{Status: readStatusFinished})
+		}
+		reads = append(reads, db.ImportReadParams{
+			StartDate:  started,
+			FinishDate: nullTime(record.DateRead),
+			Status:     readStatusFinished,
+			Rating:     rating,
+			Review:     review,
+		})
+	case ShelfReading:
+		reads = append(reads, db.ImportReadParams{StartDate: started, Status: readStatusReading})
+	case ShelfAbandoned:
+		reads = append(reads, db.ImportReadParams{StartDate: started, Status: readStatusAbandoned, Rating: rating, Review: review})
+	}
+	for _, read := range reads {
+		read.UserID = userID
+		read.BookID = bookID
+		if _, err := q.ImportRead(ctx, read); err != nil {
+			return fmt.Errorf("failed to record read: %w", err)
+		}
+	}
+	return nil
+}
+
+var (
+	seriesSuffix      = regexp.MustCompile(`\s*\([^()]*#[^()]*\)\s*$`)
+	parentheticSuffix = regexp.MustCompile(`\s*\([^()]*\)\s*$`)
+	// seriesPosition is the series and position in a Goodreads style suffix, a range like #1-3 is
+	// an omnibus and has no single position
+	seriesPosition = regexp.MustCompile(`\(([^()#]+?),?\s*#(\d+(?:\.\d+)?)\)\s*$`)
+)
+
+// seriesFromTitle reads the series from a Goodreads style "Title (Series, #2.5)"
+func seriesFromTitle(title string) (string, float64, bool) {
+	match := seriesPosition.FindStringSubmatch(title)
+	if match == nil {
+		return "", 0, false
+	}
+	position, err := strconv.ParseFloat(match[2], 64)
+	name := strings.TrimSpace(match[1])
+	if err != nil || position <= 0 || name == "" {
+		return "", 0, false
+	}
+	return name, position, true
+}
+
+// titleVariants is the title as given and, for Goodreads style "Title (Series, #2)", without the series.
+// Kindle style "Title (Series Book 2)" is tried last without any trailing parentheses.
+func titleVariants(title string) []string {
+	variants := []string{title}
+	for _, suffix := range []*regexp.Regexp{seriesSuffix, parentheticSuffix} {
+		stripped := suffix.ReplaceAllString(title, "")
+		if stripped != "" && stripped != variants[len(variants)-1] {
+			variants = append(variants, stripped)
+		}
+	}
+	return variants
+}
+
+// catalogKey is what goes in books.isbn, which has to be unique and not null.
+// Books without an ISBN are keyed by their id on the source service instead.
+func catalogKey(record Record) string {
+	if record.ISBN != "" {
+		return record.ISBN
+	}
+	if record.SourceID != "" {
+		return record.Source + ":" + record.SourceID
+	}
+	return record.Source + ":" + strings.ToLower(record.Title+"/"+record.Author)
+}
+
+func coverURL(isbn string) string {
+	if isbn == "" {
+		return ""
+	}
+	return "https://covers.openlibrary.org/b/isbn/" + isbn + "-L.jpg"
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
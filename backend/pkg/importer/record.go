@@ -0,0 +1,40 @@
+package importer
+
+// this package brings libraries exported from other services into booktrackr
+import (
+	"time"
+)
+
+// Shelf is where a record sits in the user's library, normalised across services
+type Shelf string
+
+const (
+	ShelfRead      Shelf = "read"
+	ShelfReading   Shelf = "currently-reading"
+	ShelfToRead    Shelf = "to-read"
+	ShelfAbandoned Shelf = "abandoned"
+)
+
+// Record is one library entry read from an export file
+type Record struct {
+	// Source names the service the export came from, like goodreads
+	Source string
+	// Row is the record's line in the source file, used in the report
+	Row    int
+	Title  string
+	Author string
+	// ISBN is the ISBN-13 when the export has one, otherwise the ISBN-10
+	ISBN string
+	// SourceID is the book's id on the service the export came from
+	SourceID  string
+	Shelf     Shelf
+	Rating    int
+	DateAdded time.Time
+	// DateStarted and DateRead belong to the most recent read
+	DateStarted time.Time
+	DateRead    time.Time
+	Review      string
+	ReadCount   int
+	// Problem is set when the row could not be understood, the pipeline reports it as an error
+	Problem string
+}
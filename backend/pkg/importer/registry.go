@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// Importer reads one service's export format into normalised records.
+// Formats register themselves in init so the pipeline never needs to know about them.
+type Importer interface {
+	// Name is the format's id in URLs, like goodreads
+	Name() string
+	Parse(r io.Reader) ([]Record, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Importer{}
+)
+
+// Register makes an importer available by its name, registering a name twice panics
+func Register(importer Importer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[importer.Name()]; exists {
+		panic("importer: " + importer.Name() + " registered twice")
+	}
+	registry[importer.Name()] = importer
+}
+
+// Lookup finds a registered importer by name
+func Lookup(name string) (Importer, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	importer, ok := registry[name]
+	return importer, ok
+}
+
+// Formats lists the registered importer names in alphabetical order
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
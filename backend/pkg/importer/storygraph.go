@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type storyGraphImporter struct{}
+
+func init() {
+	Register(storyGraphImporter{})
+}
+
+func (storyGraphImporter) Name() string {
+	return "storygraph"
+}
+
+// Parse reads the CSV from StoryGraph's "Export StoryGraph Library" page
+func (storyGraphImporter) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	return parseTable(reader, "storygraph", []string{"Title", "Authors", "Read Status"}, storyGraphRecord)
+}
+
+func storyGraphRecord(row int, get func(string) string) Record {
+	record := Record{
+		Source: "storygraph",
+		Row:    row,
+		Title:  get("Title"),
+		// co-authors are comma separated, the first is the primary author
+		Author: strings.TrimSpace(strings.Split(get("Authors"), ",")[0]),
+		Review: get("Review"),
+	}
+	if record.Title == "" {
+		record.Problem = "title is empty"
+		return record
+	}
+	// ISBN/UID holds a StoryGraph id when the edition has no ISBN
+	if uid := get("ISBN/UID"); uid != "" {
+		record.ISBN = cleanISBN(uid)
+		if record.ISBN == "" {
+			record.SourceID = uid
+		}
+	}
+
+	switch strings.ToLower(get("Read Status")) {
+	case "read":
+		record.Shelf = ShelfRead
+	case "currently-reading":
+		record.Shelf = ShelfReading
+	case "did-not-finish":
+		record.Shelf = ShelfAbandoned
+	default:
+		record.Shelf = ShelfToRead
+	}
+
+	// StoryGraph rates in quarter stars
+	if rating := get("Star Rating"); rating != "" {
+		stars, err := strconv.ParseFloat(rating, 64)
+		if err != nil || stars < 0 || stars > 5 {
+			record.Problem = fmt.Sprintf("star rating %q is not between 0 and 5", rating)
+			return record
+		}
+		record.Rating = int(math.Round(stars))
+		if record.Rating == 0 && stars > 0 {
+			record.Rating = 1
+		}
+	}
+
+	var err error
+	if record.DateAdded, err = parseExportDate(get("Date Added")); err != nil {
+		record.Problem = fmt.Sprintf("date added: %v", err)
+		return record
+	}
+	if record.DateRead, err = parseExportDate(get("Last Date Read")); err != nil {
+		record.Problem = fmt.Sprintf("last date read: %v", err)
+		return record
+	}
+
+	record.ReadCount, _ = strconv.Atoi(get("Read Count"))
+	if record.Shelf == ShelfRead && record.ReadCount < 1 {
+		record.ReadCount = 1
+	}
+	return record
+}
@@ -0,0 +1,301 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"booktrackr/db"
+)
+
+const calibreSource = "calibre"
+
+// SyncCalibre brings a Calibre library into a user's library. Books synced before are found by
+// their Calibre uuid and updated in place, so syncing the same library again changes nothing and
+// reports them as unchanged.
+// Like Run, each book is its own savepoint and a dry run rolls everything back.
+func (p *Pipeline) SyncCalibre(ctx context.Context, userID int64, books []CalibreBook, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Rows: []RowResult{}}
+
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+	qtx := p.store.WithTx(tx)
+
+	for i, book := range books {
+		result := RowResult{Row: i + 1, Title: book.Title}
+		if len(book.Authors) > 0 {
+			result.Author = book.Authors[0]
+		}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+			return report, err
+		}
+		result, err = syncCalibreBook(ctx, qtx, userID, book, result)
+		if err != nil {
+			result.Status = StatusError
+			result.Message = err.Error()
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO import_row"); err != nil {
+				return report, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE import_row"); err != nil {
+			return report, err
+		}
+
+		switch result.Status {
+		case StatusCreated:
+			report.Summary.Created++
+		case StatusMatched:
+			report.Summary.Matched++
+		case StatusUpdated:
+			report.Summary.Updated++
+		case StatusUnchanged:
+			report.Summary.Unchanged++
+		case StatusError:
+			report.Summary.Errors++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+func syncCalibreBook(ctx context.Context, q *db.Queries, userID int64, book CalibreBook, result RowResult) (RowResult, error) {
+	if book.Title == "" {
+		return result, fmt.Errorf("book has no title")
+	}
+	record := Record{
+		Source:    calibreSource,
+		Title:     book.Title,
+		Author:    result.Author,
+		ISBN:      book.ISBN,
+		SourceID:  book.UUID,
+		Shelf:     book.Shelf,
+		Rating:    book.Rating,
+		DateAdded: book.Added,
+		DateRead:  book.DateRead,
+		ReadCount: 1,
+	}
+
+	bookID, err := q.GetExternalBook(ctx, db.GetExternalBookParams{UserID: userID, Source: calibreSource, ExternalID: book.UUID})
+	owned := err == nil
+	switch {
+	case err == nil:
+		result.Status = StatusUpdated
+	case err != sql.ErrNoRows:
+		return result, err
+	default:
+		found, matchedBy, err := findCalibreBook(ctx, q, record, book.Identifiers)
+		if err != nil {
+			return result, err
+		}
+		if matchedBy == "" {
+			bookID, err = q.CreateBook(ctx, db.CreateBookParams{
+				Isbn:        catalogKey(record),
+				Title:       record.Title,
+				Description: book.Description,
+				Author:      record.Author,
+				ImageUrl:    coverURL(record.ISBN),
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to create book: %w", err)
+			}
+			result.Status = StatusCreated
+		} else {
+			bookID = found.ID
+			result.Status = StatusMatched
+			result.MatchedBy = matchedBy
+			// it may already be in the library from somewhere else
+			count, err := q.UserHasBook(ctx, db.UserHasBookParams{UserID: userID, BookID: bookID})
+			if err != nil {
+				return result, err
+			}
+			owned = count > 0
+		}
+	}
+	result.BookID = bookID
+
+	changed := true
+	if owned {
+		changed, err = updateFromCalibre(ctx, q, userID, bookID, record)
+	} else {
+		err = addToLibrary(ctx, q, userID, bookID, record)
+	}
+	if err != nil {
+		return result, err
+	}
+	err = q.LinkExternalBook(ctx, db.LinkExternalBookParams{UserID: userID, Source: calibreSource, ExternalID: book.UUID, BookID: bookID})
+	if err != nil {
+		return result, err
+	}
+	metadataChanged, err := saveCalibreMetadata(ctx, q, userID, bookID, book)
+	if err != nil {
+		return result, err
+	}
+	if result.Status == StatusUpdated && !changed && !metadataChanged {
+		result.Status = StatusUnchanged
+	}
+	return result, nil
+}
+
+// findCalibreBook also tries the Goodreads and Google ids Calibre keeps, before title and author
+func findCalibreBook(ctx context.Context, q *db.Queries, record Record, identifiers map[string]string) (db.Book, string, error) {
+	for _, isbn := range isbnVariants(record.ISBN) {
+		book, err := q.GetBookByIsbn(ctx, isbn)
+		if err == nil {
+			return book, "isbn", nil
+		}
+		if err != sql.ErrNoRows {
+			return db.Book{}, "", err
+		}
+	}
+	for _, kind := range []string{"goodreads", "google"} {
+		if identifiers[kind] == "" {
+			continue
+		}
+		book, err := q.GetBookByIdentifier(ctx, db.GetBookByIdentifierParams{Type: kind, Value: identifiers[kind]})
+		if err == nil {
+			return book, kind, nil
+		}
+		if err != sql.ErrNoRows {
+			return db.Book{}, "", err
+		}
+	}
+	return findBook(ctx, q, record)
+}
+
+// updateFromCalibre applies Calibre's rating and read status to an entry already in the library.
+// Calibre only knows the latest state, so reads are added or finished but never removed.
+// It reports whether anything changed.
+func updateFromCalibre(ctx context.Context, q *db.Queries, userID, bookID int64, record Record) (bool, error) {
+	read, err := q.GetCurrentRead(ctx, db.GetCurrentReadParams{UserID: userID, BookID: bookID})
+	hasRead := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	rating := sql.NullInt64{Int64: int64(record.Rating), Valid: record.Rating > 0}
+	finished := nullTime(record.DateRead)
+
+	readsChanged := false
+	switch {
+	case !hasRead && record.Shelf != ShelfToRead:
+		status := readStatusReading
+		switch record.Shelf {
+		case ShelfRead:
+			status = readStatusFinished
+		case ShelfAbandoned:
+			status = readStatusAbandoned
+		}
+		params := db.ImportReadParams{UserID: userID, BookID: bookID, Status: status, Rating: rating}
+		if status == readStatusFinished {
+			params.FinishDate = finished
+		} else {
+			params.StartDate = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+		if _, err := q.ImportRead(ctx, params); err != nil {
+			return false, err
+		}
+		readsChanged = true
+	case hasRead:
+		unchanged := db.UpdateCurrentReadParams{
+			StartDate:  read.StartDate,
+			FinishDate: read.FinishDate,
+			Status:     read.Status,
+			Rating:     read.Rating,
+			Review:     read.Review,
+			UserID:     userID,
+			BookID:     bookID,
+		}
+		update := unchanged
+		if record.Shelf == ShelfRead {
+			update.Status = readStatusFinished
+			if finished.Valid && !finished.Time.Equal(update.FinishDate.Time) {
+				update.FinishDate = finished
+			} else if !update.FinishDate.Valid {
+				update.FinishDate = sql.NullTime{Time: time.Now(), Valid: true}
+			}
+		}
+		if record.Shelf == ShelfAbandoned && read.Status == readStatusReading {
+			update.Status = readStatusAbandoned
+		}
+		if rating.Valid {
+			update.Rating = rating
+		}
+		// compared field by field, a time read back from the database is never == a parsed one
+		if update != unchanged {
+			if err := q.UpdateCurrentRead(ctx, update); err != nil {
+				return false, err
+			}
+			readsChanged = true
+		}
+	}
+
+	if readsChanged {
+		return true, q.SyncUserBookWithCurrentRead(ctx, db.SyncUserBookWithCurrentReadParams{UserID: userID, BookID: bookID})
+	}
+	if rating.Valid && !hasRead {
+		updated, err := q.SetUserBookRating(ctx, db.SetUserBookRatingParams{Rating: rating, UserID: userID, BookID: bookID})
+		return updated > 0, err
+	}
+	return false, nil
+}
+
+// saveCalibreMetadata stores identifiers, series and tags and reports whether any of them changed.
+// Tags from Calibre replace the ones an earlier sync added, tags the user added in booktrackr are kept.
+func saveCalibreMetadata(ctx context.Context, q *db.Queries, userID, bookID int64, book CalibreBook) (bool, error) {
+	changed := false
+	for kind, value := range book.Identifiers {
+		added, err := q.AddBookIdentifier(ctx, db.AddBookIdentifierParams{BookID: bookID, Type: kind, Value: value})
+		if err != nil {
+			return false, err
+		}
+		changed = changed || added > 0
+	}
+	if book.Series != "" {
+		seriesID, err := q.UpsertSeries(ctx, book.Series)
+		if err != nil {
+			return false, err
+		}
+		set, err := q.SetSeriesBook(ctx, db.SetSeriesBookParams{
+			SeriesID: seriesID,
+			BookID:   bookID,
+			Position: sql.NullFloat64{Float64: book.SeriesIndex, Valid: book.SeriesIndex > 0},
+		})
+		if err != nil {
+			return false, err
+		}
+		changed = changed || set > 0
+	}
+
+	tags := []string{}
+	for _, tag := range book.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	synced, err := q.ListSourceTags(ctx, db.ListSourceTagsParams{UserID: userID, BookID: bookID, Source: calibreSource})
+	if err != nil {
+		return false, err
+	}
+	if slices.Equal(tags, synced) {
+		return changed, nil
+	}
+	if err := q.ClearSourceTags(ctx, db.ClearSourceTagsParams{UserID: userID, BookID: bookID, Source: calibreSource}); err != nil {
+		return false, err
+	}
+	for _, tag := range tags {
+		if err := q.AddUserBookTag(ctx, db.AddUserBookTagParams{UserID: userID, BookID: bookID, Tag: tag, Source: calibreSource}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
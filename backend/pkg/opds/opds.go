@@ -0,0 +1,137 @@
+package opds
+
+// this package holds the Atom documents of an OPDS 1.2 catalog, see https://specs.opds.io/opds-1.2
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// media types of catalog documents
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OpenSearchType  = "application/opensearchdescription+xml"
+)
+
+// link relations used by the catalog
+const (
+	RelSelf       = "self"
+	RelStart      = "start"
+	RelUp         = "up"
+	RelSubsection = "subsection"
+	RelSearch     = "search"
+	RelFirst      = "first"
+	RelNext       = "next"
+	RelAlternate  = "alternate"
+	RelImage      = "http://opds-spec.org/image"
+	RelThumbnail  = "http://opds-spec.org/image/thumbnail"
+)
+
+type Link struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type Author struct {
+	Name string `xml:"name"`
+}
+
+type Content struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type Category struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+// Entry is a navigation entry when it links to another feed, or a book in an acquisition feed
+type Entry struct {
+	Title      string     `xml:"title"`
+	ID         string     `xml:"id"`
+	Updated    string     `xml:"updated"`
+	Authors    []Author   `xml:"author"`
+	Identifier string     `xml:"dc:identifier,omitempty"`
+	Issued     string     `xml:"dc:issued,omitempty"`
+	Categories []Category `xml:"category"`
+	Summary    string     `xml:"summary,omitempty"`
+	Content    *Content   `xml:"content"`
+	Links      []Link     `xml:"link"`
+}
+
+type Feed struct {
+	XMLName      xml.Name `xml:"feed"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	XmlnsDC      string   `xml:"xmlns:dc,attr"`
+	XmlnsOS      string   `xml:"xmlns:opensearch,attr"`
+	XmlnsOPDS    string   `xml:"xmlns:opds,attr"`
+	ID           string   `xml:"id"`
+	Title        string   `xml:"title"`
+	Updated      string   `xml:"updated"`
+	Author       Author   `xml:"author"`
+	Links        []Link   `xml:"link"`
+	TotalResults int64    `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage int64    `xml:"opensearch:itemsPerPage,omitempty"`
+	Entries      []Entry  `xml:"entry"`
+}
+
+// NewFeed starts a feed with the namespaces every catalog document declares
+func NewFeed(id, title string, updated time.Time) *Feed {
+	return &Feed{
+		Xmlns:     "http://www.w3.org/2005/Atom",
+		XmlnsDC:   "http://purl.org/dc/terms/",
+		XmlnsOS:   "http://a9.com/-/spec/opensearch/1.1/",
+		XmlnsOPDS: "http://opds-spec.org/2010/catalog",
+		ID:        id,
+		Title:     title,
+		Updated:   Time(updated),
+		Author:    Author{Name: "booktrackr"},
+	}
+}
+
+// OpenSearchDescription tells readers how to search the catalog
+type OpenSearchDescription struct {
+	XMLName        xml.Name `xml:"OpenSearchDescription"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	ShortName      string   `xml:"ShortName"`
+	Description    string   `xml:"Description"`
+	InputEncoding  string   `xml:"InputEncoding"`
+	OutputEncoding string   `xml:"OutputEncoding"`
+	URL            struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+// NewOpenSearchDescription describes a search whose template has a {searchTerms} placeholder
+func NewOpenSearchDescription(template string) *OpenSearchDescription {
+	description := &OpenSearchDescription{
+		Xmlns:          "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:      "booktrackr",
+		Description:    "Search your booktrackr library",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+	}
+	description.URL.Type = AcquisitionType
+	description.URL.Template = template
+	return description
+}
+
+// Time formats a time the way Atom wants it
+func Time(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Write writes an XML document with its declaration
+func Write(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
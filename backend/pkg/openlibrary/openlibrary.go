@@ -1,11 +1,37 @@
 package openlibrary
 
+// this package looks editions up in Open Library, which knows the series of many books that
+// Google Books doesn't name
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const baseURL = "https://openlibrary.org"
+
+var ErrNotFound = errors.New("book not found in Open Library")
+
+// seriesNumber splits the number off series like "The Expanse ; 1", "Discworld -- 3",
+// "Dune Chronicles, #2" or "Harry Potter (Book 1)". A hyphen alone doesn't, so Catch-22 stays whole.
+var seriesNumber = regexp.MustCompile(`(?i)^(.+?)\s*(?:;|,|--|\(|#)\s*(?:#|no\.|vol\.|volume|book)?\s*(\d+(?:\.\d+)?)\s*\)?$`)
+
+// Book is an Open Library edition. Editions only link their authors, so Author is left empty.
+// SeriesPosition is 0 when the edition doesn't number its series.
 type Book struct {
-	ID          string `json:"id"`
-	Isbn        string `json:"isbn"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Author      string `json:"author"`
+	ID             string  `json:"id"`
+	Isbn           string  `json:"isbn"`
+	Title          string  `json:"title"`
+	Description    string  `json:"description"`
+	Author         string  `json:"author"`
+	Series         string  `json:"series,omitempty"`
+	SeriesPosition float64 `json:"series_position,omitempty"`
 }
 
 type BookService interface {
@@ -14,15 +40,87 @@ type BookService interface {
 }
 
 type bookService struct {
+	client *http.Client
 }
 
 func NewOpenLibraryService() BookService {
-	return &bookService{}
+	// looked up while a book is added, so a slow Open Library only delays that a little
+	return &bookService{client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// edition is the part of an edition's JSON that is used
+type edition struct {
+	Key         string          `json:"key"`
+	Title       string          `json:"title"`
+	Isbn13      []string        `json:"isbn_13"`
+	Isbn10      []string        `json:"isbn_10"`
+	Series      []string        `json:"series"`
+	Description json.RawMessage `json:"description"`
 }
 
+// description is either a string or a {"type": "/type/text", "value": ...} object
+func (e edition) description() string {
+	var text string
+	if json.Unmarshal(e.Description, &text) == nil {
+		return text
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(e.Description, &typed) == nil {
+		return typed.Value
+	}
+	return ""
+}
+
+// parseSeries reads a series as editions list it, the position is 0 when there is no number
+func parseSeries(series string) (string, float64) {
+	series = strings.TrimSpace(series)
+	match := seriesNumber.FindStringSubmatch(series)
+	if match == nil {
+		return series, 0
+	}
+	position, err := strconv.ParseFloat(match[2], 64)
+	name := strings.TrimSpace(match[1])
+	if err != nil || name == "" {
+		return series, 0
+	}
+	return name, position
+}
+
+// GetBook looks up the edition with an ISBN
 func (s *bookService) GetBook(id string) (*Book, error) {
-	// Implement the logic to fetch a book by ID from Open Library API
-	return nil, nil // Placeholder return
+	resp, err := s.client.Get(baseURL + "/isbn/" + url.PathEscape(id) + ".json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open library: %s", resp.Status)
+	}
+	var e edition
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return nil, err
+	}
+
+	book := &Book{
+		ID:          strings.TrimPrefix(e.Key, "/books/"),
+		Isbn:        id,
+		Title:       e.Title,
+		Description: e.description(),
+	}
+	if len(e.Isbn13) > 0 {
+		book.Isbn = e.Isbn13[0]
+	} else if len(e.Isbn10) > 0 {
+		book.Isbn = e.Isbn10[0]
+	}
+	if len(e.Series) > 0 {
+		book.Series, book.SeriesPosition = parseSeries(e.Series[0])
+	}
+	return book, nil
 }
 
 func (s *bookService) GetBooks(query string) ([]*Book, error) {
@@ -0,0 +1,294 @@
+package recommend
+
+// this package recommends books from what readers liked: books liked by the same readers, books
+// by the same author and books sharing subjects, each recommendation explained by the liked books
+// that led to it
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// Limit is how many recommendations are kept for a reader
+	Limit = 50
+	// how much an author or subject match counts against co-reading, which is in 0..1
+	authorWeight  = 0.5
+	subjectWeight = 0.6
+	// subjects need to overlap this much to count at all
+	minSubjectSimilarity = 0.2
+	// reasonsPerBook is how many reasons a recommendation keeps
+	reasonsPerBook = 3
+)
+
+// kinds of reason
+const (
+	ReasonCoReading = "co-reading"
+	ReasonAuthor    = "author"
+	ReasonSubject   = "subject"
+)
+
+type Book struct {
+	ID       int64
+	Title    string
+	Author   string
+	Subjects []string
+}
+
+// Like is a book a reader rated 4 or 5. Private likes only count towards the reader's own
+// recommendations.
+type Like struct {
+	UserID  int64
+	BookID  int64
+	Rating  int64
+	Private bool
+}
+
+type Input struct {
+	Books   []Book
+	Likes   []Like
+	Library map[int64][]int64 // every book in each reader's library, liked or not
+}
+
+// Reason is a liked book that led to a recommendation and how
+type Reason struct {
+	Kind   string  `json:"kind"`
+	BookID int64   `json:"book_id"`
+	Title  string  `json:"title"`
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"`
+}
+
+type Recommendation struct {
+	BookID  int64
+	Score   float64
+	Reasons []Reason
+}
+
+type engine struct {
+	books     map[int64]Book
+	likers    map[int64][]int64 // book to the readers who publicly liked it
+	liked     map[int64][]int64 // reader to the books they publicly liked
+	byAuthor  map[string][]int64
+	bySubject map[string][]int64
+	subjects  map[int64]map[string]bool
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func newEngine(in Input) *engine {
+	e := &engine{
+		books:     map[int64]Book{},
+		likers:    map[int64][]int64{},
+		liked:     map[int64][]int64{},
+		byAuthor:  map[string][]int64{},
+		bySubject: map[string][]int64{},
+		subjects:  map[int64]map[string]bool{},
+	}
+	for _, book := range in.Books {
+		e.books[book.ID] = book
+		if author := normalize(book.Author); author != "" {
+			e.byAuthor[author] = append(e.byAuthor[author], book.ID)
+		}
+		e.subjects[book.ID] = map[string]bool{}
+		for _, subject := range book.Subjects {
+			subject = normalize(subject)
+			if subject == "" || e.subjects[book.ID][subject] {
+				continue
+			}
+			e.subjects[book.ID][subject] = true
+			e.bySubject[subject] = append(e.bySubject[subject], book.ID)
+		}
+	}
+	for _, like := range in.Likes {
+		if like.Private {
+			continue
+		}
+		e.likers[like.BookID] = append(e.likers[like.BookID], like.UserID)
+		e.liked[like.UserID] = append(e.liked[like.UserID], like.BookID)
+	}
+	return e
+}
+
+// coReading is the cosine similarity of seed to every book liked by a reader who liked seed,
+// leaving out the reader asking so their own likes don't vouch for each other
+func (e *engine) coReading(seed, userID int64) map[int64]float64 {
+	counts := map[int64]float64{}
+	readers := 0
+	for _, reader := range e.likers[seed] {
+		if reader == userID {
+			continue
+		}
+		readers++
+		for _, book := range e.liked[reader] {
+			if book != seed {
+				counts[book]++
+			}
+		}
+	}
+	similarity := map[int64]float64{}
+	for book, count := range counts {
+		others := len(e.likers[book])
+		for _, reader := range e.likers[book] {
+			if reader == userID {
+				others--
+			}
+		}
+		similarity[book] = count / math.Sqrt(float64(readers*others))
+	}
+	return similarity
+}
+
+// subjectSimilarity is the Jaccard index of the subjects of two books
+func (e *engine) subjectSimilarity(a, b int64) float64 {
+	if len(e.subjects[a]) == 0 || len(e.subjects[b]) == 0 {
+		return 0
+	}
+	shared := 0
+	for subject := range e.subjects[a] {
+		if e.subjects[b][subject] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(e.subjects[a])+len(e.subjects[b])-shared)
+}
+
+// sharedSubjects lists the subjects two books have in common, in order
+func (e *engine) sharedSubjects(a, b int64) []string {
+	shared := []string{}
+	for subject := range e.subjects[a] {
+		if e.subjects[b][subject] {
+			shared = append(shared, subject)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// Recommend ranks books for every reader with likes, best first, leaving out books already in
+// their library. Readers with nothing to go on get no recommendations.
+func Recommend(in Input) map[int64][]Recommendation {
+	e := newEngine(in)
+	seeds := map[int64][]Like{}
+	for _, like := range in.Likes {
+		if _, ok := e.books[like.BookID]; ok {
+			seeds[like.UserID] = append(seeds[like.UserID], like)
+		}
+	}
+
+	recommendations := map[int64][]Recommendation{}
+	for userID, likes := range seeds {
+		owned := map[int64]bool{}
+		for _, book := range in.Library[userID] {
+			owned[book] = true
+		}
+		for _, like := range likes {
+			owned[like.BookID] = true
+		}
+		if ranked := e.recommend(userID, likes, owned); len(ranked) > 0 {
+			recommendations[userID] = ranked
+		}
+	}
+	return recommendations
+}
+
+func (e *engine) recommend(userID int64, likes []Like, owned map[int64]bool) []Recommendation {
+	candidates := map[int64]*Recommendation{}
+	add := func(book int64, reason Reason) {
+		if owned[book] {
+			return
+		}
+		if _, ok := e.books[book]; !ok {
+			return
+		}
+		candidate := candidates[book]
+		if candidate == nil {
+			candidate = &Recommendation{BookID: book}
+			candidates[book] = candidate
+		}
+		candidate.Score += reason.Score
+		candidate.Reasons = append(candidate.Reasons, reason)
+	}
+
+	for _, like := range likes {
+		seed := e.books[like.BookID]
+		// a 5 star book counts for more than a 4 star one
+		weight := float64(like.Rating) / 5
+		for book, similarity := range e.coReading(seed.ID, userID) {
+			add(book, Reason{
+				Kind:   ReasonCoReading,
+				BookID: seed.ID,
+				Title:  seed.Title,
+				Text:   fmt.Sprintf("Because you liked %s: readers who liked it liked this too", seed.Title),
+				Score:  weight * similarity,
+			})
+		}
+		if author := normalize(seed.Author); author != "" {
+			for _, book := range e.byAuthor[author] {
+				if book == seed.ID {
+					continue
+				}
+				add(book, Reason{
+					Kind:   ReasonAuthor,
+					BookID: seed.ID,
+					Title:  seed.Title,
+					Text:   fmt.Sprintf("Because you liked %s, also by %s", seed.Title, seed.Author),
+					Score:  weight * authorWeight,
+				})
+			}
+		}
+		related := map[int64]bool{}
+		for subject := range e.subjects[seed.ID] {
+			for _, book := range e.bySubject[subject] {
+				if book != seed.ID {
+					related[book] = true
+				}
+			}
+		}
+		for book := range related {
+			similarity := e.subjectSimilarity(seed.ID, book)
+			if similarity < minSubjectSimilarity {
+				continue
+			}
+			add(book, Reason{
+				Kind:   ReasonSubject,
+				BookID: seed.ID,
+				Title:  seed.Title,
+				Text:   fmt.Sprintf("Because you liked %s, also about %s", seed.Title, strings.Join(e.sharedSubjects(seed.ID, book), ", ")),
+				Score:  weight * subjectWeight * similarity,
+			})
+		}
+	}
+
+	ranked := make([]Recommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		sort.Slice(candidate.Reasons, func(i, j int) bool {
+			a, b := candidate.Reasons[i], candidate.Reasons[j]
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			return a.BookID < b.BookID
+		})
+		if len(candidate.Reasons) > reasonsPerBook {
+			candidate.Reasons = candidate.Reasons[:reasonsPerBook]
+		}
+		for i := range candidate.Reasons {
+			candidate.Reasons[i].Score = math.Round(candidate.Reasons[i].Score*1000) / 1000
+		}
+		candidate.Score = math.Round(candidate.Score*1000) / 1000
+		ranked = append(ranked, *candidate)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].BookID < ranked[j].BookID
+	})
+	if len(ranked) > Limit {
+		ranked = ranked[:Limit]
+	}
+	return ranked
+}
@@ -0,0 +1,125 @@
+package recommend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"booktrackr/db"
+	log "booktrackr/logging"
+)
+
+// Interval is how often recommendations are recomputed
+const Interval = 6 * time.Hour
+
+// Refresher recomputes everyone's recommendations in the background and keeps them in
+// recommendations, replacing the previous run in one transaction so readers never see half of one
+type Refresher struct {
+	conn  *sql.DB
+	store *db.Queries
+}
+
+func NewRefresher(conn *sql.DB, store *db.Queries) *Refresher {
+	return &Refresher{
+		conn:  conn,
+		store: store,
+	}
+}
+
+// Run refreshes right away and then every Interval, until ctx is done
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+	for {
+		r.run(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) run(ctx context.Context) {
+	started := time.Now()
+	users, err := r.Refresh(ctx)
+	if err != nil {
+		log.Error("Failed to refresh recommendations: %v", err)
+		return
+	}
+	log.Info("Refreshed recommendations for %d users in %s", users, time.Since(started).Round(time.Millisecond))
+}
+
+func (r *Refresher) input(ctx context.Context) (Input, error) {
+	in := Input{Library: map[int64][]int64{}}
+	books, err := r.store.ListBookMetadata(ctx)
+	if err != nil {
+		return in, err
+	}
+	subjects, err := r.store.ListBookSubjects(ctx)
+	if err != nil {
+		return in, err
+	}
+	bySubject := map[int64][]string{}
+	for _, row := range subjects {
+		bySubject[row.BookID] = append(bySubject[row.BookID], row.Subject)
+	}
+	for _, book := range books {
+		in.Books = append(in.Books, Book{ID: book.ID, Title: book.Title, Author: book.Author, Subjects: bySubject[book.ID]})
+	}
+
+	likes, err := r.store.ListLikedBooks(ctx)
+	if err != nil {
+		return in, err
+	}
+	for _, like := range likes {
+		in.Likes = append(in.Likes, Like{UserID: like.UserID, BookID: like.BookID, Rating: like.Rating, Private: like.Private})
+	}
+	library, err := r.store.ListAllLibraryBooks(ctx)
+	if err != nil {
+		return in, err
+	}
+	for _, row := range library {
+		in.Library[row.UserID] = append(in.Library[row.UserID], row.BookID)
+	}
+	return in, nil
+}
+
+// Refresh recomputes recommendations now and reports how many readers got any
+func (r *Refresher) Refresh(ctx context.Context) (int, error) {
+	in, err := r.input(ctx)
+	if err != nil {
+		return 0, err
+	}
+	recommendations := Recommend(in)
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := r.store.WithTx(tx)
+	if err := qtx.ClearRecommendations(ctx); err != nil {
+		return 0, err
+	}
+	computedAt := time.Now().UTC()
+	for userID, ranked := range recommendations {
+		for _, recommendation := range ranked {
+			reasons, err := json.Marshal(recommendation.Reasons)
+			if err != nil {
+				return 0, err
+			}
+			if err := qtx.CreateRecommendation(ctx, db.CreateRecommendationParams{
+				UserID:     userID,
+				BookID:     recommendation.BookID,
+				Score:      recommendation.Score,
+				Reasons:    string(reasons),
+				ComputedAt: computedAt,
+			}); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(recommendations), tx.Commit()
+}
@@ -0,0 +1,493 @@
+package yearreview
+
+// this package lays out a reader's year in review and renders it as SVG, PNG or a standalone HTML page.
+// Both images come from the same layout, the PNG is drawn in Go with the Go fonts.
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	width  = 1200
+	margin = 60
+	inner  = width - 2*margin
+
+	// the covers collage, at most collageRows full rows
+	coverColumns = 8
+	coverWidth   = 124
+	coverHeight  = 186
+	coverGap     = 12
+	collageRows  = 3
+
+	topRated = 5
+)
+
+// Book is a book finished in the year
+type Book struct {
+	Title    string
+	Author   string
+	Rating   int64 // 0 when not rated
+	Pages    int64 // 0 when the page count is not known
+	Finished time.Time
+	Cover    image.Image // nil when the book has no cover or it could not be fetched
+}
+
+// Links are where the report is served, the HTML page links to the images
+type Links struct {
+	Page string
+	SVG  string
+	PNG  string
+}
+
+// Report is one reader's year, Books in the order they were finished
+type Report struct {
+	Username string
+	Year     int
+	Books    []Book
+	Links    Links
+}
+
+// Pages is the pages read in the books whose page count is known
+func (r Report) Pages() int64 {
+	var pages int64
+	for _, book := range r.Books {
+		pages += book.Pages
+	}
+	return pages
+}
+
+// Authors counts the different authors read
+func (r Report) Authors() int {
+	authors := map[string]bool{}
+	for _, book := range r.Books {
+		if author := strings.ToLower(strings.TrimSpace(book.Author)); author != "" {
+			authors[author] = true
+		}
+	}
+	return len(authors)
+}
+
+// Months counts the books finished each month, January first
+func (r Report) Months() [12]int {
+	var months [12]int
+	for _, book := range r.Books {
+		months[book.Finished.Month()-1]++
+	}
+	return months
+}
+
+// TopRated is the best rated books, the earlier finished first among equal ratings
+func (r Report) TopRated() []Book {
+	rated := []Book{}
+	for _, book := range r.Books {
+		if book.Rating > 0 {
+			rated = append(rated, book)
+		}
+	}
+	sort.SliceStable(rated, func(i, j int) bool {
+		return rated[i].Rating > rated[j].Rating
+	})
+	if len(rated) > topRated {
+		rated = rated[:topRated]
+	}
+	return rated
+}
+
+// Format describes one output format
+type Format struct {
+	ContentType string
+	Write       func(w io.Writer, report Report) error
+}
+
+var formats = map[string]Format{
+	"svg":  {ContentType: "image/svg+xml", Write: writeSVG},
+	"png":  {ContentType: "image/png", Write: writePNG},
+	"html": {ContentType: "text/html; charset=utf-8", Write: writeHTML},
+}
+
+// Lookup finds an output format by its file extension
+func Lookup(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+// Names lists the output formats in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	background = color.RGBA{0xfa, 0xf7, 0xf2, 0xff}
+	card       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ink        = color.RGBA{0x22, 0x22, 0x2a, 0xff}
+	muted      = color.RGBA{0x6b, 0x6b, 0x76, 0xff}
+	accent     = color.RGBA{0xc2, 0x4e, 0x2d, 0xff}
+	// placeholders for books without a cover take turns with these
+	spines = []color.RGBA{
+		{0x2d, 0x4a, 0x6b, 0xff},
+		{0x6b, 0x2d, 0x4a, 0xff},
+		{0x2d, 0x6b, 0x55, 0xff},
+		{0x7a, 0x5c, 0x2e, 0xff},
+	}
+)
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type elementKind int
+
+const (
+	rectElement elementKind = iota
+	textElement
+	pictureElement
+)
+
+// element is one thing drawn on the report. Text is placed by its baseline at X, Y.
+type element struct {
+	kind       elementKind
+	x, y, w, h int
+	fill       color.RGBA
+	text       string
+	size       float64
+	bold       bool
+	anchor     anchor
+	picture    image.Image
+}
+
+var loadFonts = sync.OnceValues(func() (map[bool]*sfnt.Font, error) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return map[bool]*sfnt.Font{false: regular, true: bold}, nil
+})
+
+// newFace makes a face for one use, faces keep state and are not safe to share between requests
+func newFace(size float64, bold bool) (font.Face, error) {
+	fonts, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(fonts[bold], &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+func measure(text string, size float64, bold bool) int {
+	face, err := newFace(size, bold)
+	if err != nil {
+		return 0
+	}
+	defer face.Close()
+	return font.MeasureString(face, text).Ceil()
+}
+
+// fit shortens text to the width with an ellipsis
+func fit(text string, size float64, bold bool, maxWidth int) string {
+	if measure(text, size, bold) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		shortened := strings.TrimSpace(string(runes)) + "…"
+		if measure(shortened, size, bold) <= maxWidth {
+			return shortened
+		}
+	}
+	return ""
+}
+
+// fitCover crops the cover to the tile's shape around its middle and scales it to the tile
+func fitCover(cover image.Image, w, h int) image.Image {
+	bounds := cover.Bounds()
+	crop := bounds
+	if bounds.Dx()*h > bounds.Dy()*w {
+		cropWidth := bounds.Dy() * w / h
+		crop.Min.X += (bounds.Dx() - cropWidth) / 2
+		crop.Max.X = crop.Min.X + cropWidth
+	} else {
+		cropHeight := bounds.Dx() * h / w
+		crop.Min.Y += (bounds.Dy() - cropHeight) / 2
+		crop.Max.Y = crop.Min.Y + cropHeight
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), cover, crop, xdraw.Src, nil)
+	return scaled
+}
+
+type layout struct {
+	elements []element
+	y        int
+}
+
+func (l *layout) rect(x, y, w, h int, fill color.RGBA) {
+	l.elements = append(l.elements, element{kind: rectElement, x: x, y: y, w: w, h: h, fill: fill})
+}
+
+func (l *layout) text(x, y int, text string, size float64, bold bool, fill color.RGBA, a anchor) {
+	l.elements = append(l.elements, element{kind: textElement, x: x, y: y, text: text, size: size, bold: bold, fill: fill, anchor: a})
+}
+
+func (l *layout) heading(text string) {
+	l.y += 56
+	l.text(margin, l.y, text, 28, true, ink, anchorStart)
+	l.y += 24
+}
+
+// lay places everything on the report, top to bottom
+func lay(report Report) layout {
+	l := layout{}
+	books := report.Books
+
+	l.y = 100
+	l.text(margin, l.y, fmt.Sprintf("%d in books", report.Year), 52, true, ink, anchorStart)
+	l.y += 44
+	l.text(margin, l.y, fit(fmt.Sprintf("%s's year of reading", report.Username), 24, false, inner), 24, false, muted, anchorStart)
+
+	// totals
+	l.y += 40
+	totals := []struct {
+		value int64
+		label string
+	}{
+		{int64(len(books)), "books finished"},
+		{report.Pages(), "pages read"},
+		{int64(report.Authors()), "authors"},
+	}
+	boxWidth := (inner - 2*24) / 3
+	for i, total := range totals {
+		x := margin + i*(boxWidth+24)
+		l.rect(x, l.y, boxWidth, 128, card)
+		l.text(x+boxWidth/2, l.y+68, fmt.Sprintf("%d", total.value), 48, true, accent, anchorMiddle)
+		l.text(x+boxWidth/2, l.y+104, total.label, 20, false, muted, anchorMiddle)
+	}
+	l.y += 128
+
+	l.heading("Covers")
+	if len(books) == 0 {
+		l.y += 20
+		l.text(margin, l.y, fmt.Sprintf("No books finished in %d yet", report.Year), 20, false, muted, anchorStart)
+	}
+	shown := books
+	if len(shown) > coverColumns*collageRows {
+		shown = shown[:coverColumns*collageRows]
+	}
+	for i, book := range shown {
+		x := margin + (i%coverColumns)*(coverWidth+coverGap)
+		y := l.y + (i/coverColumns)*(coverHeight+coverGap)
+		if book.Cover != nil {
+			l.elements = append(l.elements, element{kind: pictureElement, x: x, y: y, w: coverWidth, h: coverHeight, picture: fitCover(book.Cover, coverWidth, coverHeight)})
+			continue
+		}
+		l.rect(x, y, coverWidth, coverHeight, spines[i%len(spines)])
+		l.text(x+coverWidth/2, y+coverHeight/2, fit(book.Title, 15, true, coverWidth-16), 15, true, card, anchorMiddle)
+		l.text(x+coverWidth/2, y+coverHeight/2+22, fit(book.Author, 13, false, coverWidth-16), 13, false, card, anchorMiddle)
+	}
+	if rows := (len(shown) + coverColumns - 1) / coverColumns; rows > 0 {
+		l.y += rows*(coverHeight+coverGap) - coverGap
+	}
+	if more := len(books) - len(shown); more > 0 {
+		l.y += 32
+		l.text(margin, l.y, fmt.Sprintf("and %d more", more), 20, false, muted, anchorStart)
+	}
+
+	l.heading("Top rated")
+	rated := report.TopRated()
+	if len(rated) == 0 {
+		l.y += 20
+		l.text(margin, l.y, "No rated books this year", 20, false, muted, anchorStart)
+	}
+	for _, book := range rated {
+		l.y += 44
+		rating := fmt.Sprintf("%d/5", book.Rating)
+		title := fit(book.Title, 22, true, inner*3/5)
+		l.text(margin, l.y, title, 22, true, ink, anchorStart)
+		if book.Author != "" {
+			x := margin + measure(title, 22, true) + 12
+			l.text(x, l.y, fit("by "+book.Author, 20, false, width-margin-x-80), 20, false, muted, anchorStart)
+		}
+		l.text(width-margin, l.y, rating, 22, true, accent, anchorEnd)
+	}
+
+	l.heading("Books by month")
+	months := report.Months()
+	most := 1
+	for _, count := range months {
+		most = max(most, count)
+	}
+	const chartHeight = 180
+	column := inner / 12
+	l.y += 40 + chartHeight
+	for i, count := range months {
+		x := margin + i*column
+		height := count * chartHeight / most
+		if count > 0 {
+			l.rect(x+column/6, l.y-height, column*2/3, height, accent)
+		}
+		l.rect(x+column/6, l.y, column*2/3, 2, muted)
+		l.text(x+column/2, l.y-height-10, fmt.Sprintf("%d", count), 18, true, ink, anchorMiddle)
+		l.text(x+column/2, l.y+28, time.Month(i + 1).String()[:3], 18, false, muted, anchorMiddle)
+	}
+	l.y += 28
+
+	l.y += 64
+	l.text(width/2, l.y, "booktrackr", 18, true, muted, anchorMiddle)
+	l.y += 40
+
+	l.elements = append([]element{{kind: rectElement, w: width, h: l.y, fill: background}}, l.elements...)
+	return l
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// svg writes the report as an SVG element, the covers are embedded so the image stands on its own
+func svg(w io.Writer, report Report) error {
+	l := lay(report)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		width, l.y, width, l.y, template.HTMLEscapeString(fmt.Sprintf("%s's %d in books", report.Username, report.Year)))
+	b.WriteString(`<style>text{font-family:Go,"Helvetica Neue",Arial,sans-serif}</style>`)
+	for _, e := range l.elements {
+		switch e.kind {
+		case rectElement:
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, e.x, e.y, e.w, e.h, hexColor(e.fill))
+		case textElement:
+			weight := "normal"
+			if e.bold {
+				weight = "bold"
+			}
+			textAnchor := map[anchor]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}[e.anchor]
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%g" font-weight="%s" fill="%s" text-anchor="%s">%s</text>`,
+				e.x, e.y, e.size, weight, hexColor(e.fill), textAnchor, template.HTMLEscapeString(e.text))
+		case pictureElement:
+			var picture bytes.Buffer
+			if err := jpeg.Encode(&picture, e.picture, &jpeg.Options{Quality: 85}); err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/jpeg;base64,%s"/>`,
+				e.x, e.y, e.w, e.h, base64.StdEncoding.EncodeToString(picture.Bytes()))
+		}
+	}
+	b.WriteString(`</svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSVG(w io.Writer, report Report) error {
+	if _, err := io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"); err != nil {
+		return err
+	}
+	return svg(w, report)
+}
+
+func writePNG(w io.Writer, report Report) error {
+	l := lay(report)
+	canvas := image.NewRGBA(image.Rect(0, 0, width, l.y))
+	for _, e := range l.elements {
+		switch e.kind {
+		case rectElement:
+			draw.Draw(canvas, image.Rect(e.x, e.y, e.x+e.w, e.y+e.h), image.NewUniform(e.fill), image.Point{}, draw.Src)
+		case pictureElement:
+			draw.Draw(canvas, image.Rect(e.x, e.y, e.x+e.w, e.y+e.h), e.picture, e.picture.Bounds().Min, draw.Src)
+		case textElement:
+			face, err := newFace(e.size, e.bold)
+			if err != nil {
+				return err
+			}
+			x := e.x
+			switch e.anchor {
+			case anchorMiddle:
+				x -= font.MeasureString(face, e.text).Ceil() / 2
+			case anchorEnd:
+				x -= font.MeasureString(face, e.text).Ceil()
+			}
+			drawer := font.Drawer{Dst: canvas, Src: image.NewUniform(e.fill), Face: face, Dot: fixed.P(x, e.y)}
+			drawer.DrawString(e.text)
+			face.Close()
+		}
+	}
+	return png.Encode(w, canvas)
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<meta property="og:type" content="website">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+{{with .Links.Page}}<meta property="og:url" content="{{.}}">
+{{end}}{{with .Links.PNG}}<meta property="og:image" content="{{.}}">
+<meta name="twitter:card" content="summary_large_image">
+{{end}}<style>
+body{margin:0;background:#faf7f2;color:#22222a;font-family:Go,"Helvetica Neue",Arial,sans-serif}
+main{max-width:1200px;margin:0 auto;padding:24px 16px}
+svg{width:100%;height:auto;display:block}
+nav{margin-top:16px;text-align:center}
+nav a{color:#c24e2d;margin:0 12px}
+</style>
+</head>
+<body>
+<main>
+{{.Image}}
+<nav>{{with .Links.PNG}}<a href="{{.}}" download>Download PNG</a>{{end}}{{with .Links.SVG}}<a href="{{.}}" download>Download SVG</a>{{end}}</nav>
+</main>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, report Report) error {
+	var image strings.Builder
+	if err := svg(&image, report); err != nil {
+		return err
+	}
+	return page.Execute(w, struct {
+		Title       string
+		Description string
+		Links       Links
+		Image       template.HTML
+	}{
+		Title:       fmt.Sprintf("%s's %d in books", report.Username, report.Year),
+		Description: fmt.Sprintf("%d books and %d pages read in %d", len(report.Books), report.Pages(), report.Year),
+		Links:       report.Links,
+		Image:       template.HTML(image.String()),
+	})
+}
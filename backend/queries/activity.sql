@@ -0,0 +1,13 @@
+-- name: ListUserActivity :many
+-- one user's events before a position in the timeline, newest first. Events on private entries
+-- are only included for the user's own timeline.
+SELECT e.id, e.user_id, u.username, e.book_id, b.title, b.author, b.image_url, e.type, e.value, e.created_at
+FROM activity_events e
+JOIN users u ON u.id = e.user_id
+JOIN user_books ub ON ub.user_id = e.user_id AND ub.book_id = e.book_id
+JOIN books b ON b.id = e.book_id
+WHERE e.user_id = sqlc.arg(user_id)
+    AND (ub.visibility != 'private' OR CAST(sqlc.arg(include_private) AS BOOLEAN))
+    AND (e.created_at < datetime(sqlc.arg(before_time)) OR (e.created_at = datetime(sqlc.arg(before_time)) AND e.id < sqlc.arg(before_id)))
+ORDER BY e.created_at DESC, e.id DESC
+LIMIT sqlc.arg(limit);
@@ -0,0 +1,50 @@
+-- name: ImportAnnotation :execrows
+INSERT OR IGNORE INTO annotations (user_id, book_id, type, text, page, location, private, created_at, source, source_key)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateAnnotation :one
+INSERT INTO annotations (user_id, book_id, type, text, page, location, chapter, private, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetAnnotation :one
+SELECT id, user_id, book_id, type, text, page, location, created_at, source, source_key, chapter, private, updated_at
+FROM annotations
+WHERE id = ? AND user_id = ?;
+
+-- in reading order, CAST takes the start of a location range like 170-172
+-- name: ListAnnotations :many
+SELECT id, user_id, book_id, type, text, page, location, created_at, source, source_key, chapter, private, updated_at
+FROM annotations
+WHERE user_id = ? AND book_id = ?
+ORDER BY COALESCE(page, 0), CAST(location AS INTEGER), id;
+
+-- name: UpdateAnnotation :execrows
+UPDATE annotations
+SET type = ?, text = ?, page = ?, location = ?, chapter = ?, private = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND book_id = ?;
+
+-- name: DeleteAnnotation :execrows
+DELETE FROM annotations WHERE id = ? AND user_id = ? AND book_id = ?;
+
+-- quotes and highlights across the whole library, newest first, paged by id
+-- name: ListQuotes :many
+SELECT a.id, a.user_id, a.book_id, a.type, a.text, a.page, a.location, a.chapter, a.private, a.source, a.created_at, a.updated_at,
+    b.title AS book_title, b.author AS book_author
+FROM annotations a
+JOIN books b ON b.id = a.book_id
+WHERE a.user_id = sqlc.arg(user_id) AND a.type IN ('quote', 'highlight') AND a.id < sqlc.arg(before_id)
+ORDER BY a.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CountQuotes :one
+SELECT COUNT(*) FROM annotations WHERE user_id = ? AND type IN ('quote', 'highlight');
+
+-- name: GetRandomQuote :one
+SELECT a.id, a.user_id, a.book_id, a.type, a.text, a.page, a.location, a.chapter, a.private, a.source, a.created_at, a.updated_at,
+    b.title AS book_title, b.author AS book_author
+FROM annotations a
+JOIN books b ON b.id = a.book_id
+WHERE a.user_id = ? AND a.type IN ('quote', 'highlight')
+ORDER BY RANDOM()
+LIMIT 1;
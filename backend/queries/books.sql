@@ -18,7 +18,16 @@ SELECT
     b.description,
     b.author,
     b.image_url,
-    ub.progress,
+    CAST(COALESCE((
+        SELECT CASE
+            WHEN pe.unit = 'percent' THEN pe.value
+            WHEN pe.total > 0 THEN pe.value * 100 / pe.total
+        END
+        FROM progress_events pe
+        WHERE pe.read_id = (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) AS INTEGER) AS progress,
     ub.start_date,
     ub.finish_date,
     ub.rating
@@ -34,17 +43,29 @@ UPDATE books SET isbn = ?, title = ?, description = ?, author = ?, image_url = ?
 DELETE FROM books WHERE id = ?;
 
 -- name: CreateUserBook :exec
-INSERT INTO user_books (user_id, book_id) VALUES (?, ?);
+INSERT INTO user_books (user_id, book_id, added_at, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
 
 -- name: GetUserBook :one
 SELECT 
     ub.user_id,
     ub.book_id,
     ub.start_date,
-    ub.progress,
+    CAST(COALESCE((
+        SELECT CASE
+            WHEN pe.unit = 'percent' THEN pe.value
+            WHEN pe.total > 0 THEN pe.value * 100 / pe.total
+        END
+        FROM progress_events pe
+        WHERE pe.read_id = (SELECT MAX(r.id) FROM reads r WHERE r.user_id = ub.user_id AND r.book_id = ub.book_id)
+        ORDER BY pe.created_at DESC, pe.id DESC
+        LIMIT 1
+    ), 0) AS INTEGER) AS progress,
     ub.finish_date,
     ub.rating,
     ub.review,
+    ub.version,
+    ub.updated_at,
+    ub.visibility,
     b.isbn,
     b.title,
     b.description,
@@ -54,8 +75,13 @@ FROM user_books ub
 JOIN books b ON ub.book_id = b.id
 WHERE ub.user_id = ? AND ub.book_id = ?;
 
--- name: UpdateUserBook :exec
-UPDATE user_books SET progress = ? ,finish_date = ?, rating = ?, review = ? WHERE user_id = ? AND book_id = ?;
+-- name: UpdateUserBook :execrows
+UPDATE user_books
+SET start_date = ?, finish_date = ?, rating = ?, review = ?, visibility = ?, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND book_id = ? AND version = ?;
+
+-- name: TouchUserBook :exec
+UPDATE user_books SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE user_id = ? AND book_id = ?;
 
 -- name: ListUserBooks :many
 SELECT * FROM user_books WHERE user_id = ?;
@@ -0,0 +1,135 @@
+-- name: CreateClub :one
+INSERT INTO clubs (name, description, created_by)
+VALUES (?, ?, ?)
+RETURNING id;
+
+-- name: GetClub :one
+SELECT c.id, c.name, c.description, c.book_id, c.schedule_unit, c.schedule_total, c.created_at,
+    b.isbn, b.title, b.author, b.image_url
+FROM clubs c
+LEFT JOIN books b ON b.id = c.book_id
+WHERE c.id = ?;
+
+-- name: ListUserClubs :many
+SELECT c.id, c.name, c.description, c.book_id, c.created_at, m.role,
+    (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id) AS members
+FROM club_members m
+JOIN clubs c ON c.id = m.club_id
+WHERE m.user_id = ?
+ORDER BY c.name, c.id;
+
+-- name: UpdateClub :exec
+UPDATE clubs SET name = ?, description = ? WHERE id = ?;
+
+-- name: SetClubBook :exec
+UPDATE clubs SET book_id = ?, schedule_unit = ?, schedule_total = ? WHERE id = ?;
+
+-- name: DeleteClub :exec
+DELETE FROM clubs WHERE id = ?;
+
+-- name: GetClubMember :one
+SELECT club_id, user_id, role, joined_at
+FROM club_members
+WHERE club_id = ? AND user_id = ?;
+
+-- name: AddClubMember :exec
+INSERT INTO club_members (club_id, user_id, role)
+VALUES (?, ?, ?)
+ON CONFLICT (club_id, user_id) DO UPDATE SET role = excluded.role;
+
+-- name: RemoveClubMember :execrows
+DELETE FROM club_members WHERE club_id = ? AND user_id = ?;
+
+-- name: ListClubMemberProgress :many
+-- every member with where they are in a book: the latest progress of their current read of it
+-- and its status, both empty when the book is not in their library or not started. visibility is
+-- their entry's, empty without one, and followed whether the viewer follows them.
+SELECT u.id, u.username, u.display_name, u.profile_visibility, m.role, m.joined_at,
+    COALESCE(ub.visibility, '') AS visibility,
+    CAST(EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = sqlc.arg(viewer_id) AND f.followee_id = u.id AND f.status = 'accepted'
+    ) AS BOOLEAN) AS followed,
+    COALESCE(r.status, '') AS status,
+    COALESCE(pe.unit, '') AS unit,
+    COALESCE(pe.value, 0) AS value,
+    COALESCE(pe.total, 0) AS total
+FROM club_members m
+JOIN users u ON u.id = m.user_id
+LEFT JOIN user_books ub ON ub.user_id = m.user_id AND ub.book_id = sqlc.arg(book_id)
+LEFT JOIN reads r ON r.id = (
+    SELECT MAX(r2.id) FROM reads r2 WHERE r2.user_id = m.user_id AND r2.book_id = sqlc.arg(book_id)
+)
+LEFT JOIN progress_events pe ON pe.id = (
+    SELECT pe2.id FROM progress_events pe2
+    WHERE pe2.read_id = r.id
+    ORDER BY pe2.created_at DESC, pe2.id DESC
+    LIMIT 1
+)
+WHERE m.club_id = sqlc.arg(club_id)
+ORDER BY u.username;
+
+-- name: CreateClubInvite :exec
+INSERT INTO club_invites (club_id, user_id, role, invited_by)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (club_id, user_id) DO UPDATE SET role = excluded.role, invited_by = excluded.invited_by;
+
+-- name: GetClubInvite :one
+SELECT club_id, user_id, role, invited_by, created_at
+FROM club_invites
+WHERE club_id = ? AND user_id = ?;
+
+-- name: ListUserClubInvites :many
+SELECT i.club_id, c.name, c.description, i.role, u.username AS invited_by, i.created_at
+FROM club_invites i
+JOIN clubs c ON c.id = i.club_id
+JOIN users u ON u.id = i.invited_by
+WHERE i.user_id = ?
+ORDER BY i.created_at DESC, i.club_id;
+
+-- name: DeleteClubInvite :execrows
+DELETE FROM club_invites WHERE club_id = ? AND user_id = ?;
+
+-- name: CreateClubSection :one
+INSERT INTO club_sections (club_id, book_id, title, start_at, end_at, deadline)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, club_id, book_id, title, start_at, end_at, deadline;
+
+-- name: GetClubSection :one
+SELECT id, club_id, book_id, title, start_at, end_at, deadline
+FROM club_sections
+WHERE id = ? AND club_id = ?;
+
+-- name: ListClubSections :many
+SELECT s.id, s.club_id, s.book_id, s.title, s.start_at, s.end_at, s.deadline,
+    (SELECT COUNT(*) FROM club_posts p WHERE p.section_id = s.id AND p.deleted_at IS NULL) AS posts
+FROM club_sections s
+WHERE s.club_id = ? AND s.book_id = ?
+ORDER BY s.start_at, s.id;
+
+-- name: UpdateClubSection :exec
+UPDATE club_sections SET title = ?, start_at = ?, end_at = ?, deadline = ?
+WHERE id = ? AND club_id = ?;
+
+-- name: DeleteClubSection :exec
+DELETE FROM club_sections WHERE id = ? AND club_id = ?;
+
+-- name: CreateClubPost :one
+INSERT INTO club_posts (section_id, user_id, parent_id, body)
+VALUES (?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetClubPost :one
+SELECT id, section_id, user_id, parent_id, body, created_at, deleted_at
+FROM club_posts
+WHERE id = ? AND section_id = ?;
+
+-- name: ListClubPosts :many
+SELECT p.id, p.parent_id, p.user_id, u.username, p.body, p.created_at, p.deleted_at
+FROM club_posts p
+JOIN users u ON u.id = p.user_id
+WHERE p.section_id = ?
+ORDER BY p.created_at, p.id;
+
+-- name: DeleteClubPost :exec
+UPDATE club_posts SET body = '', deleted_at = CURRENT_TIMESTAMP WHERE id = ?;
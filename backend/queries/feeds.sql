@@ -0,0 +1,32 @@
+-- name: GetFeedSettings :one
+SELECT id, username, feed_enabled, feed_reviews, feed_updated_at
+FROM users
+WHERE id = ?;
+
+-- name: GetFeedSettingsByUsername :one
+-- only public profiles have a feed, making a profile private or followers-only turns it off
+SELECT id, username, feed_enabled, feed_reviews, feed_updated_at
+FROM users
+WHERE username = ? AND profile_visibility = 'public';
+
+-- name: UpdateFeedSettings :exec
+UPDATE users SET feed_enabled = ?, feed_reviews = ?, feed_updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListFeedReads :many
+SELECT r.id, r.book_id, r.start_date, r.finish_date, r.status, r.rating, r.review,
+    b.isbn, b.title, b.author, b.image_url
+FROM reads r
+JOIN books b ON b.id = r.book_id
+JOIN user_books ub ON ub.user_id = r.user_id AND ub.book_id = r.book_id
+JOIN users u ON u.id = r.user_id
+WHERE r.user_id = ? AND ub.visibility = 'public' AND u.profile_visibility = 'public'
+ORDER BY COALESCE(r.finish_date, r.start_date) DESC, r.id DESC
+LIMIT ?;
+
+-- name: GetLibraryUpdatedAt :one
+SELECT updated_at
+FROM user_books
+WHERE user_id = ? AND updated_at IS NOT NULL
+ORDER BY updated_at DESC
+LIMIT 1;
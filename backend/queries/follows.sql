@@ -0,0 +1,37 @@
+-- name: GetFollow :one
+SELECT follower_id, followee_id, status, created_at, accepted_at
+FROM follows
+WHERE follower_id = ? AND followee_id = ?;
+
+-- name: CreateFollow :exec
+INSERT INTO follows (follower_id, followee_id, status, accepted_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: AcceptFollow :execrows
+UPDATE follows SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP
+WHERE follower_id = ? AND followee_id = ? AND status = 'pending';
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = ? AND followee_id = ?;
+
+-- name: ListFollowers :many
+SELECT u.id, u.username, u.display_name, f.status, f.created_at
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.followee_id = ? AND f.status = ?
+ORDER BY f.created_at DESC, u.id DESC;
+
+-- name: ListFollowing :many
+SELECT u.id, u.username, u.display_name, f.status, f.created_at
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = ?
+ORDER BY f.created_at DESC, u.id DESC;
+
+-- name: ListTimelineSources :many
+-- the people whose activity shows in a user's timeline, their profile has to be visible to followers
+SELECT u.id
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = ? AND f.status = 'accepted' AND u.profile_visibility IN ('followers', 'public');
@@ -0,0 +1,69 @@
+-- name: ListReadingGoals :many
+SELECT user_id, year, unit, target, updated_at
+FROM reading_goals
+WHERE user_id = ? AND year = ?
+ORDER BY unit;
+
+-- name: SetReadingGoal :one
+INSERT INTO reading_goals (user_id, year, unit, target)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, year, unit) DO UPDATE SET target = excluded.target, updated_at = CURRENT_TIMESTAMP
+RETURNING user_id, year, unit, target, updated_at;
+
+-- name: DeleteReadingGoal :execrows
+DELETE FROM reading_goals WHERE user_id = ? AND year = ? AND unit = ?;
+
+-- name: ListFinishedBooks :many
+-- the reads finished in a year with what goals and challenges look at, a book read twice in it
+-- counts twice. Pages and minutes are the totals last given with progress in that unit, 0 when
+-- there never was any.
+SELECT fb.book_id, fb.title, fb.author, fb.finish_date, fb.rating, fb.pages,
+    CAST(COALESCE((
+        SELECT pe.total FROM progress_events pe
+        WHERE pe.user_id = fb.user_id AND pe.book_id = fb.book_id AND pe.unit = 'minutes' AND pe.total IS NOT NULL
+        ORDER BY pe.created_at DESC, pe.id DESC LIMIT 1
+    ), 0) AS INTEGER) AS minutes,
+    CAST(COALESCE((
+        SELECT s.name FROM series_books sb JOIN series s ON s.id = sb.series_id
+        WHERE sb.book_id = fb.book_id AND sb.user_id IN (0, fb.user_id) ORDER BY s.name LIMIT 1
+    ), '') AS TEXT) AS series,
+    CAST(COALESCE((
+        SELECT group_concat(t.tag, char(31)) FROM user_book_tags t
+        WHERE t.user_id = fb.user_id AND t.book_id = fb.book_id
+    ), '') AS TEXT) AS tags
+FROM finished_books fb
+WHERE fb.user_id = sqlc.arg(user_id)
+    AND CAST(strftime('%Y', fb.finish_date) AS INTEGER) = CAST(sqlc.arg(year) AS INTEGER)
+ORDER BY fb.finish_date, fb.read_id;
+
+-- name: ListAuthorsReadBefore :many
+-- authors of books the user finished before a year, whoever else they read that year is new to them
+SELECT DISTINCT b.author
+FROM reads r
+JOIN books b ON b.id = r.book_id
+WHERE r.user_id = sqlc.arg(user_id) AND r.status = 'finished' AND r.finish_date IS NOT NULL
+    AND CAST(strftime('%Y', r.finish_date) AS INTEGER) < CAST(sqlc.arg(year) AS INTEGER);
+
+-- name: ListChallenges :many
+SELECT id, user_id, year, name, target, rules, created_at
+FROM challenges
+WHERE user_id = ? AND year = ?
+ORDER BY created_at, id;
+
+-- name: GetChallenge :one
+SELECT id, user_id, year, name, target, rules, created_at
+FROM challenges
+WHERE id = ? AND user_id = ?;
+
+-- name: CreateChallenge :one
+INSERT INTO challenges (user_id, year, name, target, rules)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, year, name, target, rules, created_at;
+
+-- name: UpdateChallenge :one
+UPDATE challenges SET year = ?, name = ?, target = ?, rules = ?
+WHERE id = ? AND user_id = ?
+RETURNING id, user_id, year, name, target, rules, created_at;
+
+-- name: DeleteChallenge :execrows
+DELETE FROM challenges WHERE id = ? AND user_id = ?;
@@ -0,0 +1,42 @@
+-- name: GetBookByIsbn :one
+SELECT id, isbn, title, description, author, image_url FROM books WHERE isbn = ?;
+
+-- name: FindBookByTitleAuthor :one
+SELECT id, isbn, title, description, author, image_url
+FROM books
+WHERE title = ? COLLATE NOCASE AND author = ? COLLATE NOCASE
+ORDER BY id
+LIMIT 1;
+
+-- name: UserHasBook :one
+SELECT COUNT(*) FROM user_books WHERE user_id = ? AND book_id = ?;
+
+-- name: ImportUserBook :exec
+INSERT INTO user_books (user_id, book_id, start_date, finish_date, rating, review, added_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+
+-- name: ImportRead :one
+INSERT INTO reads (user_id, book_id, start_date, finish_date, status, rating, review)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (user_id, format, dry_run, status, total_rows)
+VALUES (?, ?, ?, 'queued', ?)
+RETURNING id;
+
+-- name: GetImportJob :one
+SELECT id, user_id, format, dry_run, status, total_rows, processed_rows, report, error, created_at, finished_at
+FROM import_jobs
+WHERE id = ? AND user_id = ?;
+
+-- name: UpdateImportJobProgress :exec
+UPDATE import_jobs SET status = 'running', processed_rows = ? WHERE id = ?;
+
+-- name: FinishImportJob :exec
+UPDATE import_jobs SET status = ?, report = ?, error = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: FailInterruptedImportJobs :exec
+UPDATE import_jobs
+SET status = 'failed', error = 'the server restarted before the import finished', finished_at = CURRENT_TIMESTAMP
+WHERE status IN ('queued', 'running');
@@ -0,0 +1,31 @@
+-- name: NotifyReviewComment :exec
+-- tells the reviewer and everyone else who commented on the review about a new comment
+INSERT INTO notifications (user_id, type, actor_id, reviewer_id, book_id, comment_id)
+SELECT recipients.user_id, 'comment', sqlc.arg(actor_id), sqlc.arg(reviewer_id), sqlc.arg(book_id), sqlc.arg(comment_id)
+FROM (
+    SELECT sqlc.arg(reviewer_id) AS user_id
+    UNION
+    SELECT c.user_id FROM review_comments c
+    WHERE c.reviewer_id = sqlc.arg(reviewer_id) AND c.book_id = sqlc.arg(book_id) AND c.deleted_at IS NULL
+) recipients
+WHERE recipients.user_id != sqlc.arg(actor_id);
+
+-- name: ListNotifications :many
+SELECT n.id, n.type, a.username AS actor, r.username AS reviewer, n.book_id, b.title, n.comment_id, n.created_at, n.read_at
+FROM notifications n
+JOIN users a ON a.id = n.actor_id
+JOIN users r ON r.id = n.reviewer_id
+JOIN books b ON b.id = n.book_id
+WHERE n.user_id = sqlc.arg(user_id)
+    AND (n.read_at IS NULL OR CAST(sqlc.arg(include_read) AS BOOLEAN))
+    AND (n.created_at < datetime(sqlc.arg(before_time)) OR (n.created_at = datetime(sqlc.arg(before_time)) AND n.id < sqlc.arg(before_id)))
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+-- marks the user's notifications up to and including up_to_id as read
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND id <= ? AND read_at IS NULL;
@@ -0,0 +1,21 @@
+-- name: GetProfile :one
+SELECT id, username, display_name, bio, profile_visibility, created_at
+FROM users
+WHERE id = ?;
+
+-- name: GetProfileByUsername :one
+SELECT id, username, display_name, bio, profile_visibility, created_at
+FROM users
+WHERE username = ?;
+
+-- name: UpdateProfile :exec
+UPDATE users SET display_name = ?, bio = ?, profile_visibility = ?
+WHERE id = ?;
+
+-- name: ListVisibleUserTags :many
+SELECT t.tag, COUNT(*) AS books
+FROM user_book_tags t
+JOIN user_books ub ON ub.user_id = t.user_id AND ub.book_id = t.book_id
+WHERE t.user_id = sqlc.arg(user_id) AND ub.visibility IN (sqlc.slice(visibilities))
+GROUP BY t.tag
+ORDER BY t.tag COLLATE NOCASE;
@@ -0,0 +1,37 @@
+-- name: CreateProgressEvent :one
+INSERT INTO progress_events (user_id, book_id, read_id, unit, value, total, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, book_id, read_id, unit, value, total, created_at;
+
+-- name: ListProgressEvents :many
+SELECT id, user_id, book_id, read_id, unit, value, total, created_at
+FROM progress_events
+WHERE user_id = ? AND book_id = ?
+ORDER BY created_at, id;
+
+-- name: GetLatestProgressTotal :one
+SELECT total
+FROM progress_events
+WHERE user_id = ? AND book_id = ? AND unit = ? AND total IS NOT NULL
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: ListReadingProgress :many
+-- progress on the current read of the books being read, all of them when book_id is 0
+SELECT r.book_id, r.start_date, pe.unit, pe.value, pe.total, pe.created_at
+FROM reads r
+JOIN progress_events pe ON pe.read_id = r.id
+WHERE r.user_id = sqlc.arg(user_id) AND r.status = 'reading'
+    AND (CAST(sqlc.arg(book_id) AS INTEGER) = 0 OR r.book_id = sqlc.arg(book_id))
+    AND r.id = (SELECT MAX(latest.id) FROM reads latest WHERE latest.user_id = r.user_id AND latest.book_id = r.book_id)
+ORDER BY r.book_id, pe.created_at, pe.id;
+
+-- name: GetUsualPace :one
+-- how fast the user gets through the books they finish: pages a day over those with a page count,
+-- and books a day over all of them. A book finished the day it was started counts as a day.
+SELECT CAST(COALESCE(SUM(CASE WHEN pages > 0 THEN pages END), 0) AS INTEGER) AS pages,
+    CAST(COALESCE(SUM(CASE WHEN pages > 0 THEN MAX(julianday(finish_date) - julianday(start_date), 1) END), 0) AS REAL) AS page_days,
+    COUNT(*) AS books,
+    CAST(COALESCE(SUM(MAX(julianday(finish_date) - julianday(start_date), 1)), 0) AS REAL) AS days
+FROM finished_books
+WHERE user_id = ? AND start_date IS NOT NULL AND finish_date >= start_date;
@@ -0,0 +1,48 @@
+-- name: CreateRead :one
+INSERT INTO reads (user_id, book_id, start_date, status)
+VALUES (?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetRead :one
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE id = ? AND user_id = ?;
+
+-- name: ListReadsByUserBook :many
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE user_id = ? AND book_id = ?
+ORDER BY id DESC;
+
+-- name: UpdateRead :exec
+UPDATE reads SET start_date = ?, finish_date = ?, status = ?, rating = ?, review = ? WHERE id = ? AND user_id = ?;
+
+-- name: UpdateCurrentRead :exec
+UPDATE reads SET start_date = ?, finish_date = ?, status = ?, rating = ?, review = ?
+WHERE id = (
+    SELECT MAX(r.id) FROM reads r WHERE r.user_id = ? AND r.book_id = ?
+);
+
+-- name: StartUserBookRead :exec
+-- a new read changes the entry, so earlier ETags no longer match. The rating and review were the
+-- last read's, which keeps them, and saving the entry would otherwise copy them onto the new one.
+UPDATE user_books SET start_date = ?, finish_date = NULL, progress = 0, rating = NULL, review = NULL,
+    version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_books.user_id = ? AND user_books.book_id = ?;
+
+-- name: SyncUserBookWithCurrentRead :exec
+UPDATE user_books SET
+    start_date = (SELECT r.start_date FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1),
+    finish_date = (SELECT r.finish_date FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1),
+    rating = COALESCE((SELECT r.rating FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1), user_books.rating),
+    review = COALESCE((SELECT r.review FROM reads r WHERE r.user_id = user_books.user_id AND r.book_id = user_books.book_id ORDER BY r.id DESC LIMIT 1), user_books.review),
+    version = user_books.version + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_books.user_id = ? AND user_books.book_id = ?;
+
+-- name: GetCurrentRead :one
+SELECT id, user_id, book_id, start_date, finish_date, status, rating, review
+FROM reads
+WHERE user_id = ? AND book_id = ?
+ORDER BY id DESC
+LIMIT 1;
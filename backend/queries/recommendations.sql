@@ -0,0 +1,36 @@
+-- name: ListLikedBooks :many
+-- the books each user rated highly, private entries only count towards their own recommendations
+SELECT user_id, book_id, CAST(rating AS INTEGER) AS rating, visibility = 'private' AS private
+FROM user_books
+WHERE rating >= 4;
+
+-- name: ListAllLibraryBooks :many
+SELECT user_id, book_id FROM user_books;
+
+-- name: ListBookMetadata :many
+SELECT id, title, author FROM books;
+
+-- name: ListBookSubjects :many
+-- the tags readers gave a book stand in for its subjects, only from public entries as
+-- recommendations are shown to anyone
+SELECT DISTINCT t.book_id, CAST(lower(trim(t.tag)) AS TEXT) AS subject
+FROM user_book_tags t
+JOIN user_books ub ON ub.user_id = t.user_id AND ub.book_id = t.book_id
+WHERE ub.visibility = 'public' AND trim(t.tag) != '';
+
+-- name: ClearRecommendations :exec
+DELETE FROM recommendations;
+
+-- name: CreateRecommendation :exec
+INSERT INTO recommendations (user_id, book_id, score, reasons, computed_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: ListRecommendations :many
+-- books added to the library since the last run are left out
+SELECT r.book_id, b.title, b.author, b.image_url, r.score, r.reasons, r.computed_at
+FROM recommendations r
+JOIN books b ON b.id = r.book_id
+WHERE r.user_id = ?
+    AND NOT EXISTS (SELECT 1 FROM user_books ub WHERE ub.user_id = r.user_id AND ub.book_id = r.book_id)
+ORDER BY r.score DESC, r.book_id
+LIMIT ?;
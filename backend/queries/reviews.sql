@@ -0,0 +1,88 @@
+-- name: GetReview :one
+-- the review on a library entry, the caller decides whether the viewer may see it from its visibility
+SELECT ub.user_id, ub.book_id, ub.review, ub.rating, ub.visibility, ub.updated_at,
+    b.isbn, b.title, b.author, b.image_url
+FROM user_books ub
+JOIN books b ON b.id = ub.book_id
+WHERE ub.user_id = ? AND ub.book_id = ?;
+
+-- name: CountReviewComments :one
+SELECT COUNT(*)
+FROM review_comments
+WHERE reviewer_id = ? AND book_id = ? AND deleted_at IS NULL AND hidden_at IS NULL;
+
+-- name: ListReviewComments :many
+-- a page of a review's comments, oldest first. Deleted and hidden comments stay in the list so
+-- the conversation around them still reads in order, the caller leaves their body out.
+SELECT c.id, c.user_id, u.username, c.body, c.created_at, c.updated_at, c.deleted_at, c.hidden_at
+FROM review_comments c
+JOIN users u ON u.id = c.user_id
+WHERE c.reviewer_id = sqlc.arg(reviewer_id) AND c.book_id = sqlc.arg(book_id)
+    AND (c.created_at > datetime(sqlc.arg(after_time)) OR (c.created_at = datetime(sqlc.arg(after_time)) AND c.id > sqlc.arg(after_id)))
+ORDER BY c.created_at, c.id
+LIMIT sqlc.arg(limit);
+
+-- name: CreateReviewComment :one
+INSERT INTO review_comments (reviewer_id, book_id, user_id, body)
+VALUES (?, ?, ?, ?)
+RETURNING id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by;
+
+-- name: GetReviewComment :one
+SELECT id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by
+FROM review_comments
+WHERE id = ?;
+
+-- name: UpdateReviewComment :one
+UPDATE review_comments SET body = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, reviewer_id, book_id, user_id, body, created_at, updated_at, deleted_at, hidden_at, hidden_by;
+
+-- name: DeleteReviewComment :exec
+UPDATE review_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL;
+
+-- name: ListReviewReactions :many
+SELECT reaction, COUNT(*) AS reactions, CAST(MAX(user_id = sqlc.arg(viewer_id)) AS BOOLEAN) AS reacted
+FROM review_reactions
+WHERE reviewer_id = sqlc.arg(reviewer_id) AND book_id = sqlc.arg(book_id)
+GROUP BY reaction
+ORDER BY reaction;
+
+-- name: AddReviewReaction :exec
+INSERT INTO review_reactions (reviewer_id, book_id, user_id, reaction)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (reviewer_id, book_id, user_id, reaction) DO NOTHING;
+
+-- name: RemoveReviewReaction :exec
+DELETE FROM review_reactions
+WHERE reviewer_id = ? AND book_id = ? AND user_id = ? AND reaction = ?;
+
+-- name: ReportReviewComment :exec
+INSERT INTO comment_reports (comment_id, user_id, reason)
+VALUES (?, ?, ?)
+ON CONFLICT (comment_id, user_id) DO UPDATE SET reason = excluded.reason;
+
+-- name: ListReportedComments :many
+-- comments waiting for an admin: reported and still shown, newest first
+SELECT c.id, c.reviewer_id, r.username AS reviewer, c.book_id, c.user_id, u.username, c.body, c.created_at,
+    COUNT(cr.user_id) AS reports, CAST(group_concat(cr.reason, char(10)) AS TEXT) AS reasons
+FROM review_comments c
+JOIN comment_reports cr ON cr.comment_id = c.id
+JOIN users u ON u.id = c.user_id
+JOIN users r ON r.id = c.reviewer_id
+WHERE c.deleted_at IS NULL AND c.hidden_at IS NULL
+    AND (c.created_at < datetime(sqlc.arg(before_time)) OR (c.created_at = datetime(sqlc.arg(before_time)) AND c.id < sqlc.arg(before_id)))
+GROUP BY c.id
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: HideReviewComment :execrows
+UPDATE review_comments SET hidden_at = CURRENT_TIMESTAMP, hidden_by = ? WHERE id = ? AND hidden_at IS NULL;
+
+-- name: RestoreReviewComment :execrows
+UPDATE review_comments SET hidden_at = NULL, hidden_by = NULL WHERE id = ? AND hidden_at IS NOT NULL;
+
+-- name: DismissCommentReports :exec
+DELETE FROM comment_reports WHERE comment_id = ?;
+
+-- name: IsAdmin :one
+SELECT is_admin FROM users WHERE id = ?;
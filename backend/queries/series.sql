@@ -0,0 +1,54 @@
+-- name: AddSeriesBook :exec
+-- for guesses like a series in an imported title, a position set by Calibre or by hand is kept
+INSERT OR IGNORE INTO series_books (series_id, book_id, position) VALUES (?, ?, ?);
+
+-- name: SetUserSeriesBook :exec
+-- a user's own entry, which only they see in place of the catalog's
+INSERT INTO series_books (series_id, book_id, user_id, position) VALUES (?, ?, ?, ?)
+ON CONFLICT (series_id, book_id, user_id) DO UPDATE SET position = excluded.position;
+
+-- name: RemoveUserSeriesBook :execrows
+DELETE FROM series_books WHERE series_id = ? AND book_id = ? AND user_id = ?;
+
+-- name: ListBookSeries :many
+-- the series a book is in for a user, manual is whether they put it there themselves
+SELECT s.id, s.name, sb.position, CAST(sb.user_id != 0 AS BOOLEAN) AS manual
+FROM series_books sb
+JOIN series s ON s.id = sb.series_id
+WHERE sb.book_id = sqlc.arg(book_id) AND sb.user_id = (
+        SELECT MAX(own.user_id) FROM series_books own
+        WHERE own.series_id = sb.series_id AND own.book_id = sb.book_id AND own.user_id IN (0, sqlc.arg(user_id))
+    )
+ORDER BY s.name;
+
+-- name: ListSeriesEntries :many
+-- the entries of series in reading order, with where the user is with each. series_id 0 means every
+-- series the user has finished an entry of. Entries are the catalog's and the user's own, theirs
+-- taking the place of the catalog's for the same book.
+SELECT sb.series_id, s.name AS series_name, b.id AS book_id, b.title, b.author, b.image_url, sb.position,
+    CAST(EXISTS (
+        SELECT 1 FROM user_books ub WHERE ub.user_id = sqlc.arg(user_id) AND ub.book_id = b.id
+    ) AS BOOLEAN) AS in_library,
+    CAST(EXISTS (
+        SELECT 1 FROM reads r WHERE r.user_id = sqlc.arg(user_id) AND r.book_id = b.id AND r.status = 'finished'
+    ) AS BOOLEAN) AS finished,
+    CAST(COALESCE((
+        SELECT r.status FROM reads r WHERE r.user_id = sqlc.arg(user_id) AND r.book_id = b.id ORDER BY r.id DESC LIMIT 1
+    ), '') AS TEXT) AS read_status,
+    CAST(COALESCE((
+        SELECT max(r.finish_date) FROM reads r WHERE r.user_id = sqlc.arg(user_id) AND r.book_id = b.id AND r.status = 'finished'
+    ), '') AS TEXT) AS last_finished
+FROM series_books sb
+JOIN series s ON s.id = sb.series_id
+JOIN books b ON b.id = sb.book_id
+WHERE sb.user_id = (
+        SELECT MAX(own.user_id) FROM series_books own
+        WHERE own.series_id = sb.series_id AND own.book_id = sb.book_id AND own.user_id IN (0, sqlc.arg(user_id))
+    )
+    AND ((CAST(sqlc.arg(series_id) AS INTEGER) = 0 AND sb.series_id IN (
+        SELECT sb2.series_id FROM series_books sb2
+        JOIN reads r ON r.book_id = sb2.book_id
+        WHERE sb2.user_id IN (0, sqlc.arg(user_id)) AND r.user_id = sqlc.arg(user_id) AND r.status = 'finished'
+    ))
+    OR sb.series_id = CAST(sqlc.arg(series_id) AS INTEGER))
+ORDER BY sb.series_id, sb.position IS NULL, sb.position, b.title;
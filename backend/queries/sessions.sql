@@ -0,0 +1,54 @@
+-- name: GetRunningSession :one
+SELECT id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+FROM reading_sessions
+WHERE user_id = ? AND ended_at IS NULL;
+
+-- name: StartReadingSession :one
+INSERT INTO reading_sessions (user_id, book_id, read_id, started_at, start_page)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page;
+
+-- name: StopReadingSession :one
+UPDATE reading_sessions
+SET ended_at = ?, end_page = ?
+WHERE id = ? AND ended_at IS NULL
+RETURNING id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page;
+
+-- name: ListReadingSessions :many
+SELECT id, user_id, book_id, read_id, started_at, ended_at, start_page, end_page
+FROM reading_sessions
+WHERE user_id = ? AND book_id = ?
+ORDER BY started_at DESC, id DESC;
+
+-- name: GetLatestPage :one
+-- where the reader is in a book as far as page progress goes
+SELECT value
+FROM progress_events
+WHERE user_id = ? AND book_id = ? AND unit = 'pages'
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: ListReadingTimes :many
+-- every time the user read something, a finished session or a progress update, for streaks
+SELECT started_at AS read_at FROM reading_sessions WHERE reading_sessions.user_id = sqlc.arg(user_id) AND ended_at IS NOT NULL
+UNION ALL
+SELECT created_at FROM progress_events WHERE progress_events.user_id = sqlc.arg(user_id)
+ORDER BY read_at;
+
+-- name: ListSessionSpeeds :many
+-- finished sessions that moved forward through pages, with the tags of their book as genres
+SELECT s.id, s.started_at, s.ended_at,
+    CAST(s.end_page - s.start_page AS INTEGER) AS pages,
+    CAST(COALESCE((
+        SELECT group_concat(t.tag, char(31)) FROM user_book_tags t
+        WHERE t.user_id = s.user_id AND t.book_id = s.book_id
+    ), '') AS TEXT) AS tags
+FROM reading_sessions s
+WHERE s.user_id = ? AND s.ended_at IS NOT NULL AND s.end_page > s.start_page
+ORDER BY s.started_at;
+
+-- name: GetTimezone :one
+SELECT timezone FROM users WHERE id = ?;
+
+-- name: UpdateTimezone :exec
+UPDATE users SET timezone = ? WHERE id = ?;
@@ -0,0 +1,65 @@
+-- name: GetStatsVersion :one
+SELECT stats_version FROM users WHERE id = ?;
+
+-- name: GetStatsSummary :one
+-- days to finish only takes in entries with a start date
+SELECT COUNT(*) AS books,
+    CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages,
+    CAST(COALESCE(AVG(CASE WHEN start_date IS NOT NULL THEN julianday(finish_date) - julianday(start_date) END), 0) AS REAL) AS average_days
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id)
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time));
+
+-- name: ListStatsByMonth :many
+SELECT CAST(strftime('%Y-%m', finish_date) AS TEXT) AS month,
+    COUNT(*) AS books,
+    CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id)
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time))
+GROUP BY month
+ORDER BY month;
+
+-- name: ListStatsRatings :many
+SELECT rating, COUNT(*) AS books
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id) AND rating IS NOT NULL AND rating > 0
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time))
+GROUP BY rating
+ORDER BY rating;
+
+-- name: ListStatsTopAuthors :many
+SELECT author, COUNT(*) AS books, CAST(COALESCE(SUM(pages), 0) AS INTEGER) AS pages
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id) AND author != ''
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time))
+GROUP BY author COLLATE NOCASE
+ORDER BY books DESC, pages DESC, author COLLATE NOCASE
+LIMIT sqlc.arg(limit);
+
+-- name: ListStatsLongestReads :many
+SELECT book_id, title, author, start_date, finish_date,
+    CAST(julianday(finish_date) - julianday(start_date) AS REAL) AS days
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id) AND start_date IS NOT NULL
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time))
+ORDER BY days DESC, book_id
+LIMIT sqlc.arg(limit);
+
+-- name: ListStatsShortestReads :many
+SELECT book_id, title, author, start_date, finish_date,
+    CAST(julianday(finish_date) - julianday(start_date) AS REAL) AS days
+FROM finished_books
+WHERE user_id = sqlc.arg(user_id) AND start_date IS NOT NULL
+    AND datetime(finish_date) >= datetime(sqlc.arg(from_time)) AND datetime(finish_date) < datetime(sqlc.arg(to_time))
+ORDER BY days, book_id
+LIMIT sqlc.arg(limit);
+
+-- name: ListStatsWeekdays :many
+-- when the user reads, from their progress updates. 0 is Sunday.
+SELECT CAST(strftime('%w', created_at) AS INTEGER) AS weekday, COUNT(*) AS updates
+FROM progress_events
+WHERE user_id = sqlc.arg(user_id)
+    AND datetime(created_at) >= datetime(sqlc.arg(from_time)) AND datetime(created_at) < datetime(sqlc.arg(to_time))
+GROUP BY weekday
+ORDER BY weekday;
@@ -0,0 +1,40 @@
+-- name: GetExternalBook :one
+SELECT book_id FROM external_books WHERE user_id = ? AND source = ? AND external_id = ?;
+
+-- name: LinkExternalBook :exec
+INSERT INTO external_books (user_id, source, external_id, book_id, synced_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, source, external_id) DO UPDATE SET book_id = excluded.book_id, synced_at = CURRENT_TIMESTAMP;
+
+-- name: GetBookByIdentifier :one
+SELECT b.id, b.isbn, b.title, b.description, b.author, b.image_url
+FROM book_identifiers bi
+JOIN books b ON b.id = bi.book_id
+WHERE bi.type = ? AND bi.value = ?;
+
+-- name: AddBookIdentifier :execrows
+INSERT OR IGNORE INTO book_identifiers (book_id, type, value) VALUES (?, ?, ?);
+
+-- name: UpsertSeries :one
+INSERT INTO series (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = series.name
+RETURNING id;
+
+-- name: SetSeriesBook :execrows
+-- the catalog's entry, a later sync moves it
+INSERT INTO series_books (series_id, book_id, position) VALUES (?, ?, ?)
+ON CONFLICT (series_id, book_id, user_id) DO UPDATE SET position = excluded.position
+WHERE series_books.position IS NOT excluded.position;
+
+-- name: ListSourceTags :many
+SELECT tag FROM user_book_tags WHERE user_id = ? AND book_id = ? AND source = ? ORDER BY tag;
+
+-- name: ClearSourceTags :exec
+DELETE FROM user_book_tags WHERE user_id = ? AND book_id = ? AND source = ?;
+
+-- name: AddUserBookTag :exec
+INSERT OR IGNORE INTO user_book_tags (user_id, book_id, tag, source) VALUES (?, ?, ?, ?);
+
+-- name: SetUserBookRating :execrows
+UPDATE user_books SET rating = sqlc.arg(rating), version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id) AND book_id = sqlc.arg(book_id) AND (rating IS NULL OR rating != sqlc.arg(rating));
@@ -0,0 +1,6 @@
+-- name: ListUserTags :many
+SELECT tag, COUNT(*) AS books
+FROM user_book_tags
+WHERE user_id = ?
+GROUP BY tag
+ORDER BY tag COLLATE NOCASE;
@@ -0,0 +1,20 @@
+-- name: CreateAccessToken :one
+INSERT INTO access_tokens (user_id, name, token_hash) VALUES (?, ?, ?)
+RETURNING id, user_id, name, token_hash, created_at, last_used_at;
+
+-- name: ListAccessTokens :many
+SELECT id, user_id, name, token_hash, created_at, last_used_at
+FROM access_tokens
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: DeleteAccessToken :execrows
+DELETE FROM access_tokens WHERE id = ? AND user_id = ?;
+
+-- name: GetAccessTokenByHash :one
+SELECT id, user_id, name, token_hash, created_at, last_used_at
+FROM access_tokens
+WHERE token_hash = ?;
+
+-- name: TouchAccessToken :exec
+UPDATE access_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?;
@@ -0,0 +1,23 @@
+-- name: GetYearReviewSettings :one
+-- the owner of a report by username and whether they shared the year, a year never set is private
+SELECT u.id, u.username, CAST(COALESCE(yr.public, FALSE) AS BOOLEAN) AS public
+FROM users u
+LEFT JOIN year_reviews yr ON yr.user_id = u.id AND yr.year = sqlc.arg(year)
+WHERE u.username = sqlc.arg(username);
+
+-- name: SetYearReviewPublic :exec
+INSERT INTO year_reviews (user_id, year, public)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id, year) DO UPDATE SET public = excluded.public, updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListYearInReviewBooks :many
+-- the reads finished in a year with their covers, in the order they were finished, so a book
+-- read twice is in it twice. Anyone but the owner only gets the public ones.
+SELECT fb.book_id, fb.title, fb.author, b.image_url, fb.finish_date, fb.rating, fb.pages
+FROM finished_books fb
+JOIN books b ON b.id = fb.book_id
+JOIN user_books ub ON ub.user_id = fb.user_id AND ub.book_id = fb.book_id
+WHERE fb.user_id = sqlc.arg(user_id)
+    AND (CAST(sqlc.arg(owner) AS BOOLEAN) OR ub.visibility = 'public')
+    AND CAST(strftime('%Y', fb.finish_date) AS INTEGER) = CAST(sqlc.arg(year) AS INTEGER)
+ORDER BY fb.finish_date, fb.read_id;
@@ -65,6 +65,9 @@ interface UseBookReturn {
 
 const API_BASE_URL = createApiUrl('/user/books');
 
+// ETags from the last fetch of each book, sent back as If-Match on update
+const bookETags = new Map<string, string>();
+
 const initialLoadingState: LoadingState = {
   fetchAll: false,
   create: false,
@@ -141,6 +144,9 @@ export const useBooks = (): UseBookReturn => {
         },
       });
       if (!response.ok) throw new Error('Book not found');
+
+      const etag = response.headers.get('ETag');
+      if (etag) bookETags.set(id, etag);
       
       return await response.json();
     } catch (err) {
@@ -179,12 +185,29 @@ export const useBooks = (): UseBookReturn => {
   const updateBook = useCallback(async (id: string, bookUpdate: Partial<UserBook>): Promise<BookResponse> => {
     try {
       setOperationLoading('update', true, id);
+
+      // books edited from the list view haven't been fetched on their own, fetch the ETag
+      // rather than updating without a concurrency check
+      let etag = bookETags.get(id);
+      if (!etag) {
+        const current = await fetch(`${API_BASE_URL}/${id}`, {
+          method: 'GET',
+          headers: {
+            'Authorization': `Bearer ${user}`,
+          },
+        });
+        if (!current.ok) throw new Error('Book not found');
+        etag = current.headers.get('ETag') ?? undefined;
+        if (!etag) throw new Error('Could not check the book for changes, reload and try again');
+        bookETags.set(id, etag);
+      }
       
       const response = await fetch(`${API_BASE_URL}/${id}`, {
         method: 'PUT',
         headers: {
           'Content-Type': 'application/json',
           'Authorization': `Bearer ${user}`, // Assuming you have a token
+          'If-Match': etag,
         },
         body: JSON.stringify({
             id: bookUpdate.id,
@@ -196,7 +219,11 @@ export const useBooks = (): UseBookReturn => {
         }),
       });
       
+      if (response.status === 412) throw new Error('Book was changed elsewhere, reload to see the latest version');
       if (!response.ok) throw new Error('Failed to update book');
+
+      const newETag = response.headers.get('ETag');
+      if (newETag) bookETags.set(id, newETag);
       
       return await response.json();
     } catch (err) {